@@ -0,0 +1,130 @@
+// Command migrate manages the database schema. It reads the same settings
+// as the server (see internal/config).
+//
+//	migrate [flags] [command]
+//
+// Commands:
+//
+//	up [N]    apply all pending migrations, or only the next N (default command)
+//	down [N]  revert the last migration, or the last N
+//	redo      revert and re-apply the last migration
+//	status    list migrations and when they were applied
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"todo/internal/config"
+	"todo/internal/database"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg, args, err := config.LoadArgs(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := database.Connect(context.Background(), cfg.Database)
+	if err != nil {
+		log.Fatalf("failed to connect to database: %v", err)
+	}
+	if err := run(db, args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(db *gorm.DB, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "up":
+		n, err := count(args)
+		if err != nil {
+			return err
+		}
+		applied, err := database.MigrateUp(db, n)
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+	case "down":
+		n, err := count(args)
+		if err != nil {
+			return err
+		}
+		reverted, err := database.MigrateDown(db, n)
+		for _, m := range reverted {
+			fmt.Printf("reverted %d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "redo":
+		if len(args) > 0 {
+			return fmt.Errorf("redo takes no arguments")
+		}
+		m, err := database.MigrateRedo(db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("redid %d_%s\n", m.Version, m.Name)
+		return nil
+	case "status":
+		if len(args) > 0 {
+			return fmt.Errorf("status takes no arguments")
+		}
+		statuses, err := database.MigrationStatuses(db)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown command %q, want up, down, redo or status", command)
+	}
+}
+
+// count parses the optional N of up and down; 0 means the command's default.
+func count(args []string) (int, error) {
+	switch len(args) {
+	case 0:
+		return 0, nil
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("%q is not a positive number of migrations", args[0])
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("too many arguments")
+	}
+}
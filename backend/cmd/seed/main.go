@@ -0,0 +1,105 @@
+// Command seed inserts tasks into the database. It reads the same settings
+// as the server (see internal/config) and only adds rows that are missing, so
+// it is safe to run repeatedly.
+//
+//	seed [flags] [command]
+//
+// Commands:
+//
+//	samples            insert the built-in sample tasks (default command)
+//	load FILE...       insert the tasks of YAML or JSON fixture files
+//	generate N [SEED]  insert N synthetic tasks for load testing; the same
+//	                   SEED (default 1) always generates the same tasks
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"todo/internal/config"
+	"todo/internal/database"
+	"todo/internal/models"
+	"todo/internal/seed"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg, args, err := config.LoadArgs(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tasks, err := tasksFor(args, time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := database.InitDB(context.Background(), cfg.Database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := seed.Apply(db, tasks)
+	if err != nil {
+		log.Fatalf("seeding failed: %v", err)
+	}
+	fmt.Println(result)
+}
+
+func tasksFor(args []string, now time.Time) ([]models.Task, error) {
+	command := "samples"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "samples":
+		if len(args) > 0 {
+			return nil, fmt.Errorf("samples takes no arguments")
+		}
+		return seed.Samples(now), nil
+	case "load":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("load needs at least one fixture file")
+		}
+		var tasks []models.Task
+		for _, path := range args {
+			loaded, err := seed.LoadFile(path, now)
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, loaded...)
+		}
+		return tasks, nil
+	case "generate":
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("usage: generate N [SEED]")
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%q is not a positive number of tasks", args[0])
+		}
+		var randomSeed int64 = 1
+		if len(args) == 2 {
+			if randomSeed, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+				return nil, fmt.Errorf("%q is not a valid seed", args[1])
+			}
+		}
+		return seed.Generate(n, randomSeed, now), nil
+	default:
+		return nil, fmt.Errorf("unknown command %q, want samples, load or generate", command)
+	}
+}
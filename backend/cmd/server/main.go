@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"todo/internal/config"
+	"todo/internal/database"
+	"todo/internal/handlers"
+	"todo/internal/health"
+	"todo/internal/idempotency"
+	"todo/internal/logging"
+	"todo/internal/metrics"
+	"todo/internal/ratelimit"
+	"todo/internal/repository"
+	"todo/internal/rpc"
+	"todo/internal/seed"
+	"todo/internal/services"
+	"todo/internal/tracing"
+
+	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger := logging.New(cfg.Log, os.Stderr)
+	slog.SetDefault(logger)
+
+	tp, shutdownTracing, err := tracing.New(context.Background(), cfg.Tracing, os.Stdout)
+	if err != nil {
+		fatal(logger, "failed to set up tracing", err)
+	}
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(tracing.Propagator)
+
+	// The first SIGINT or SIGTERM starts a graceful shutdown; a second one
+	// kills the server right away.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.InitDB(ctx, cfg.Database)
+	if err != nil {
+		fatal(logger, "failed to open database", err)
+	}
+	if cfg.Seed {
+		result, err := seed.Apply(db, seed.Samples(time.Now()))
+		if err != nil {
+			fatal(logger, "failed to seed database", err)
+		}
+		logger.Info("seeded sample tasks", slog.String("result", result.String()))
+	}
+
+	svc := services.New(repository.NewGormTaskRepository(db), repository.NewGormSubtaskRepository(db), repository.NewGormViewRepository(db))
+
+	if err := tracing.InstrumentDB(db, tp); err != nil {
+		fatal(logger, "failed to trace database", err)
+	}
+	m := metrics.New()
+	if err := m.InstrumentDB(db, cfg.Database.Name); err != nil {
+		fatal(logger, "failed to instrument database", err)
+	}
+	if err := m.RegisterTaskCounts(svc.OpenTaskCounts); err != nil {
+		fatal(logger, "failed to register task metrics", err)
+	}
+
+	checker := health.New()
+	// Liveness stays process-local, so that a database outage takes pods out
+	// of rotation instead of restarting all of them at once.
+	checker.AddReadiness("database", func(ctx context.Context) error { return database.Ping(ctx, db) })
+	checker.AddReadiness("migrations", func(ctx context.Context) error { return database.CheckMigrations(ctx, db) })
+
+	appOpts := []handlers.AppOption{
+		handlers.WithLogger(logger), handlers.WithLogSampleRate(cfg.Log.SampleRate), handlers.WithMetrics(m), handlers.WithTracerProvider(tp),
+		handlers.WithHealth(checker), handlers.WithIdempotency(idempotency.NewGormStore(db), cfg.Idempotency.TTL),
+	}
+	if cfg.RateLimit.Enabled {
+		limiter, err := newRateLimiter(cfg.RateLimit)
+		if err != nil {
+			fatal(logger, "failed to set up rate limiting", err)
+		}
+		appOpts = append(appOpts, handlers.WithRateLimiter(limiter))
+	}
+	if cfg.ResponseCache.Enabled {
+		appOpts = append(appOpts, handlers.WithResponseCache(cfg.ResponseCache.TTL))
+	}
+	app := handlers.NewApp(handlers.New(svc), cfg.HTTP.CORSOrigins, appOpts...)
+	grpcServer := rpc.NewServer(svc)
+
+	lis, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.GRPC.Port))
+	if err != nil {
+		fatal(logger, "failed to listen on gRPC port", err)
+	}
+	serveErr := make(chan error, 2)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			serveErr <- fmt.Errorf("gRPC server: %w", err)
+		}
+	}()
+	go func() {
+		if err := app.Listen(":" + strconv.Itoa(cfg.HTTP.Port)); err != nil {
+			serveErr <- fmt.Errorf("HTTP server: %w", err)
+		}
+	}()
+
+	var failed error
+	select {
+	case <-ctx.Done():
+		logger.Info("shutting down", slog.Duration("timeout", cfg.ShutdownTimeout))
+	case failed = <-serveErr:
+		logger.Error("server stopped", slog.Any("error", failed))
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	checker.Drain()
+	// End the WatchTasks streams, which would otherwise hold up GracefulStop.
+	svc.Close()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+			logger.Error("HTTP requests did not finish in time", slog.Any("error", err))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		stopGRPC(shutdownCtx, grpcServer)
+	}()
+	wg.Wait()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("failed to flush traces", slog.Any("error", err))
+	}
+	if err := database.Close(db); err != nil {
+		logger.Error("failed to close database", slog.Any("error", err))
+	}
+	if failed != nil {
+		os.Exit(1)
+	}
+	logger.Info("shutdown complete")
+}
+
+// newRateLimiter keeps the budgets of cfg in memory, which suits a single
+// server. Replicas sharing a budget need a ratelimit.Store they all reach.
+func newRateLimiter(cfg config.RateLimit) (*ratelimit.Limiter, error) {
+	read, err := ratelimit.ParseLimit(cfg.Read)
+	if err != nil {
+		return nil, err
+	}
+	write, err := ratelimit.ParseLimit(cfg.Write)
+	if err != nil {
+		return nil, err
+	}
+	var routes []ratelimit.Route
+	for key, budget := range cfg.Routes {
+		method, path, err := ratelimit.ParseRoute(key)
+		if err != nil {
+			return nil, err
+		}
+		limit, err := ratelimit.ParseLimit(budget)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, ratelimit.Route{Method: method, Path: path, Limit: limit})
+	}
+	return ratelimit.New(ratelimit.NewMemoryStore(), read, write, routes...), nil
+}
+
+// stopGRPC waits for running calls to finish, and cancels those still
+// running when ctx is done.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, slog.Any("error", err))
+	os.Exit(1)
+}
@@ -1,14 +1,11 @@
 #!/bin/sh
-echo "Waiting for MySQL..."
-while ! nc -z db 3306; do
-  sleep 0.1
-done
-echo "MySQL is up - executing migration"
-./migrate
+# Both commands wait for the database to come up (DB_CONNECT_TIMEOUT).
+./migrate up
 if [ $? -eq 0 ]; then
   echo "Migration successful - starting server"
-  ./server
+  # exec so that the server receives SIGTERM and shuts down gracefully.
+  exec ./server
 else
   echo "Migration failed"
   exit 1
-fi
\ No newline at end of file
+fi
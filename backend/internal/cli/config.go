@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// Config holds the CLI settings. Values come from, in increasing order of
+// precedence: defaults, the config file, TODO_* environment variables and
+// command-line flags.
+type Config struct {
+	Server string `yaml:"server"`
+	Token  string `yaml:"token"`
+	Output string `yaml:"output"`
+}
+
+// defaultConfigPath is $XDG_CONFIG_HOME/todo/config.yaml or its platform
+// equivalent.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "todo", "config.yaml")
+}
+
+// loadConfig reads path, falling back to TODO_CONFIG and then the default
+// location. A missing file is only an error when the path was given
+// explicitly.
+func loadConfig(path string) (Config, error) {
+	cfg := Config{Server: defaultServer, Output: "table"}
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("TODO_CONFIG")
+		explicit = path != ""
+	}
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				return cfg, fmt.Errorf("reading config %s: %w", path, err)
+			}
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+		default:
+			return cfg, fmt.Errorf("reading config: %w", err)
+		}
+	}
+	if v := os.Getenv("TODO_SERVER"); v != "" {
+		cfg.Server = v
+	}
+	if v := os.Getenv("TODO_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	if v := os.Getenv("TODO_OUTPUT"); v != "" {
+		cfg.Output = v
+	}
+	return cfg, nil
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"todo/pkg/client"
+
+	"gopkg.in/yaml.v3"
+)
+
+var outputFormats = []string{"table", "json", "yaml"}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printYAML(w io.Writer, v interface{}) error {
+	// Round-trip through JSON so the YAML keys match the API's field names.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(generic)
+}
+
+// render writes v in the requested format, using table for the table format.
+func render(w io.Writer, format string, v interface{}, table func(*tabwriter.Writer)) error {
+	switch format {
+	case "json":
+		return printJSON(w, v)
+	case "yaml":
+		return printYAML(w, v)
+	case "table", "":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown output format %q (want one of %v)", format, outputFormats)
+}
+
+func taskTable(tasks []client.Task) func(*tabwriter.Writer) {
+	return func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tDONE\tPRIORITY\tDUE\tASSIGNEE\tSUBTASKS\tTITLE")
+		for _, t := range tasks {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				t.ID, check(t.Done), t.Priority, dueString(t), dash(t.Assignee), subtaskProgress(t.Subtasks), t.Title)
+		}
+	}
+}
+
+func subtaskTable(subtasks []client.Subtask) func(*tabwriter.Writer) {
+	return func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tTASK\tDONE\tTITLE")
+		for _, s := range subtasks {
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%s\n", s.ID, s.TaskID, check(s.Done), s.Title)
+		}
+	}
+}
+
+func check(done bool) string {
+	if done {
+		return "x"
+	}
+	return " "
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// dueString shows the day a task is due in the time zone it was set in.
+func dueString(t client.Task) string {
+	if t.DueDate == nil {
+		return "-"
+	}
+	due := *t.DueDate
+	if loc, err := time.LoadLocation(t.DueTimezone); err == nil {
+		due = due.In(loc)
+	}
+	return due.Format("2006-01-02")
+}
+
+func subtaskProgress(subtasks []client.Subtask) string {
+	if len(subtasks) == 0 {
+		return "-"
+	}
+	done := 0
+	for _, s := range subtasks {
+		if s.Done {
+			done++
+		}
+	}
+	return strconv.Itoa(done) + "/" + strconv.Itoa(len(subtasks))
+}
@@ -0,0 +1,139 @@
+// Package cli implements the todo command-line client. It talks to the
+// server over HTTP through pkg/client.
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"todo/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+type app struct {
+	configPath string
+	server     string
+	token      string
+	output     string
+	timeout    time.Duration
+
+	cfg    Config
+	client *client.Client
+}
+
+// NewRootCommand returns the todo command tree, including the completion
+// subcommand for bash, zsh, fish and PowerShell.
+func NewRootCommand() *cobra.Command {
+	a := &app{}
+	root := &cobra.Command{
+		Use:           "todo",
+		Short:         "Manage tasks and subtasks from the terminal",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return a.init(cmd)
+		},
+	}
+	flags := root.PersistentFlags()
+	flags.StringVar(&a.configPath, "config", "", "config file (default $XDG_CONFIG_HOME/todo/config.yaml, or $TODO_CONFIG)")
+	flags.StringVar(&a.server, "server", "", "server base URL (default "+defaultServer+", or $TODO_SERVER)")
+	flags.StringVar(&a.token, "token", "", "API token (or $TODO_TOKEN)")
+	flags.StringVarP(&a.output, "output", "o", "", "output format: table, json or yaml (or $TODO_OUTPUT)")
+	flags.DurationVar(&a.timeout, "timeout", 10*time.Second, "request timeout")
+	root.RegisterFlagCompletionFunc("output", fixedCompletion(outputFormats...))
+
+	root.AddCommand(
+		a.listCommand(),
+		a.showCommand(),
+		a.addCommand(),
+		a.editCommand(),
+		a.doneCommand("done", true),
+		a.doneCommand("undone", false),
+		a.rmCommand(),
+		a.subtaskCommand(),
+	)
+	return root
+}
+
+func (a *app) init(cmd *cobra.Command) error {
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return err
+	}
+	if a.server != "" {
+		cfg.Server = a.server
+	}
+	if a.token != "" {
+		cfg.Token = a.token
+	}
+	if a.output != "" {
+		cfg.Output = a.output
+	}
+	a.cfg = cfg
+	opts := []client.Option{client.WithTimeout(a.timeout), client.WithUserAgent("todo-cli")}
+	if cfg.Token != "" {
+		opts = append(opts, client.WithToken(cfg.Token))
+	}
+	a.client = client.New(cfg.Server, opts...)
+	return nil
+}
+
+func parseID(s string) (uint, error) {
+	id, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID %q", s)
+	}
+	return uint(id), nil
+}
+
+// parseDue accepts a date (2006-01-02), which makes a date-only deadline in
+// UTC, or an RFC 3339 timestamp; "none" clears the due date.
+func parseDue(s string) (due *time.Time, allDay bool, err error) {
+	if s == "none" || s == "" {
+		return nil, false, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return &t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid due date %q: use YYYY-MM-DD or RFC 3339", s)
+	}
+	return &t, false, nil
+}
+
+func fixedCompletion(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeTaskIDs offers the IDs of open tasks, annotated with their titles.
+func (a *app) completeTaskIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if err := a.init(cmd); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	tasks, err := a.client.ListTasks(cmd.Context(), client.ListTasksOptions{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var ids []string
+	for _, t := range tasks {
+		id := strconv.FormatUint(uint64(t.ID), 10)
+		if strings.HasPrefix(id, toComplete) {
+			ids = append(ids, id+"\t"+t.Title)
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeOneTaskID is completeTaskIDs for commands taking a single task ID
+// as their first argument.
+func (a *app) completeOneTaskID(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return a.completeTaskIDs(cmd, args, toComplete)
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"todo/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+func (a *app) subtaskCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "subtask",
+		Aliases: []string{"sub"},
+		Short:   "Manage the subtasks of a task",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:               "list TASK_ID",
+			Aliases:           []string{"ls"},
+			Short:             "List the subtasks of a task",
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: a.completeOneTaskID,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				taskID, err := parseID(args[0])
+				if err != nil {
+					return err
+				}
+				subtasks, err := a.client.ListSubtasks(cmd.Context(), taskID)
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), a.cfg.Output, subtasks, subtaskTable(subtasks))
+			},
+		},
+		&cobra.Command{
+			Use:               "add TASK_ID TITLE",
+			Short:             "Add a subtask to a task",
+			Args:              cobra.ExactArgs(2),
+			ValidArgsFunction: a.completeOneTaskID,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				taskID, err := parseID(args[0])
+				if err != nil {
+					return err
+				}
+				subtask, err := a.client.CreateSubtask(cmd.Context(), taskID, client.SubtaskInput{Title: args[1]})
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), a.cfg.Output, subtask, subtaskTable([]client.Subtask{*subtask}))
+			},
+		},
+		&cobra.Command{
+			Use:   "edit ID TITLE",
+			Short: "Rename a subtask",
+			Args:  cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				id, err := parseID(args[0])
+				if err != nil {
+					return err
+				}
+				subtask, err := a.client.UpdateSubtask(cmd.Context(), id, client.SubtaskInput{Title: args[1]})
+				if err != nil {
+					return err
+				}
+				return render(cmd.OutOrStdout(), a.cfg.Output, subtask, subtaskTable([]client.Subtask{*subtask}))
+			},
+		},
+		a.subtaskDoneCommand("done", true),
+		a.subtaskDoneCommand("undone", false),
+		&cobra.Command{
+			Use:   "rm ID...",
+			Short: "Delete subtasks",
+			Args:  cobra.MinimumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				for _, arg := range args {
+					id, err := parseID(arg)
+					if err != nil {
+						return err
+					}
+					if err := a.client.DeleteSubtask(cmd.Context(), id); err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.ErrOrStderr(), "Deleted subtask %d\n", id)
+				}
+				return nil
+			},
+		},
+	)
+	return cmd
+}
+
+func (a *app) subtaskDoneCommand(name string, done bool) *cobra.Command {
+	short := "Mark subtasks as done"
+	if !done {
+		short = "Mark subtasks as not done"
+	}
+	return &cobra.Command{
+		Use:   name + " ID...",
+		Short: short,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var subtasks []client.Subtask
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				subtask, err := a.client.SetSubtaskDone(cmd.Context(), id, done)
+				if err != nil {
+					return err
+				}
+				subtasks = append(subtasks, *subtask)
+			}
+			return render(cmd.OutOrStdout(), a.cfg.Output, subtasks, subtaskTable(subtasks))
+		},
+	}
+}
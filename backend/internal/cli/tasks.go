@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"todo/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+var priorities = []string{string(client.PriorityLow), string(client.PriorityMedium), string(client.PriorityHigh)}
+
+func (a *app) listCommand() *cobra.Command {
+	var opts struct {
+		assignee string
+		search   string
+		status   string
+		sortBy   string
+		query    string
+	}
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List tasks",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tasks, err := a.client.ListTasks(cmd.Context(), client.ListTasksOptions{
+				Assignee: opts.assignee,
+				Search:   opts.search,
+				Status:   client.Status(opts.status),
+				SortBy:   client.SortBy(opts.sortBy),
+				Query:    opts.query,
+			})
+			if err != nil {
+				return err
+			}
+			return render(cmd.OutOrStdout(), a.cfg.Output, tasks, taskTable(tasks))
+		},
+	}
+	cmd.Flags().StringVar(&opts.assignee, "assignee", "", "only tasks assigned to this person")
+	cmd.Flags().StringVar(&opts.search, "search", "", "words in the title or description")
+	cmd.Flags().StringVar(&opts.status, "status", "", "completed or pending")
+	cmd.Flags().StringVar(&opts.sortBy, "sort", "", "dueDate or priority")
+	cmd.Flags().StringVarP(&opts.query, "query", "q", "", `task query, such as "priority:High -done due<7d"`)
+	cmd.RegisterFlagCompletionFunc("status", fixedCompletion(string(client.StatusCompleted), string(client.StatusPending)))
+	cmd.RegisterFlagCompletionFunc("sort", fixedCompletion(string(client.SortByDueDate), string(client.SortByPriority)))
+	return cmd
+}
+
+func (a *app) showCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "show ID",
+		Short:             "Show a task and its subtasks",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeOneTaskID,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			task, err := a.client.GetTask(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+			return render(cmd.OutOrStdout(), a.cfg.Output, task, func(tw *tabwriter.Writer) {
+				taskTable([]client.Task{*task})(tw)
+				if len(task.Subtasks) > 0 {
+					fmt.Fprintln(tw)
+					subtaskTable(task.Subtasks)(tw)
+				}
+			})
+		},
+	}
+}
+
+type taskFlags struct {
+	title       string
+	description string
+	priority    string
+	assignee    string
+	due         string
+}
+
+func (f *taskFlags) register(cmd *cobra.Command, withTitle bool) {
+	if withTitle {
+		cmd.Flags().StringVar(&f.title, "title", "", "new title")
+	}
+	cmd.Flags().StringVarP(&f.description, "description", "d", "", "description")
+	cmd.Flags().StringVarP(&f.priority, "priority", "p", "", "Low, Medium or High")
+	cmd.Flags().StringVarP(&f.assignee, "assignee", "a", "", "assignee")
+	cmd.Flags().StringVar(&f.due, "due", "", "due date, YYYY-MM-DD or RFC 3339; \"none\" clears it")
+	cmd.RegisterFlagCompletionFunc("priority", fixedCompletion(priorities...))
+}
+
+func (a *app) addCommand() *cobra.Command {
+	var f taskFlags
+	cmd := &cobra.Command{
+		Use:   "add TITLE",
+		Short: "Create a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			due, allDay, err := parseDue(f.due)
+			if err != nil {
+				return err
+			}
+			priority := client.Priority(f.priority)
+			if priority == "" {
+				priority = client.PriorityMedium
+			}
+			task, err := a.client.CreateTask(cmd.Context(), client.TaskInput{
+				Title:       args[0],
+				Description: f.description,
+				Priority:    priority,
+				Assignee:    f.assignee,
+				DueDate:     due,
+				DueAllDay:   allDay,
+			})
+			if err != nil {
+				return err
+			}
+			return render(cmd.OutOrStdout(), a.cfg.Output, task, taskTable([]client.Task{*task}))
+		},
+	}
+	f.register(cmd, false)
+	return cmd
+}
+
+func (a *app) editCommand() *cobra.Command {
+	var f taskFlags
+	cmd := &cobra.Command{
+		Use:               "edit ID",
+		Short:             "Change the fields of a task given as flags",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeOneTaskID,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			task, err := a.client.GetTask(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+			// The API replaces every field, so start from the current values.
+			in := client.TaskInput{
+				Title:       task.Title,
+				Description: task.Description,
+				Priority:    task.Priority,
+				Assignee:    task.Assignee,
+				DueDate:     task.DueDate,
+				DueAllDay:   task.DueAllDay,
+				DueTimezone: task.DueTimezone,
+			}
+			changed := cmd.Flags().Changed
+			if changed("title") {
+				in.Title = f.title
+			}
+			if changed("description") {
+				in.Description = f.description
+			}
+			if changed("priority") {
+				in.Priority = client.Priority(f.priority)
+			}
+			if changed("assignee") {
+				in.Assignee = f.assignee
+			}
+			if changed("due") {
+				if in.DueDate, in.DueAllDay, err = parseDue(f.due); err != nil {
+					return err
+				}
+				in.DueTimezone = ""
+			}
+			updated, err := a.client.UpdateTask(cmd.Context(), id, in)
+			if err != nil {
+				return err
+			}
+			return render(cmd.OutOrStdout(), a.cfg.Output, updated, taskTable([]client.Task{*updated}))
+		},
+	}
+	f.register(cmd, true)
+	return cmd
+}
+
+func (a *app) doneCommand(name string, done bool) *cobra.Command {
+	short := "Mark tasks as done"
+	if !done {
+		short = "Mark tasks as not done"
+	}
+	return &cobra.Command{
+		Use:               name + " ID...",
+		Short:             short,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var tasks []client.Task
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				task, err := a.client.SetTaskDone(cmd.Context(), id, done)
+				if err != nil {
+					return err
+				}
+				tasks = append(tasks, *task)
+			}
+			return render(cmd.OutOrStdout(), a.cfg.Output, tasks, taskTable(tasks))
+		},
+	}
+}
+
+func (a *app) rmCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm ID...",
+		Short:             "Delete tasks",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: a.completeTaskIDs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				if err := a.client.DeleteTask(cmd.Context(), id); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Deleted task %d\n", id)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,473 @@
+// Package config loads the server configuration. Settings are resolved in
+// this order, later sources overriding earlier ones:
+//
+//  1. built-in defaults
+//  2. a YAML (.yaml, .yml) or TOML (.toml) file named by --config or CONFIG_FILE
+//  3. environment variables
+//  4. command-line flags
+//
+// The result is validated once at startup and passed explicitly to the
+// database and HTTP layers.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"todo/internal/ratelimit"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	HTTP     HTTP     `yaml:"http" toml:"http"`
+	GRPC     GRPC     `yaml:"grpc" toml:"grpc"`
+	Database Database `yaml:"database" toml:"database"`
+	Log      Log      `yaml:"log" toml:"log"`
+	Tracing  Tracing  `yaml:"tracing" toml:"tracing"`
+	// RateLimit is the request budget of each client of the HTTP API.
+	RateLimit RateLimit `yaml:"rate_limit" toml:"rate_limit"`
+	// Idempotency governs POST requests sent with an Idempotency-Key.
+	Idempotency Idempotency `yaml:"idempotency" toml:"idempotency"`
+	// ResponseCache keeps the responses of task reads in memory.
+	ResponseCache ResponseCache `yaml:"response_cache" toml:"response_cache"`
+	// Seed inserts the sample tasks at startup if they are missing. The
+	// seed command offers more control.
+	Seed bool `yaml:"seed" toml:"seed"`
+	// ShutdownTimeout bounds how long the server waits for in-flight
+	// requests and open streams to finish once asked to stop.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+type HTTP struct {
+	Port        int      `yaml:"port" toml:"port"`
+	CORSOrigins []string `yaml:"cors_origins" toml:"cors_origins"`
+}
+
+type GRPC struct {
+	Port int `yaml:"port" toml:"port"`
+}
+
+type Database struct {
+	// Driver is mysql, postgres or sqlite.
+	Driver string `yaml:"driver" toml:"driver"`
+	// DSN, when set, is passed to the driver as is and the connection
+	// fields below are ignored. For sqlite it is the database file.
+	DSN  string `yaml:"dsn" toml:"dsn"`
+	Host string `yaml:"host" toml:"host"`
+	// Port 0 selects the driver's default port.
+	Port     int    `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+	// AutoMigrate applies pending schema migrations at startup instead of
+	// refusing to start.
+	AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate"`
+	// ConnectTimeout is how long to keep retrying while the database is not
+	// reachable yet. 0 tries once.
+	ConnectTimeout time.Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+}
+
+type Log struct {
+	// Level is the minimum level logged: debug, info, warn or error.
+	Level string `yaml:"level" toml:"level"`
+	// SampleRate is the fraction of successful requests written to the
+	// access log, between 0 and 1. Failed requests are always logged.
+	SampleRate float64 `yaml:"sample_rate" toml:"sample_rate"`
+}
+
+// LogLevels lists the supported values of Log.Level.
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+type Tracing struct {
+	// Exporter is where spans go: none, stdout, otlp-grpc or otlp-http.
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// Endpoint is the URL of the OTLP collector, such as
+	// http://localhost:4318. When empty the exporter falls back to the
+	// standard OTEL_EXPORTER_OTLP_* variables and then to localhost.
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	// SampleRatio is the fraction of new traces recorded, between 0 and 1.
+	// Requests that arrive with a sampled traceparent are always recorded.
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+type RateLimit struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Read is the budget of GET and HEAD requests, such as 300/m. See
+	// ratelimit.ParseLimit for the format.
+	Read string `yaml:"read" toml:"read"`
+	// Write is the budget of every other request.
+	Write string `yaml:"write" toml:"write"`
+	// Routes gives single routes a budget of their own, keyed by method and
+	// route template, such as "POST /tasks": "10/m".
+	Routes map[string]string `yaml:"routes" toml:"routes"`
+}
+
+type Idempotency struct {
+	// TTL is how long the response to a request with an Idempotency-Key is
+	// kept and replayed to retries.
+	TTL time.Duration `yaml:"ttl" toml:"ttl"`
+}
+
+type ResponseCache struct {
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// TTL bounds how long a response is kept, and so how long writes made
+	// by other servers on the same database go unnoticed.
+	TTL time.Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// TracingExporters lists the supported values of Tracing.Exporter.
+var TracingExporters = []string{"none", "stdout", "otlp-grpc", "otlp-http"}
+
+// Drivers lists the supported values of Database.Driver.
+var Drivers = []string{"mysql", "postgres", "sqlite"}
+
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Port:        3000,
+			CORSOrigins: []string{"http://localhost:5173"},
+		},
+		GRPC: GRPC{Port: 50051},
+		Database: Database{
+			Driver:         "mysql",
+			Host:           "localhost",
+			User:           "root",
+			Name:           "todo",
+			ConnectTimeout: 30 * time.Second,
+		},
+		Log: Log{
+			Level:      "info",
+			SampleRate: 1,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Read:    "300/m",
+			Write:   "60/m",
+		},
+		Idempotency:     Idempotency{TTL: 24 * time.Hour},
+		ResponseCache:   ResponseCache{TTL: 5 * time.Second},
+		ShutdownTimeout: 10 * time.Second,
+	}
+}
+
+// setting ties one field to its environment variable and flag.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	set   func(*Config, string) error
+}
+
+var settings = []setting{
+	{"PORT", "port", "HTTP listen port", intField(func(c *Config) *int { return &c.HTTP.Port })},
+	{"CORS_ORIGINS", "cors-origins", "comma-separated origins allowed by CORS", func(c *Config, v string) error {
+		c.HTTP.CORSOrigins = splitList(v)
+		return nil
+	}},
+	{"GRPC_PORT", "grpc-port", "gRPC listen port", intField(func(c *Config) *int { return &c.GRPC.Port })},
+	{"DB_DRIVER", "db-driver", "database driver: mysql, postgres or sqlite", stringField(func(c *Config) *string { return &c.Database.Driver })},
+	{"DB_DSN", "db-dsn", "database DSN, overriding the other database settings", stringField(func(c *Config) *string { return &c.Database.DSN })},
+	{"DB_HOST", "db-host", "database host", stringField(func(c *Config) *string { return &c.Database.Host })},
+	{"DB_PORT", "db-port", "database port", intField(func(c *Config) *int { return &c.Database.Port })},
+	{"DB_USER", "db-user", "database user", stringField(func(c *Config) *string { return &c.Database.User })},
+	{"DB_PASSWORD", "db-password", "database password", stringField(func(c *Config) *string { return &c.Database.Password })},
+	{"DB_NAME", "db-name", "database name", stringField(func(c *Config) *string { return &c.Database.Name })},
+	{"DB_AUTO_MIGRATE", "db-auto-migrate", "apply pending schema migrations at startup", boolField(func(c *Config) *bool { return &c.Database.AutoMigrate })},
+	{"DB_CONNECT_TIMEOUT", "db-connect-timeout", "how long to retry connecting to the database, e.g. 30s", durationField(func(c *Config) *time.Duration { return &c.Database.ConnectTimeout })},
+	{"LOG_LEVEL", "log-level", "minimum log level: debug, info, warn or error", stringField(func(c *Config) *string { return &c.Log.Level })},
+	{"LOG_SAMPLE_RATE", "log-sample-rate", "fraction of successful requests written to the access log", floatField(func(c *Config) *float64 { return &c.Log.SampleRate })},
+	{"TRACING_EXPORTER", "tracing-exporter", "trace exporter: none, stdout, otlp-grpc or otlp-http", stringField(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"TRACING_ENDPOINT", "tracing-endpoint", "OTLP collector URL", stringField(func(c *Config) *string { return &c.Tracing.Endpoint })},
+	{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "fraction of new traces recorded", floatField(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+	{"RATE_LIMIT_ENABLED", "rate-limit-enabled", "limit the requests of each client", boolField(func(c *Config) *bool { return &c.RateLimit.Enabled })},
+	{"RATE_LIMIT_READ", "rate-limit-read", "budget of GET requests per client, e.g. 300/m", stringField(func(c *Config) *string { return &c.RateLimit.Read })},
+	{"RATE_LIMIT_WRITE", "rate-limit-write", "budget of other requests per client, e.g. 60/m", stringField(func(c *Config) *string { return &c.RateLimit.Write })},
+	{"RATE_LIMIT_ROUTES", "rate-limit-routes", "comma-separated per-route budgets, e.g. POST /tasks=10/m", mapField(func(c *Config) *map[string]string { return &c.RateLimit.Routes })},
+	{"IDEMPOTENCY_TTL", "idempotency-ttl", "how long responses to Idempotency-Key requests are replayed, e.g. 24h", durationField(func(c *Config) *time.Duration { return &c.Idempotency.TTL })},
+	{"RESPONSE_CACHE_ENABLED", "response-cache-enabled", "cache the responses of task reads in memory", boolField(func(c *Config) *bool { return &c.ResponseCache.Enabled })},
+	{"RESPONSE_CACHE_TTL", "response-cache-ttl", "how long cached task reads are kept at most, e.g. 5s", durationField(func(c *Config) *time.Duration { return &c.ResponseCache.TTL })},
+	{"SEED_DATABASE", "seed", "insert the sample tasks at startup if missing", boolField(func(c *Config) *bool { return &c.Seed })},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long to wait for in-flight requests when stopping, e.g. 10s", durationField(func(c *Config) *time.Duration { return &c.ShutdownTimeout })},
+}
+
+// Load resolves the configuration from args (without the program name), the
+// environment and the config file, and validates it.
+func Load(args []string) (*Config, error) {
+	cfg, _, err := LoadArgs(args)
+	return cfg, err
+}
+
+// LoadArgs is Load for commands that take positional arguments after the
+// flags; it also returns those arguments.
+func LoadArgs(args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file (or $CONFIG_FILE)")
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.flag] = fs.String(s.flag, "", s.usage+" (or $"+s.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := Default()
+	if *configPath != "" {
+		if err := loadFile(&cfg, *configPath); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.env); ok && v != "" {
+			if err := s.set(&cfg, v); err != nil {
+				return nil, nil, fmt.Errorf("config: $%s: %w", s.env, err)
+			}
+		}
+	}
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				if err := s.set(&cfg, *flagValues[s.flag]); err != nil {
+					flagErr = fmt.Errorf("config: --%s: %w", s.flag, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, nil, flagErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return &cfg, fs.Args(), nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		// An empty file decodes to io.EOF and leaves the defaults alone.
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("config: %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("config: %s: unsupported file type %q, use .yaml, .yml or .toml", path, ext)
+	}
+	return nil
+}
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (c *Config) Validate() error {
+	var problems []string
+	checkPort := func(name string, port int) {
+		if port < 1 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("%s: %d is not a valid port (1-65535)", name, port))
+		}
+	}
+	checkPort("http.port", c.HTTP.Port)
+	checkPort("grpc.port", c.GRPC.Port)
+	if c.Database.Port != 0 {
+		checkPort("database.port", c.Database.Port)
+	}
+	if c.HTTP.Port == c.GRPC.Port {
+		problems = append(problems, fmt.Sprintf("grpc.port: %d is already used by http.port", c.GRPC.Port))
+	}
+	for _, origin := range c.HTTP.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			problems = append(problems, fmt.Sprintf("http.cors_origins: %q is not an origin like https://example.com", origin))
+		}
+	}
+	switch c.Database.Driver {
+	case "mysql", "postgres":
+		if c.Database.DSN != "" {
+			break
+		}
+		if c.Database.Host == "" {
+			problems = append(problems, "database.host: must not be empty")
+		}
+		if c.Database.User == "" {
+			problems = append(problems, "database.user: must not be empty")
+		}
+		if c.Database.Name == "" {
+			problems = append(problems, "database.name: must not be empty")
+		}
+	case "sqlite":
+		if c.Database.DSN == "" {
+			problems = append(problems, "database.dsn: must name the database file when database.driver is sqlite")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("database.driver: %q is not one of %s", c.Database.Driver, strings.Join(Drivers, ", ")))
+	}
+	if c.Database.ConnectTimeout < 0 {
+		problems = append(problems, fmt.Sprintf("database.connect_timeout: %s must not be negative", c.Database.ConnectTimeout))
+	}
+	if !slices.Contains(LogLevels, c.Log.Level) {
+		problems = append(problems, fmt.Sprintf("log.level: %q is not one of %s", c.Log.Level, strings.Join(LogLevels, ", ")))
+	}
+	if c.Log.SampleRate < 0 || c.Log.SampleRate > 1 {
+		problems = append(problems, fmt.Sprintf("log.sample_rate: %g is not between 0 and 1", c.Log.SampleRate))
+	}
+	if !slices.Contains(TracingExporters, c.Tracing.Exporter) {
+		problems = append(problems, fmt.Sprintf("tracing.exporter: %q is not one of %s", c.Tracing.Exporter, strings.Join(TracingExporters, ", ")))
+	}
+	if c.Tracing.Endpoint != "" {
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("tracing.endpoint: %q is not a URL like http://localhost:4318", c.Tracing.Endpoint))
+		}
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, fmt.Sprintf("tracing.sample_ratio: %g is not between 0 and 1", c.Tracing.SampleRatio))
+	}
+	if c.RateLimit.Enabled {
+		if _, err := ratelimit.ParseLimit(c.RateLimit.Read); err != nil {
+			problems = append(problems, "rate_limit.read: "+err.Error())
+		}
+		if _, err := ratelimit.ParseLimit(c.RateLimit.Write); err != nil {
+			problems = append(problems, "rate_limit.write: "+err.Error())
+		}
+		routes := make([]string, 0, len(c.RateLimit.Routes))
+		for route := range c.RateLimit.Routes {
+			routes = append(routes, route)
+		}
+		slices.Sort(routes)
+		for _, route := range routes {
+			if _, _, err := ratelimit.ParseRoute(route); err != nil {
+				problems = append(problems, "rate_limit.routes: "+err.Error())
+			}
+			if _, err := ratelimit.ParseLimit(c.RateLimit.Routes[route]); err != nil {
+				problems = append(problems, fmt.Sprintf("rate_limit.routes[%s]: %v", route, err))
+			}
+		}
+	}
+	if c.Idempotency.TTL <= 0 {
+		problems = append(problems, fmt.Sprintf("idempotency.ttl: %s must be positive", c.Idempotency.TTL))
+	}
+	if c.ResponseCache.Enabled && c.ResponseCache.TTL <= 0 {
+		problems = append(problems, fmt.Sprintf("response_cache.ttl: %s must be positive", c.ResponseCache.TTL))
+	}
+	if c.ShutdownTimeout <= 0 {
+		problems = append(problems, fmt.Sprintf("shutdown_timeout: %s must be positive", c.ShutdownTimeout))
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func stringField(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func intField(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", v)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func floatField(field func(*Config) *float64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", v)
+		}
+		*field(c) = f
+		return nil
+	}
+}
+
+// mapField reads a comma-separated list of key=value pairs.
+func mapField(field func(*Config) *map[string]string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		m := make(map[string]string)
+		for _, item := range splitList(v) {
+			key, value, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("%q is not a key=value pair", item)
+			}
+			m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		*field(c) = m
+		return nil
+	}
+}
+
+func durationField(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s", v)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func boolField(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+		*field(c) = b
+		return nil
+	}
+}
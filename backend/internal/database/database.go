@@ -1,31 +1,113 @@
 package database
 
 import (
-    "fmt"
-    "os"
-    "todo/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"todo/internal/config"
+	"todo/internal/logging"
 
-    "gorm.io/driver/mysql"
-    "gorm.io/gorm"
+	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
-
-func InitDB() *gorm.DB {
-    user := os.Getenv("DB_USER")
-    password := os.Getenv("DB_PASSWORD")
-    host := os.Getenv("DB_HOST")
-    port := os.Getenv("DB_PORT")
-    dbname := os.Getenv("DB_NAME")
-
-    dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-        user, password, host, port, dbname)
-    var err error
-    DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
-    if err != nil {
-        panic("failed to connect to database")
-    }
-    DB.AutoMigrate(&models.Task{}, &models.Subtask{})
-
-    return DB
-}
\ No newline at end of file
+// ErrPendingMigrations is returned by CheckMigrations and InitDB when the
+// schema lags behind the migrations of this build.
+var ErrPendingMigrations = errors.New("database has pending migrations")
+
+// Backoff between connection attempts, doubling from connectRetryMin up to
+// connectRetryMax.
+const (
+	connectRetryMin = 100 * time.Millisecond
+	connectRetryMax = 5 * time.Second
+)
+
+// InitDB connects to the database and makes sure its schema is current.
+// Pending migrations are applied when cfg.AutoMigrate is set; otherwise they
+// are an error, so a server never runs against a schema it does not expect.
+func InitDB(ctx context.Context, cfg config.Database) (*gorm.DB, error) {
+	db, err := Connect(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if cfg.AutoMigrate {
+		err = Migrate(db)
+	} else {
+		err = CheckMigrations(ctx, db)
+	}
+	if err != nil {
+		Close(db)
+		return nil, err
+	}
+	return db, nil
+}
+
+// Connect opens the database like Open and waits until it answers, retrying
+// with exponential backoff for up to cfg.ConnectTimeout. It gives up early
+// when ctx is cancelled, such as by a shutdown signal.
+func Connect(ctx context.Context, cfg config.Database) (*gorm.DB, error) {
+	if _, ok := dialects[cfg.Driver]; !ok {
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+	deadline := time.Now().Add(cfg.ConnectTimeout)
+	delay := connectRetryMin
+	for attempt := 1; ; attempt++ {
+		db, err := Open(cfg)
+		if err == nil {
+			if err = Ping(ctx, db); err == nil {
+				return db, nil
+			}
+		}
+		Close(db)
+		if time.Now().Add(delay).After(deadline) {
+			if attempt > 1 {
+				return nil, fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+			}
+			return nil, err
+		}
+		logging.FromContext(ctx).Warn("database not reachable, retrying",
+			slog.Int("attempt", attempt), slog.Duration("retry_in", delay), slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, connectRetryMax)
+	}
+}
+
+// Ping checks that the database answers.
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckMigrations returns an error wrapping ErrPendingMigrations when some
+// migrations have not been applied to db.
+func CheckMigrations(ctx context.Context, db *gorm.DB) error {
+	pending, err := PendingMigrations(db.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to read schema migrations: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d pending, starting with %d_%s; run `migrate up` or set DB_AUTO_MIGRATE=true",
+			ErrPendingMigrations, len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
+
+// Close closes the connection pool of db, if it has one.
+func Close(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil
+	}
+	return sqlDB.Close()
+}
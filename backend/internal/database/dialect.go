@@ -0,0 +1,179 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"todo/internal/config"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// dialect collects what differs between the supported databases.
+type dialect struct {
+	defaultPort int
+	dsn         func(cfg config.Database, port int) string
+	open        func(dsn string) gorm.Dialector
+	// searchIndex creates the index backing search, if the driver has one.
+	searchIndex func(db *gorm.DB) error
+	// search restricts tx to tasks whose title or description match term.
+	search func(tx *gorm.DB, term string) *gorm.DB
+	// epoch is an SQL expression of column, a timestamp, in Unix seconds.
+	epoch func(column string) string
+	// lock and unlock guard schema migrations against concurrent runs. They
+	// are called on a single connection.
+	lock   func(conn *gorm.DB) error
+	unlock func(conn *gorm.DB) error
+}
+
+// migrationLock names the lock taken while migrating. The Postgres advisory
+// lock needs a number, so it uses migrationLockKey instead.
+const (
+	migrationLock    = "todo_schema_migrations"
+	migrationLockKey = 7236414501
+)
+
+// migrationLockTimeout bounds how long a migration waits for another one.
+const migrationLockTimeout = 60 * time.Second
+
+// postgresSearchVector must match the expression of idx_tasks_search for
+// Postgres to use the index.
+const postgresSearchVector = "to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(description, ''))"
+
+var dialects = map[string]dialect{
+	"mysql": {
+		defaultPort: 3306,
+		dsn: func(cfg config.Database, port int) string {
+			return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
+				cfg.User, cfg.Password, cfg.Host, port, cfg.Name)
+		},
+		open: mysql.Open,
+		searchIndex: func(db *gorm.DB) error {
+			if db.Migrator().HasIndex("tasks", "idx_tasks_search") {
+				return nil
+			}
+			return db.Exec("CREATE FULLTEXT INDEX idx_tasks_search ON tasks (title, description)").Error
+		},
+		search: func(tx *gorm.DB, term string) *gorm.DB {
+			return tx.Where("MATCH (title, description) AGAINST (? IN NATURAL LANGUAGE MODE)", term)
+		},
+		// UNIX_TIMESTAMP would read the DATETIME in the session time zone,
+		// which need not be the UTC the values are stored in.
+		epoch: func(column string) string { return "TIMESTAMPDIFF(SECOND, '1970-01-01 00:00:00', " + column + ")" },
+		lock: func(conn *gorm.DB) error {
+			var got sql.NullInt64
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", migrationLock, int(migrationLockTimeout.Seconds())).Scan(&got).Error; err != nil {
+				return err
+			}
+			if got.Int64 != 1 {
+				return errMigrationLocked
+			}
+			return nil
+		},
+		unlock: func(conn *gorm.DB) error {
+			return conn.Exec("SELECT RELEASE_LOCK(?)", migrationLock).Error
+		},
+	},
+	"postgres": {
+		defaultPort: 5432,
+		dsn: func(cfg config.Database, port int) string {
+			return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable TimeZone=UTC",
+				quotePostgres(cfg.Host), port, quotePostgres(cfg.User), quotePostgres(cfg.Password), quotePostgres(cfg.Name))
+		},
+		open: postgres.Open,
+		searchIndex: func(db *gorm.DB) error {
+			return db.Exec("CREATE INDEX IF NOT EXISTS idx_tasks_search ON tasks USING GIN (" + postgresSearchVector + ")").Error
+		},
+		search: func(tx *gorm.DB, term string) *gorm.DB {
+			return tx.Where(postgresSearchVector+" @@ plainto_tsquery('simple', ?)", term)
+		},
+		epoch: func(column string) string { return "EXTRACT(EPOCH FROM " + column + ")" },
+		lock: func(conn *gorm.DB) error {
+			if err := conn.Exec(fmt.Sprintf("SET lock_timeout = '%ds'", int(migrationLockTimeout.Seconds()))).Error; err != nil {
+				return err
+			}
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+				return fmt.Errorf("%w: %v", errMigrationLocked, err)
+			}
+			return conn.Exec("SET lock_timeout = 0").Error
+		},
+		unlock: func(conn *gorm.DB) error {
+			return conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey).Error
+		},
+	},
+	"sqlite": {
+		open:        sqlite.Open,
+		searchIndex: func(*gorm.DB) error { return nil },
+		search: func(tx *gorm.DB, term string) *gorm.DB {
+			// No full-text index; fall back to a case-insensitive substring match.
+			pattern := "%" + escapeLike(term) + "%"
+			return tx.Where("(title LIKE ? ESCAPE '\\' OR description LIKE ? ESCAPE '\\')", pattern, pattern)
+		},
+		// The driver stores times as text with their UTC offset, which
+		// strftime honours.
+		epoch: func(column string) string { return "CAST(strftime('%s', " + column + ") AS INTEGER)" },
+		// SQLite locks the whole file for each write transaction, and each
+		// migration runs in one, so there is nothing more to take.
+		lock:   func(*gorm.DB) error { return nil },
+		unlock: func(*gorm.DB) error { return nil },
+	},
+}
+
+// quotePostgres quotes a value of a key/value connection string, so that
+// spaces, quotes and backslashes in it survive.
+func quotePostgres(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func dialectOf(db *gorm.DB) dialect {
+	if d, ok := dialects[db.Dialector.Name()]; ok {
+		return d
+	}
+	return dialects["sqlite"]
+}
+
+// Open connects to the database described by cfg without migrating it.
+func Open(cfg config.Database) (*gorm.DB, error) {
+	dsn, err := DSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return gorm.Open(dialects[cfg.Driver].open(dsn), &gorm.Config{})
+}
+
+// DSN returns the connection string Open uses for cfg: cfg.DSN, or one
+// built from the host, port, user, password and name.
+func DSN(cfg config.Database) (string, error) {
+	d, ok := dialects[cfg.Driver]
+	if !ok {
+		return "", fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
+	if cfg.DSN != "" || d.dsn == nil {
+		return cfg.DSN, nil
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = d.defaultPort
+	}
+	return d.dsn(cfg, port), nil
+}
+
+// SearchTasks restricts tx to tasks whose title or description match term,
+// using the full-text search of the connected database where there is one.
+func SearchTasks(tx *gorm.DB, term string) *gorm.DB {
+	return dialectOf(tx).search(tx, term)
+}
+
+// EpochSeconds returns an SQL expression of column, a timestamp, in Unix
+// seconds on the database of tx, for arithmetic in aggregate queries.
+func EpochSeconds(tx *gorm.DB, column string) string {
+	return dialectOf(tx).epoch(column)
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one numbered, reversible change to the schema. Migrations are
+// applied in ascending Version order and recorded in schema_migrations.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Migration
+	// AppliedAt is nil for pending migrations.
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of schema_migrations.
+type schemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255;not null"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// ErrNoMigrationToRevert is returned by MigrateDown and MigrateRedo when no
+// migration has been applied.
+var ErrNoMigrationToRevert = errors.New("no applied migration to revert")
+
+var errMigrationLocked = errors.New("timed out waiting for another migration to finish")
+
+// Migrations returns every known migration in version order.
+func Migrations() []Migration {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return sorted
+}
+
+// MigrationStatuses lists every known migration with when it was applied.
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []MigrationStatus
+	for _, m := range Migrations() {
+		status := MigrationStatus{Migration: m}
+		if row, ok := applied[m.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// PendingMigrations lists the migrations that have not been applied yet.
+func PendingMigrations(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range Migrations() {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies every pending migration.
+func Migrate(db *gorm.DB) error {
+	_, err := MigrateUp(db, 0)
+	return err
+}
+
+// MigrateUp applies up to n pending migrations, or all of them when n <= 0,
+// and returns the ones it applied.
+func MigrateUp(db *gorm.DB, n int) ([]Migration, error) {
+	var done []Migration
+	err := withMigrationLock(db, func(conn *gorm.DB) error {
+		pending, err := PendingMigrations(conn)
+		if err != nil {
+			return err
+		}
+		if n > 0 && n < len(pending) {
+			pending = pending[:n]
+		}
+		for _, m := range pending {
+			if err := applyMigration(conn, m); err != nil {
+				return err
+			}
+			done = append(done, m)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// MigrateDown reverts the n most recently applied migrations (at least one)
+// and returns the ones it reverted.
+func MigrateDown(db *gorm.DB, n int) ([]Migration, error) {
+	if n < 1 {
+		n = 1
+	}
+	var done []Migration
+	err := withMigrationLock(db, func(conn *gorm.DB) error {
+		applied, err := appliedInOrder(conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return ErrNoMigrationToRevert
+		}
+		for i := len(applied) - 1; i >= 0 && len(done) < n; i-- {
+			if err := revertMigration(conn, applied[i]); err != nil {
+				return err
+			}
+			done = append(done, applied[i])
+		}
+		return nil
+	})
+	return done, err
+}
+
+// MigrateRedo reverts the most recently applied migration and applies it
+// again, without letting another migration run in between.
+func MigrateRedo(db *gorm.DB) (Migration, error) {
+	var redone Migration
+	err := withMigrationLock(db, func(conn *gorm.DB) error {
+		applied, err := appliedInOrder(conn)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return ErrNoMigrationToRevert
+		}
+		redone = applied[len(applied)-1]
+		if err := revertMigration(conn, redone); err != nil {
+			return err
+		}
+		return applyMigration(conn, redone)
+	})
+	return redone, err
+}
+
+// withMigrationLock runs fn on a single connection holding the migration
+// lock, so replicas starting together migrate one after the other.
+func withMigrationLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	d := dialectOf(db)
+	return db.Connection(func(conn *gorm.DB) error {
+		// Connection hands out a chainable instance; start a fresh session on
+		// the pinned connection so statements do not leak into each other.
+		conn = conn.Session(&gorm.Session{NewDB: true})
+		if err := d.lock(conn); err != nil {
+			return fmt.Errorf("migrate: lock: %w", err)
+		}
+		defer d.unlock(conn)
+		if err := conn.AutoMigrate(&schemaMigration{}); err != nil {
+			return fmt.Errorf("migrate: create schema_migrations: %w", err)
+		}
+		return fn(conn)
+	})
+}
+
+func applyMigration(db *gorm.DB, m Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Another process may have applied it since the pending list was read.
+		var count int64
+		if err := tx.Model(&schemaMigration{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := m.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&schemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migrate: up %d_%s: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
+
+func revertMigration(db *gorm.DB, m Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := m.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&schemaMigration{}, m.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migrate: down %d_%s: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
+
+func appliedMigrations(db *gorm.DB) (map[int]schemaMigration, error) {
+	applied := make(map[int]schemaMigration)
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// appliedInOrder returns the applied migrations in version order. A version
+// recorded in the database but unknown to this binary is an error, since it
+// cannot be reverted.
+func appliedInOrder(db *gorm.DB) ([]Migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int]bool)
+	var ordered []Migration
+	for _, m := range Migrations() {
+		if _, ok := applied[m.Version]; ok {
+			ordered = append(ordered, m)
+		}
+		known[m.Version] = true
+	}
+	for version := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("migrate: database has migration %d, which this build does not know", version)
+		}
+	}
+	return ordered, nil
+}
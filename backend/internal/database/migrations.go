@@ -0,0 +1,297 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrations is the schema history. Append new migrations with the next
+// version number; never edit one that has been released. Each migration
+// declares the tables it needs as they were at that version rather than
+// using the live models, which keep changing.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_tasks_and_subtasks",
+		// AutoMigrate rather than CreateTable adopts databases created
+		// before migrations existed, when the server auto-migrated.
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&taskV1{}, &subtaskV1{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&subtaskV1{}, &taskV1{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add_tasks_search_index",
+		Up: func(tx *gorm.DB) error {
+			return dialectOf(tx).searchIndex(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasIndex("tasks", "idx_tasks_search") {
+				return nil
+			}
+			return tx.Migrator().DropIndex("tasks", "idx_tasks_search")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create_idempotency_keys",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&idempotencyKeyV3{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&idempotencyKeyV3{})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create_task_changes",
+		// Existing tasks get the changes they would have recorded, as far as
+		// their timestamps tell: created at CreatedAt and, when done,
+		// completed at UpdatedAt.
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&taskChangeV4{}); err != nil {
+				return err
+			}
+			if err := tx.Exec("INSERT INTO task_changes (task_id, kind, assignee, open_delta, occurred_at) " +
+				"SELECT id, 'created', assignee, 1, created_at FROM tasks ORDER BY id").Error; err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO task_changes (task_id, kind, assignee, open_delta, occurred_at) "+
+				"SELECT id, 'completed', assignee, -1, updated_at FROM tasks WHERE done = ? ORDER BY id", true).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&taskChangeV4{})
+		},
+	},
+	{
+		Version: 5,
+		Name:    "optional_due_dates",
+		// Tasks without a due date were stored with the zero time, and MySQL
+		// connections used loc=Local, storing the wall-clock times of the
+		// server's time zone. The DSN now uses loc=UTC, so the stored times
+		// are converted, assuming the migration runs in the time zone of
+		// the servers that wrote them. Existing due dates keep their exact
+		// time. Down converts them back for the loc=Local code.
+		//
+		// MySQL commits each schema change at once, so the schema changes
+		// come first: the data changes after them commit with the recorded
+		// version or not at all, and a retry never converts twice.
+		Up: func(tx *gorm.DB) error {
+			// Databases adopted from AutoMigrate may have the columns already.
+			m := tx.Migrator()
+			for _, field := range []string{"DueAllDay", "DueTimezone", "OverdueAt"} {
+				if m.HasColumn(&taskV5{}, field) {
+					continue
+				}
+				if err := m.AddColumn(&taskV5{}, field); err != nil {
+					return err
+				}
+			}
+			if !m.HasIndex(&taskV5{}, "OverdueAt") {
+				if err := m.CreateIndex(&taskV5{}, "OverdueAt"); err != nil {
+					return err
+				}
+			}
+			if tx.Dialector.Name() == "mysql" {
+				if err := rewriteTimesV5(tx, localToUTC); err != nil {
+					return err
+				}
+			}
+			if err := tx.Exec("UPDATE tasks SET due_date = NULL WHERE due_date < ?", noDueDateV4).Error; err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE tasks SET due_timezone = 'UTC', overdue_at = due_date WHERE due_date IS NOT NULL").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if m.HasIndex(&taskV5{}, "OverdueAt") {
+				if err := m.DropIndex(&taskV5{}, "OverdueAt"); err != nil {
+					return err
+				}
+			}
+			for _, field := range []string{"OverdueAt", "DueTimezone", "DueAllDay"} {
+				if !m.HasColumn(&taskV5{}, field) {
+					continue
+				}
+				if err := m.DropColumn(&taskV5{}, field); err != nil {
+					return err
+				}
+			}
+			if tx.Dialector.Name() == "mysql" {
+				if err := rewriteTimesV5(tx, utcToLocal); err != nil {
+					return err
+				}
+			}
+			return tx.Exec("UPDATE tasks SET due_date = ? WHERE due_date IS NULL", time.Time{}).Error
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create_saved_views",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&savedViewV6{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&savedViewV6{})
+		},
+	},
+}
+
+type taskV1 struct {
+	ID          uint   `gorm:"primaryKey"`
+	Title       string `gorm:"not null"`
+	Description string
+	Priority    string `gorm:"size:16;default:'Medium'"`
+	Assignee    string
+	DueDate     time.Time
+	Done        bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Subtasks    []subtaskV1 `gorm:"foreignKey:TaskID"`
+}
+
+func (taskV1) TableName() string { return "tasks" }
+
+type subtaskV1 struct {
+	ID        uint `gorm:"primaryKey"`
+	TaskID    uint
+	Title     string `gorm:"not null"`
+	Done      bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (subtaskV1) TableName() string { return "subtasks" }
+
+type idempotencyKeyV3 struct {
+	ID          string `gorm:"primaryKey;size:64"`
+	Fingerprint string `gorm:"size:64;not null"`
+	Status      int    `gorm:"not null;default:0"`
+	ContentType string `gorm:"size:255"`
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+}
+
+func (idempotencyKeyV3) TableName() string { return "idempotency_keys" }
+
+type taskChangeV4 struct {
+	ID         uint   `gorm:"primaryKey"`
+	TaskID     uint   `gorm:"index;not null"`
+	Kind       string `gorm:"size:16;not null"`
+	Assignee   string
+	OpenDelta  int       `gorm:"not null"`
+	OccurredAt time.Time `gorm:"index;not null"`
+}
+
+func (taskChangeV4) TableName() string { return "task_changes" }
+
+type taskV5 struct {
+	ID          uint `gorm:"primaryKey"`
+	DueDate     *time.Time
+	DueAllDay   bool
+	DueTimezone string     `gorm:"size:64"`
+	OverdueAt   *time.Time `gorm:"index"`
+}
+
+func (taskV5) TableName() string { return "tasks" }
+
+type savedViewV6 struct {
+	ID         uint   `gorm:"primaryKey"`
+	Name       string `gorm:"size:100;not null"`
+	Owner      string `gorm:"size:100;not null;index"`
+	Visibility string `gorm:"size:16;not null;default:'private'"`
+	Definition string `gorm:"type:text"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (savedViewV6) TableName() string { return "saved_views" }
+
+// noDueDateV4 bounds the zero time that tasks without a due date were
+// stored with until version 5, in any time zone; every real due date is
+// after it.
+var noDueDateV4 = time.Date(2, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// rewriteTimesV5 converts every DATETIME column that version 5 moved from
+// local time to UTC.
+func rewriteTimesV5(tx *gorm.DB, convert func(time.Time) time.Time) error {
+	for table, columns := range map[string][]string{
+		"tasks":            {"due_date", "created_at", "updated_at"},
+		"subtasks":         {"created_at", "updated_at"},
+		"task_changes":     {"occurred_at"},
+		"idempotency_keys": {"created_at", "expires_at"},
+	} {
+		if err := rewriteTimes(tx, convert, table, columns...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// localToUTC reads the wall-clock time of t, which the driver labels UTC,
+// in the local time zone and returns it in UTC. utcToLocal undoes it.
+func localToUTC(t time.Time) time.Time {
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	return time.Date(y, mo, d, h, mi, s, t.Nanosecond(), time.Local).UTC()
+}
+
+func utcToLocal(t time.Time) time.Time {
+	local := t.In(time.Local)
+	y, mo, d := local.Date()
+	h, mi, s := local.Clock()
+	return time.Date(y, mo, d, h, mi, s, local.Nanosecond(), time.UTC)
+}
+
+// rewriteTimes rewrites the given DATETIME columns of table with convert,
+// leaving NULL and zero times alone.
+func rewriteTimes(tx *gorm.DB, convert func(time.Time) time.Time, table string, columns ...string) error {
+	rows, err := tx.Table(table).Select(append([]string{"id"}, columns...)).Rows()
+	if err != nil {
+		return err
+	}
+	type row struct {
+		id     interface{}
+		values map[string]interface{}
+	}
+	var converted []row
+	for rows.Next() {
+		var id interface{}
+		times := make([]sql.NullTime, len(columns))
+		dest := []interface{}{&id}
+		for i := range times {
+			dest = append(dest, &times[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			rows.Close()
+			return err
+		}
+		r := row{id: id, values: make(map[string]interface{}, len(columns))}
+		for i, t := range times {
+			if !t.Valid || t.Time.Year() <= 1 {
+				continue
+			}
+			r.values[columns[i]] = convert(t.Time)
+		}
+		if len(r.values) > 0 {
+			converted = append(converted, r)
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	for _, r := range converted {
+		if err := tx.Table(table).Where("id = ?", r.id).UpdateColumns(r.values).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+// Package docs builds the OpenAPI 3 description of the REST API served at
+// /openapi.json. Model schemas are derived from the json and validate tags on
+// the models package, so they follow the structs automatically; operations
+// are listed by hand in operations.go.
+package docs
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"todo/internal/models"
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+var (
+	specOnce sync.Once
+	spec     *Document
+)
+
+// Spec returns the OpenAPI document for the API.
+func Spec() *Document {
+	specOnce.Do(func() {
+		spec = &Document{
+			OpenAPI: "3.0.3",
+			Info:    Info{Title: "Todo API", Version: "1.0.0"},
+			Paths:   make(map[string]*PathItem),
+			Components: Components{Schemas: map[string]*Schema{
+				"Task":             modelSchema(reflect.TypeOf(models.Task{})),
+				"Subtask":          modelSchema(reflect.TypeOf(models.Subtask{})),
+				"TaskStats":        modelSchema(reflect.TypeOf(models.TaskStats{})),
+				"GroupCount":       modelSchema(reflect.TypeOf(models.GroupCount{})),
+				"SubtaskCounts":    modelSchema(reflect.TypeOf(models.SubtaskCounts{})),
+				"Burndown":         modelSchema(reflect.TypeOf(models.Burndown{})),
+				"BurndownPoint":    modelSchema(reflect.TypeOf(models.BurndownPoint{})),
+				"Throughput":       modelSchema(reflect.TypeOf(models.Throughput{})),
+				"ThroughputSeries": modelSchema(reflect.TypeOf(models.ThroughputSeries{})),
+				"CycleTimes":       modelSchema(reflect.TypeOf(models.CycleTimes{})),
+				"Agenda":           modelSchema(reflect.TypeOf(models.Agenda{})),
+				"AgendaBucket":     modelSchema(reflect.TypeOf(models.AgendaBucket{})),
+				"DurationStats":    modelSchema(reflect.TypeOf(models.DurationStats{})),
+				"SavedView":        modelSchema(reflect.TypeOf(models.SavedView{})),
+				"ViewDefinition":   modelSchema(reflect.TypeOf(models.ViewDefinition{})),
+				"TaskFilter":       modelSchema(reflect.TypeOf(models.TaskFilter{})),
+				"ViewTasks":        modelSchema(reflect.TypeOf(models.ViewTasks{})),
+				"TaskGroup":        modelSchema(reflect.TypeOf(models.TaskGroup{})),
+				"Problem": {
+					Type:     "object",
+					Required: []string{"type", "title", "status", "code"},
+					Properties: map[string]*Schema{
+						"type":       {Type: "string"},
+						"title":      {Type: "string"},
+						"status":     {Type: "integer"},
+						"detail":     {Type: "string"},
+						"instance":   {Type: "string"},
+						"code":       {Type: "string"},
+						"request_id": {Type: "string"},
+						"errors":     {Type: "array", Items: ref("FieldError")},
+						"column":     {Type: "integer"},
+					},
+				},
+				"FieldError": {
+					Type:     "object",
+					Required: []string{"field", "code", "message"},
+					Properties: map[string]*Schema{
+						"field":   {Type: "string"},
+						"code":    {Type: "string"},
+						"param":   {Type: "string"},
+						"message": {Type: "string"},
+					},
+				},
+				"Done": {
+					Type:       "object",
+					Properties: map[string]*Schema{"done": {Type: "boolean"}},
+				},
+			}},
+		}
+		for _, op := range operations {
+			path := OpenAPIPath(op.path)
+			item, ok := spec.Paths[path]
+			if !ok {
+				item = &PathItem{}
+				spec.Paths[path] = item
+			}
+			(*item)[strings.ToLower(op.method)] = op.build()
+		}
+	})
+	return spec
+}
+
+// OpenAPIPath turns a Fiber route like /tasks/:id into /tasks/{id}.
+func OpenAPIPath(route string) string {
+	parts := strings.Split(route, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// modelSchema describes a model struct from its json, gorm and validate tags.
+func modelSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" && f.Anonymous {
+			// Embedded structs are flattened into their parent in JSON.
+			embedded := modelSchema(f.Type)
+			for prop, schema := range embedded.Properties {
+				s.Properties[prop] = schema
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		prop := typeSchema(f.Type)
+		switch f.Name {
+		case "ID", "CreatedAt", "UpdatedAt", "OverdueAt", "Owned":
+			prop.ReadOnly = true
+		}
+		if f.Type.Kind() == reflect.Ptr {
+			prop.Nullable = true
+		}
+		if def := gormDefault(f.Tag.Get("gorm")); def != "" {
+			prop.Default = def
+		}
+		for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+			switch {
+			case rule == "required":
+				s.Required = append(s.Required, name)
+				if prop.Type == "string" {
+					one := 1
+					prop.MinLength = &one
+				}
+			case strings.HasPrefix(rule, "oneof="):
+				prop.Enum = strings.Fields(strings.TrimPrefix(rule, "oneof="))
+			case strings.HasPrefix(rule, "max=") && prop.Type == "string":
+				if max, err := strconv.Atoi(strings.TrimPrefix(rule, "max=")); err == nil {
+					prop.MaxLength = &max
+				}
+			}
+		}
+		s.Properties[name] = prop
+	}
+	return s
+}
+
+func typeSchema(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Struct:
+		return ref(t.Name())
+	}
+	return &Schema{}
+}
+
+// gormDefault extracts the value of a default:'...' gorm tag option.
+func gormDefault(tag string) string {
+	for _, opt := range strings.Split(tag, ";") {
+		if v, ok := strings.CutPrefix(opt, "default:"); ok {
+			return strings.Trim(v, "'")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,204 @@
+package docs
+
+import (
+	"net/http"
+	"strconv"
+	"todo/internal/models"
+)
+
+// operation is a compact description of one route, expanded by build.
+type operation struct {
+	method  string
+	path    string
+	id      string
+	summary string
+	tag     string
+	params  []Parameter
+	body    *Schema
+	status  int
+	result  *Schema
+	// content type of result, application/json when empty
+	resultType string
+	errors     []int
+	// schema of error responses, application/problem+json with the Problem
+	// component when nil
+	errorResult *Schema
+	// conditional routes answer If-None-Match and If-Modified-Since
+	conditional bool
+}
+
+func idParam(description string) Parameter {
+	return Parameter{Name: "id", In: "path", Required: true, Description: description, Schema: &Schema{Type: "integer"}}
+}
+
+var filterParams = []Parameter{
+	{Name: "assignee", In: "query", Description: "Only tasks assigned to this person", Schema: &Schema{Type: "string"}},
+	{Name: "search", In: "query", Description: "Words in the title or description", Schema: &Schema{Type: "string"}},
+	{Name: "status", In: "query", Description: "Only completed or pending tasks", Schema: &Schema{Type: "string", Enum: []string{"completed", "pending"}}},
+	{Name: "sortBy", In: "query", Description: "Sort order; insertion order when omitted", Schema: &Schema{Type: "string", Enum: []string{"dueDate", "priority"}}},
+	{Name: "q", In: "query", Description: "Task query, such as priority:High -done due<7d", Schema: &Schema{Type: "string"}},
+}
+
+var maxIdempotencyKeyLength = 255
+
+var idempotencyKeyParam = Parameter{
+	Name: "Idempotency-Key", In: "header",
+	Description: "Unique key of this request; retries with the same key and body get the first response again",
+	Schema:      &Schema{Type: "string", MaxLength: &maxIdempotencyKeyLength},
+}
+
+var conditionalParams = []Parameter{
+	{Name: "If-None-Match", In: "header", Description: "ETag of the copy held by the client; 304 when it is current", Schema: &Schema{Type: "string"}},
+	{Name: "If-Modified-Since", In: "header", Description: "Last-Modified of the copy held by the client, used when If-None-Match is absent", Schema: &Schema{Type: "string"}},
+}
+
+var analyticsParams = []Parameter{
+	{Name: "from", In: "query", Description: "First day, 30 days before to when omitted", Schema: &Schema{Type: "string", Format: "date"}},
+	{Name: "to", In: "query", Description: "Last day, today when omitted", Schema: &Schema{Type: "string", Format: "date"}},
+	{Name: "tz", In: "query", Description: "IANA time zone whose midnights separate the days", Schema: &Schema{Type: "string", Default: "UTC"}},
+}
+
+var agendaParams = []Parameter{
+	{Name: "assignee", In: "query", Description: "Only tasks assigned to this person", Schema: &Schema{Type: "string"}},
+	{Name: "tz", In: "query", Description: "IANA time zone whose midnights separate the days", Schema: &Schema{Type: "string", Default: "UTC"}},
+}
+
+var graphQLRequest = &Schema{
+	Type:     "object",
+	Required: []string{"query"},
+	Properties: map[string]*Schema{
+		"query":         {Type: "string"},
+		"operationName": {Type: "string"},
+		"variables":     {Type: "object", AdditionalProperties: true},
+	},
+}
+
+var graphQLResponse = &Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"data":   {Type: "object", AdditionalProperties: true},
+		"errors": {Type: "array", Items: &Schema{Type: "object", AdditionalProperties: true}},
+	},
+}
+
+// operations lists every route registered by handlers.RegisterRoutes.
+var operations = []operation{
+	{method: http.MethodGet, path: "/", id: "ping", summary: "Check that the server is up", tag: "meta",
+		status: http.StatusOK, result: &Schema{Type: "string"}, resultType: "text/plain"},
+
+	{method: http.MethodPost, path: "/tasks", id: "createTask", summary: "Create a new task", tag: "tasks",
+		body: ref("Task"), status: http.StatusCreated, result: ref("Task"), errors: []int{400, 500}},
+	{method: http.MethodGet, path: "/tasks", id: "getTasks", summary: "List tasks with their subtasks", tag: "tasks",
+		params: filterParams, status: http.StatusOK, result: &Schema{Type: "array", Items: ref("Task")}, errors: []int{400, 500}, conditional: true},
+	{method: http.MethodGet, path: "/tasks/:id", id: "getTaskByID", summary: "Get a task by ID", tag: "tasks",
+		params: []Parameter{idParam("Task ID")}, status: http.StatusOK, result: ref("Task"), errors: []int{400, 404, 500}, conditional: true},
+	{method: http.MethodPut, path: "/tasks/:id", id: "updateTask", summary: "Update an existing task", tag: "tasks",
+		params: []Parameter{idParam("Task ID")}, body: ref("Task"), status: http.StatusOK, result: ref("Task"), errors: []int{400, 404, 500}},
+	{method: http.MethodDelete, path: "/tasks/:id", id: "deleteTask", summary: "Delete a task", tag: "tasks",
+		params: []Parameter{idParam("Task ID")}, status: http.StatusNoContent, errors: []int{400, 404, 500}},
+	{method: http.MethodPatch, path: "/tasks/:id/done", id: "updateTaskDone", summary: "Mark a task as done or undone", tag: "tasks",
+		params: []Parameter{idParam("Task ID")}, body: ref("Done"), status: http.StatusOK, result: ref("Task"), errors: []int{400, 404, 500}},
+
+	{method: http.MethodPost, path: "/tasks/:id/subtasks", id: "createSubtask", summary: "Create a subtask for a task", tag: "subtasks",
+		params: []Parameter{idParam("Task ID")}, body: ref("Subtask"), status: http.StatusCreated, result: ref("Subtask"), errors: []int{400, 500}},
+	{method: http.MethodGet, path: "/tasks/:id/subtasks", id: "getSubtasks", summary: "List the subtasks of a task", tag: "subtasks",
+		params: []Parameter{idParam("Task ID")}, status: http.StatusOK, result: &Schema{Type: "array", Items: ref("Subtask")}, errors: []int{400, 500}, conditional: true},
+	{method: http.MethodPut, path: "/subtasks/:id", id: "updateSubtask", summary: "Update an existing subtask", tag: "subtasks",
+		params: []Parameter{idParam("Subtask ID")}, body: ref("Subtask"), status: http.StatusOK, result: ref("Subtask"), errors: []int{400, 404, 500}},
+	{method: http.MethodDelete, path: "/subtasks/:id", id: "deleteSubtask", summary: "Delete a subtask", tag: "subtasks",
+		params: []Parameter{idParam("Subtask ID")}, status: http.StatusNoContent, errors: []int{400, 404, 500}},
+	{method: http.MethodPatch, path: "/subtasks/:id/done", id: "updateSubtaskDone", summary: "Mark a subtask as done or undone", tag: "subtasks",
+		params: []Parameter{idParam("Subtask ID")}, body: ref("Done"), status: http.StatusOK, result: ref("Subtask"), errors: []int{400, 404, 500}},
+
+	{method: http.MethodGet, path: "/stats", id: "getStats", summary: "Count tasks by status, priority and assignee", tag: "stats",
+		params: filterParams, status: http.StatusOK, result: ref("TaskStats"), errors: []int{400, 500}},
+	{method: http.MethodGet, path: "/analytics/burndown", id: "getBurndown", summary: "Open tasks at the end of each day", tag: "stats",
+		params: analyticsParams, status: http.StatusOK, result: ref("Burndown"), errors: []int{400, 500}},
+	{method: http.MethodGet, path: "/analytics/throughput", id: "getThroughput", summary: "Tasks completed per week and assignee", tag: "stats",
+		params: analyticsParams, status: http.StatusOK, result: ref("Throughput"), errors: []int{400, 500}},
+	{method: http.MethodGet, path: "/analytics/cycle-time", id: "getCycleTimes", summary: "Lead and cycle time percentiles of completed tasks", tag: "stats",
+		params: analyticsParams, status: http.StatusOK, result: ref("CycleTimes"), errors: []int{400, 500}},
+	{method: http.MethodGet, path: "/agenda", id: "getAgenda", summary: "Open tasks bucketed by when they are due", tag: "stats",
+		params: agendaParams, status: http.StatusOK, result: ref("Agenda"), errors: []int{400, 500}},
+	{method: http.MethodGet, path: "/agenda/:bucket", id: "getAgendaBucket", summary: "Open tasks of one agenda bucket", tag: "stats",
+		params: append([]Parameter{{Name: "bucket", In: "path", Required: true, Description: "Agenda bucket",
+			Schema: &Schema{Type: "string", Enum: models.AgendaBuckets}}}, agendaParams...),
+		status: http.StatusOK, result: ref("Agenda"), errors: []int{400, 500}},
+
+	{method: http.MethodGet, path: "/views", id: "getViews", summary: "List your views and the views shared with you", tag: "views",
+		status: http.StatusOK, result: &Schema{Type: "array", Items: ref("SavedView")}, errors: []int{500}},
+	{method: http.MethodPost, path: "/views", id: "createView", summary: "Save a named filter, sort order and grouping", tag: "views",
+		body: ref("SavedView"), status: http.StatusCreated, result: ref("SavedView"), errors: []int{400, 401, 500}},
+	{method: http.MethodGet, path: "/views/:id", id: "getViewByID", summary: "Get a view by ID", tag: "views",
+		params: []Parameter{idParam("View ID")}, status: http.StatusOK, result: ref("SavedView"), errors: []int{400, 404, 500}},
+	{method: http.MethodPut, path: "/views/:id", id: "updateView", summary: "Update a view you own", tag: "views",
+		params: []Parameter{idParam("View ID")}, body: ref("SavedView"), status: http.StatusOK, result: ref("SavedView"), errors: []int{400, 401, 403, 404, 500}},
+	{method: http.MethodDelete, path: "/views/:id", id: "deleteView", summary: "Delete a view you own", tag: "views",
+		params: []Parameter{idParam("View ID")}, status: http.StatusNoContent, errors: []int{400, 403, 404, 500}},
+	{method: http.MethodGet, path: "/views/:id/tasks", id: "getViewTasks", summary: "List the tasks of a view in its groups", tag: "views",
+		params: []Parameter{idParam("View ID")}, status: http.StatusOK, result: ref("ViewTasks"), errors: []int{400, 404, 500}, conditional: true},
+
+	{method: http.MethodPost, path: "/graphql", id: "graphql", summary: "Run a GraphQL query or mutation", tag: "graphql",
+		body: graphQLRequest, status: http.StatusOK, result: graphQLResponse, errors: []int{400}, errorResult: graphQLResponse},
+
+	{method: http.MethodGet, path: "/openapi.json", id: "openAPI", summary: "This OpenAPI document", tag: "meta",
+		status: http.StatusOK, result: &Schema{Type: "object", AdditionalProperties: true}},
+	{method: http.MethodGet, path: "/docs", id: "docs", summary: "Browsable API documentation", tag: "meta",
+		status: http.StatusOK, result: &Schema{Type: "string"}, resultType: "text/html"},
+}
+
+func (op operation) build() *Operation {
+	o := &Operation{
+		OperationID: op.id,
+		Summary:     op.summary,
+		Parameters:  op.params,
+		Responses:   make(map[string]Response),
+	}
+	if op.tag != "" {
+		o.Tags = []string{op.tag}
+	}
+	if op.body != nil {
+		o.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: op.body}},
+		}
+	}
+	success := Response{Description: http.StatusText(op.status)}
+	if op.result != nil {
+		contentType := op.resultType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		success.Content = map[string]MediaType{contentType: {Schema: op.result}}
+	}
+	o.Responses[strconv.Itoa(op.status)] = success
+	errorType, errorResult := "application/json", op.errorResult
+	if errorResult == nil {
+		errorType, errorResult = "application/problem+json", ref("Problem")
+	}
+	for _, status := range op.errors {
+		o.Responses[strconv.Itoa(status)] = Response{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{errorType: {Schema: errorResult}},
+		}
+	}
+	if op.conditional {
+		o.Parameters = append(append([]Parameter(nil), op.params...), conditionalParams...)
+		o.Responses[strconv.Itoa(http.StatusNotModified)] = Response{Description: http.StatusText(http.StatusNotModified)}
+	}
+	if op.method == http.MethodPost {
+		o.Parameters = append(append([]Parameter(nil), op.params...), idempotencyKeyParam)
+		for _, status := range []int{http.StatusConflict, http.StatusUnprocessableEntity} {
+			o.Responses[strconv.Itoa(status)] = Response{
+				Description: http.StatusText(status),
+				Content:     map[string]MediaType{"application/problem+json": {Schema: ref("Problem")}},
+			}
+		}
+	}
+	// Any route may be rate limited.
+	o.Responses[strconv.Itoa(http.StatusTooManyRequests)] = Response{
+		Description: http.StatusText(http.StatusTooManyRequests),
+		Content:     map[string]MediaType{"application/problem+json": {Schema: ref("Problem")}},
+	}
+	return o
+}
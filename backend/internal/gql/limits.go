@@ -0,0 +1,91 @@
+package gql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	MaxDepth      = 6
+	MaxComplexity = 500
+
+	// listFactor is the assumed size of list fields when estimating cost.
+	listFactor = 10
+)
+
+// listFields are the fields whose children are multiplied by listFactor.
+var listFields = map[string]bool{
+	"tasks":    true,
+	"subtasks": true,
+}
+
+// checkLimits rejects operations nested deeper than maxDepth or whose
+// estimated cost exceeds maxComplexity. Introspection fields are not counted.
+func checkLimits(doc *ast.Document, maxDepth, maxComplexity int) error {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[frag.Name.Value] = frag
+		}
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		w := &limitWalker{fragments: fragments, visiting: make(map[string]bool)}
+		depth, cost := w.selectionSet(op.SelectionSet)
+		if depth > maxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, maxDepth)
+		}
+		if cost > maxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", cost, maxComplexity)
+		}
+	}
+	return nil
+}
+
+type limitWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	visiting  map[string]bool
+}
+
+func (w *limitWalker) selectionSet(set *ast.SelectionSet) (depth, cost int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, sel := range set.Selections {
+		var d, c int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			d, c = w.selectionSet(sel.SelectionSet)
+			d++
+			if listFields[sel.Name.Value] {
+				c *= listFactor
+			}
+			c++
+		case *ast.InlineFragment:
+			d, c = w.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			name := sel.Name.Value
+			frag, ok := w.fragments[name]
+			if !ok || w.visiting[name] {
+				// Unknown and cyclic fragments are reported by validation.
+				continue
+			}
+			w.visiting[name] = true
+			d, c = w.selectionSet(frag.SelectionSet)
+			delete(w.visiting, name)
+		}
+		if d > depth {
+			depth = d
+		}
+		cost += c
+	}
+	return depth, cost
+}
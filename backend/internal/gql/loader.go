@@ -0,0 +1,96 @@
+package gql
+
+import (
+	"context"
+	"sync"
+	"todo/internal/models"
+	"todo/internal/services"
+)
+
+type (
+	loaderKey  struct{}
+	serviceKey struct{}
+)
+
+func withService(ctx context.Context, svc *services.Service) context.Context {
+	return context.WithValue(ctx, serviceKey{}, svc)
+}
+
+// serviceFrom returns the Service that Execute put in ctx.
+func serviceFrom(ctx context.Context) *services.Service {
+	return ctx.Value(serviceKey{}).(*services.Service)
+}
+
+// subtaskLoader batches subtask lookups for the lifetime of one request.
+// Resolvers that return tasks prime it with their IDs, so the first subtasks
+// field resolved fetches the subtasks of every primed task in one query
+// instead of one query per task.
+type subtaskLoader struct {
+	mu      sync.Mutex
+	pending map[uint]struct{}
+	cache   map[uint][]models.Subtask
+	fetch   func([]uint) (map[uint][]models.Subtask, error)
+}
+
+func newSubtaskLoader(svc *services.Service) *subtaskLoader {
+	return &subtaskLoader{
+		pending: make(map[uint]struct{}),
+		cache:   make(map[uint][]models.Subtask),
+		fetch:   svc.SubtasksByTaskIDs,
+	}
+}
+
+// withLoaders returns a copy of ctx carrying fresh per-request loaders
+// reading through the Service in ctx.
+func withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loaderKey{}, newSubtaskLoader(serviceFrom(ctx)))
+}
+
+func loaderFrom(ctx context.Context) *subtaskLoader {
+	if l, ok := ctx.Value(loaderKey{}).(*subtaskLoader); ok {
+		return l
+	}
+	return newSubtaskLoader(serviceFrom(ctx))
+}
+
+// Prime schedules taskIDs for the next batch.
+func (l *subtaskLoader) Prime(taskIDs ...uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range taskIDs {
+		if _, ok := l.cache[id]; !ok {
+			l.pending[id] = struct{}{}
+		}
+	}
+}
+
+// Load returns the subtasks of taskID, fetching it together with every
+// pending task on a cache miss.
+func (l *subtaskLoader) Load(taskID uint) ([]models.Subtask, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if subtasks, ok := l.cache[taskID]; ok {
+		return subtasks, nil
+	}
+	l.pending[taskID] = struct{}{}
+	ids := make([]uint, 0, len(l.pending))
+	for id := range l.pending {
+		ids = append(ids, id)
+	}
+	byTask, err := l.fetch(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		l.cache[id] = byTask[id]
+		delete(l.pending, id)
+	}
+	return l.cache[taskID], nil
+}
+
+// Clear drops the cached subtasks of taskID after a mutation.
+func (l *subtaskLoader) Clear(taskID uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, taskID)
+}
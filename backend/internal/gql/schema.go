@@ -0,0 +1,421 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+	"todo/internal/logging"
+	"todo/internal/models"
+	"todo/internal/services"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Schema is the GraphQL schema served at /graphql. Field and error semantics
+// follow the REST handlers.
+var Schema graphql.Schema
+
+var subtaskType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Subtask",
+	Fields: graphql.Fields{
+		"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"taskId":    &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"title":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"done":      &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"createdAt": &graphql.Field{Type: graphql.DateTime},
+		"updatedAt": &graphql.Field{Type: graphql.DateTime},
+	},
+})
+
+var taskType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Task",
+	Fields: graphql.Fields{
+		"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+		"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.Field{Type: graphql.String},
+		"priority":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"assignee":    &graphql.Field{Type: graphql.String},
+		"dueDate":     &graphql.Field{Type: graphql.DateTime},
+		"dueAllDay":   &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"dueTimezone": &graphql.Field{Type: graphql.String},
+		"overdueAt":   &graphql.Field{Type: graphql.DateTime},
+		"done":        &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"createdAt":   &graphql.Field{Type: graphql.DateTime},
+		"updatedAt":   &graphql.Field{Type: graphql.DateTime},
+		"subtasks": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(subtaskType))),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				task := p.Source.(models.Task)
+				return loaderFrom(p.Context).Load(task.ID)
+			},
+		},
+	},
+})
+
+var taskSummaryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TaskSummary",
+	Fields: graphql.Fields{
+		"total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"done":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"open":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var taskInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "TaskInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"priority":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"assignee":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"dueDate":     &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"dueAllDay":   &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false},
+		"dueTimezone": &graphql.InputObjectFieldConfig{Type: graphql.String},
+	},
+})
+
+var subtaskInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "SubtaskInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var filterArgs = graphql.FieldConfigArgument{
+	"assignee": &graphql.ArgumentConfig{Type: graphql.String},
+	"search":   &graphql.ArgumentConfig{Type: graphql.String, Description: "words in the title or description"},
+	"status":   &graphql.ArgumentConfig{Type: graphql.String, Description: "completed or pending"},
+	"sortBy":   &graphql.ArgumentConfig{Type: graphql.String, Description: "dueDate or priority"},
+	"q":        &graphql.ArgumentConfig{Type: graphql.String, Description: "task query, such as priority:High -done due<7d"},
+}
+
+var idArg = graphql.FieldConfigArgument{
+	"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+}
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"tasks": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taskType))),
+			Args: filterArgs,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				tasks, err := serviceFrom(p.Context).ListTaskRows(filterFrom(p.Args))
+				if err != nil {
+					return nil, userError(p.Context, err, "Could not retrieve tasks")
+				}
+				loader := loaderFrom(p.Context)
+				for _, task := range tasks {
+					loader.Prime(task.ID)
+				}
+				return tasks, nil
+			},
+		},
+		"task": &graphql.Field{
+			Type: taskType,
+			Args: idArg,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"], "Invalid task ID")
+				if err != nil {
+					return nil, err
+				}
+				task, err := serviceFrom(p.Context).FindTask(id)
+				if err != nil {
+					return nil, userError(p.Context, err, "Could not retrieve task")
+				}
+				return task, nil
+			},
+		},
+		"subtasks": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(subtaskType))),
+			Args: graphql.FieldConfigArgument{
+				"taskId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				taskID, err := parseID(p.Args["taskId"], "Invalid task ID")
+				if err != nil {
+					return nil, err
+				}
+				return loaderFrom(p.Context).Load(taskID)
+			},
+		},
+		"taskSummary": &graphql.Field{
+			Type: graphql.NewNonNull(taskSummaryType),
+			Args: graphql.FieldConfigArgument{
+				"assignee": filterArgs["assignee"],
+				"search":   filterArgs["search"],
+				"status":   filterArgs["status"],
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				summary, err := serviceFrom(p.Context).SummarizeTasks(filterFrom(p.Args))
+				if err != nil {
+					return nil, userError(p.Context, err, "Could not retrieve tasks")
+				}
+				return summary, nil
+			},
+		},
+	},
+})
+
+var mutationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Mutation",
+	Fields: graphql.Fields{
+		"createTask": &graphql.Field{
+			Type: graphql.NewNonNull(taskType),
+			Args: graphql.FieldConfigArgument{
+				"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(taskInputType)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				task := taskFromInput(p.Args["input"])
+				if err := serviceFrom(p.Context).CreateTask(&task); err != nil {
+					return nil, userError(p.Context, err, "Could not create task")
+				}
+				return task, nil
+			},
+		},
+		"updateTask": &graphql.Field{
+			Type: graphql.NewNonNull(taskType),
+			Args: graphql.FieldConfigArgument{
+				"id":    idArg["id"],
+				"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(taskInputType)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"], "Invalid task ID")
+				if err != nil {
+					return nil, err
+				}
+				task, err := serviceFrom(p.Context).FindTask(id)
+				if err != nil {
+					return nil, userError(p.Context, err, "Could not retrieve task")
+				}
+				if err := serviceFrom(p.Context).UpdateTask(&task, taskFromInput(p.Args["input"])); err != nil {
+					return nil, userError(p.Context, err, "Could not update task")
+				}
+				return task, nil
+			},
+		},
+		"updateTaskDone": &graphql.Field{
+			Type: graphql.NewNonNull(taskType),
+			Args: graphql.FieldConfigArgument{
+				"id":   idArg["id"],
+				"done": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Boolean)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"], "Invalid task ID")
+				if err != nil {
+					return nil, err
+				}
+				task, err := serviceFrom(p.Context).FindTask(id)
+				if err != nil {
+					return nil, userError(p.Context, err, "Could not retrieve task")
+				}
+				if err := serviceFrom(p.Context).SetTaskDone(&task, p.Args["done"].(bool)); err != nil {
+					return nil, userError(p.Context, err, "Could not update task")
+				}
+				return task, nil
+			},
+		},
+		"deleteTask": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+			Args: idArg,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"], "Invalid task ID")
+				if err != nil {
+					return nil, err
+				}
+				task, err := serviceFrom(p.Context).FindTask(id)
+				if err != nil {
+					return nil, userError(p.Context, err, "Could not delete task")
+				}
+				if err := serviceFrom(p.Context).DeleteTask(&task); err != nil {
+					return nil, userError(p.Context, err, "Could not delete task")
+				}
+				return true, nil
+			},
+		},
+		"createSubtask": &graphql.Field{
+			Type: graphql.NewNonNull(subtaskType),
+			Args: graphql.FieldConfigArgument{
+				"taskId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				"input":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(subtaskInputType)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				taskID, err := parseID(p.Args["taskId"], "Invalid task ID")
+				if err != nil {
+					return nil, err
+				}
+				subtask := subtaskFromInput(p.Args["input"])
+				subtask.TaskID = taskID
+				if err := serviceFrom(p.Context).CreateSubtask(&subtask); err != nil {
+					return nil, userError(p.Context, err, "Could not create subtask")
+				}
+				loaderFrom(p.Context).Clear(taskID)
+				return subtask, nil
+			},
+		},
+		"updateSubtask": &graphql.Field{
+			Type: graphql.NewNonNull(subtaskType),
+			Args: graphql.FieldConfigArgument{
+				"id":    idArg["id"],
+				"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(subtaskInputType)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"], "Invalid subtask ID")
+				if err != nil {
+					return nil, err
+				}
+				subtask, err := serviceFrom(p.Context).FindSubtask(id)
+				if err != nil {
+					return nil, userError(p.Context, err, "Could not retrieve subtask")
+				}
+				if err := serviceFrom(p.Context).UpdateSubtask(&subtask, subtaskFromInput(p.Args["input"])); err != nil {
+					return nil, userError(p.Context, err, "Could not update subtask")
+				}
+				loaderFrom(p.Context).Clear(subtask.TaskID)
+				return subtask, nil
+			},
+		},
+		"updateSubtaskDone": &graphql.Field{
+			Type: graphql.NewNonNull(subtaskType),
+			Args: graphql.FieldConfigArgument{
+				"id":   idArg["id"],
+				"done": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Boolean)},
+			},
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"], "Invalid subtask ID")
+				if err != nil {
+					return nil, err
+				}
+				subtask, err := serviceFrom(p.Context).FindSubtask(id)
+				if err != nil {
+					return nil, userError(p.Context, err, "Could not retrieve subtask")
+				}
+				if err := serviceFrom(p.Context).SetSubtaskDone(&subtask, p.Args["done"].(bool)); err != nil {
+					return nil, userError(p.Context, err, "Could not update subtask")
+				}
+				loaderFrom(p.Context).Clear(subtask.TaskID)
+				return subtask, nil
+			},
+		},
+		"deleteSubtask": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+			Args: idArg,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				id, err := parseID(p.Args["id"], "Invalid subtask ID")
+				if err != nil {
+					return nil, err
+				}
+				subtask, err := serviceFrom(p.Context).FindSubtask(id)
+				if err != nil {
+					return nil, userError(p.Context, err, "Could not delete subtask")
+				}
+				if err := serviceFrom(p.Context).DeleteSubtask(&subtask); err != nil {
+					return nil, userError(p.Context, err, "Could not delete subtask")
+				}
+				loaderFrom(p.Context).Clear(subtask.TaskID)
+				return true, nil
+			},
+		},
+	},
+})
+
+func init() {
+	var err error
+	Schema, err = graphql.NewSchema(graphql.SchemaConfig{
+		Query:    queryType,
+		Mutation: mutationType,
+	})
+	if err != nil {
+		panic("invalid GraphQL schema: " + err.Error())
+	}
+}
+
+// Request is the body of a GraphQL-over-HTTP request.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Execute runs req against Schema with svc after enforcing the depth and
+// complexity limits. Each call gets its own subtask loader.
+func Execute(ctx context.Context, svc *services.Service, req Request) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err == nil {
+		if err := checkLimits(doc, MaxDepth, MaxComplexity); err != nil {
+			return &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())}}
+		}
+	}
+	return graphql.Do(graphql.Params{
+		Schema:         Schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        withLoaders(withService(ctx, svc.WithContext(ctx))),
+	})
+}
+
+func filterFrom(args map[string]interface{}) models.TaskFilter {
+	var filter models.TaskFilter
+	filter.Assignee, _ = args["assignee"].(string)
+	filter.Search, _ = args["search"].(string)
+	filter.Status, _ = args["status"].(string)
+	filter.SortBy, _ = args["sortBy"].(string)
+	filter.Query, _ = args["q"].(string)
+	return filter
+}
+
+func taskFromInput(v interface{}) models.Task {
+	in, _ := v.(map[string]interface{})
+	var task models.Task
+	task.Title, _ = in["title"].(string)
+	task.Description, _ = in["description"].(string)
+	task.Priority, _ = in["priority"].(string)
+	task.Assignee, _ = in["assignee"].(string)
+	if due, ok := in["dueDate"].(time.Time); ok {
+		task.DueDate = &due
+	} else if due, ok := in["dueDate"].(*time.Time); ok && due != nil {
+		task.DueDate = due
+	}
+	task.DueAllDay, _ = in["dueAllDay"].(bool)
+	task.DueTimezone, _ = in["dueTimezone"].(string)
+	return task
+}
+
+func subtaskFromInput(v interface{}) models.Subtask {
+	in, _ := v.(map[string]interface{})
+	var subtask models.Subtask
+	subtask.Title, _ = in["title"].(string)
+	return subtask
+}
+
+func parseID(v interface{}, message string) (uint, error) {
+	s, _ := v.(string)
+	id, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		return 0, errors.New(message)
+	}
+	return uint(id), nil
+}
+
+// userError maps service errors onto the messages the REST handlers return.
+// Anything else is logged and hidden behind fallback.
+func userError(ctx context.Context, err error, fallback string) error {
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound):
+		return errors.New("Task not found")
+	case errors.Is(err, services.ErrSubtaskNotFound):
+		return errors.New("Subtask not found")
+	case services.IsValidationError(err):
+		return errors.New(services.ValidationMessage(err))
+	}
+	logging.FromContext(ctx).ErrorContext(ctx, "graphql resolver failed", slog.String("reason", fallback), slog.Any("error", err))
+	return errors.New(fallback)
+}
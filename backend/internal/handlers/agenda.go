@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"time"
+	"todo/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAgenda lists the open tasks by when they are due, in every bucket or,
+// under /agenda/:bucket, in one.
+func (h *Handler) GetAgenda(c *fiber.Ctx) error {
+	var q models.AgendaQuery
+	if err := c.QueryParser(&q); err != nil {
+		return problem(fiber.StatusBadRequest, CodeInvalidQuery, "invalid_query")
+	}
+	q.Bucket = c.Params("bucket")
+	agenda, err := h.service(c).Agenda(q, time.Now())
+	if err != nil {
+		if isValidationError(err) {
+			return validationProblem(err)
+		}
+		return internalError(c, err, "get_agenda_failed")
+	}
+	return c.JSON(agenda)
+}
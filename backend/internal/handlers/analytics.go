@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+	"todo/internal/models"
+	"todo/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GetBurndown(c *fiber.Ctx) error {
+	return h.sendAnalytics(c, func(svc *services.Service, q models.AnalyticsQuery) (interface{}, error) {
+		return svc.Burndown(q, time.Now())
+	})
+}
+
+func (h *Handler) GetThroughput(c *fiber.Ctx) error {
+	return h.sendAnalytics(c, func(svc *services.Service, q models.AnalyticsQuery) (interface{}, error) {
+		return svc.Throughput(q, time.Now())
+	})
+}
+
+func (h *Handler) GetCycleTimes(c *fiber.Ctx) error {
+	return h.sendAnalytics(c, func(svc *services.Service, q models.AnalyticsQuery) (interface{}, error) {
+		return svc.CycleTimes(q, time.Now())
+	})
+}
+
+// sendAnalytics parses the range of an analytics request and sends the
+// report that build makes of it.
+func (h *Handler) sendAnalytics(c *fiber.Ctx, build func(*services.Service, models.AnalyticsQuery) (interface{}, error)) error {
+	var q models.AnalyticsQuery
+	if err := c.QueryParser(&q); err != nil {
+		return problem(fiber.StatusBadRequest, CodeInvalidQuery, "invalid_query")
+	}
+	report, err := build(h.service(c), q)
+	if err != nil {
+		switch {
+		case isValidationError(err):
+			return validationProblem(err)
+		case errors.Is(err, services.ErrInvalidRange):
+			return problem(fiber.StatusBadRequest, CodeInvalidQuery, "invalid_range")
+		}
+		return internalError(c, err, "get_analytics_failed")
+	}
+	return c.JSON(report)
+}
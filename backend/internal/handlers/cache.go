@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"todo/internal/models"
+	"todo/internal/services"
+	"todo/internal/taskquery"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxCachedResponses bounds the response cache; it starts over once full.
+const maxCachedResponses = 1024
+
+// cachedResponse is the JSON body of a read and its validators.
+type cachedResponse struct {
+	body     []byte
+	etag     string
+	modified time.Time
+	// revision is the Service revision the body was read at.
+	revision uint64
+	expires  time.Time
+}
+
+// responseCache keeps the responses of reads made at the latest Service
+// revision, keyed by URL. A nil *responseCache caches nothing.
+type responseCache struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	revision uint64
+	entries  map[string]cachedResponse
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, entries: make(map[string]cachedResponse)}
+}
+
+func (rc *responseCache) get(key string, revision uint64, now time.Time) (cachedResponse, bool) {
+	if rc == nil {
+		return cachedResponse{}, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	r, ok := rc.entries[key]
+	if !ok || r.revision != revision || !now.Before(r.expires) {
+		return cachedResponse{}, false
+	}
+	return r, true
+}
+
+// put stores r unless a newer revision was cached meanwhile. Responses of
+// older revisions are dropped, since nothing reads them any more.
+func (rc *responseCache) put(key string, r cachedResponse, now time.Time) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	switch {
+	case r.revision < rc.revision:
+		return
+	case r.revision > rc.revision || len(rc.entries) >= maxCachedResponses:
+		rc.revision = r.revision
+		rc.entries = make(map[string]cachedResponse)
+	}
+	r.expires = now.Add(rc.ttl)
+	rc.entries[key] = r
+}
+
+// sendCacheable answers a GET with the JSON of what load reads, tagged with
+// an ETag and a Last-Modified time, or with 304 Not Modified when the copy
+// of the client is still current. load also returns when its result was
+// last modified, which sendCacheable moves up to the last write made
+// through the Service, since deletions leave no timestamp behind. With a
+// response cache, load only runs when something was written since the
+// cached response was read.
+func (h *Handler) sendCacheable(c *fiber.Ctx, load func(*services.Service) (interface{}, time.Time, error)) error {
+	key := c.OriginalURL()
+	// Taken before loading, so that a write racing with load leaves the
+	// response stale.
+	revision, _ := h.svc.Revision()
+	if r, ok := h.cache.get(key, revision, time.Now()); ok {
+		return sendConditional(c, r)
+	}
+
+	v, modified, err := load(h.service(c))
+	if err != nil {
+		return err
+	}
+	body, err := c.App().Config().JSONEncoder(v)
+	if err != nil {
+		return err
+	}
+	if _, changed := h.svc.Revision(); changed.After(modified) {
+		modified = changed
+	}
+	sum := sha256.Sum256(body)
+	r := cachedResponse{
+		body:     body,
+		etag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+		modified: modified.UTC().Truncate(time.Second),
+		revision: revision,
+	}
+	h.cache.put(key, r, time.Now())
+	return sendConditional(c, r)
+}
+
+// sendUncached answers a GET with the JSON of what load reads, without
+// caching it or sending validators. It serves reads whose result changes
+// with the time alone, which neither the Service revision nor a
+// Last-Modified time would reveal.
+func (h *Handler) sendUncached(c *fiber.Ctx, load func(*services.Service) (interface{}, time.Time, error)) error {
+	v, _, err := load(h.service(c))
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(v)
+}
+
+// sendTaskList sends a list of tasks read with query like sendCacheable,
+// unless query depends on the clock. A query that does not parse is left
+// for load to report.
+func (h *Handler) sendTaskList(c *fiber.Ctx, query string, load func(*services.Service) (interface{}, time.Time, error)) error {
+	if expr, err := taskquery.Parse(query); err == nil && taskquery.DependsOnClock(expr) {
+		return h.sendUncached(c, load)
+	}
+	return h.sendCacheable(c, load)
+}
+
+// sendConditional sends r, or 304 Not Modified when the validators of the
+// request match it. Clients are asked to revalidate before every reuse.
+func sendConditional(c *fiber.Ctx, r cachedResponse) error {
+	c.Set(fiber.HeaderETag, r.etag)
+	c.Set(fiber.HeaderLastModified, r.modified.Format(http.TimeFormat))
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	if notModified(c, r) {
+		c.Status(fiber.StatusNotModified)
+		return nil
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(r.body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is
+// none, as RFC 9110 section 13.2.2 orders.
+func notModified(c *fiber.Ctx, r cachedResponse) bool {
+	if inm := c.Get(fiber.HeaderIfNoneMatch); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == r.etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	return err == nil && !r.modified.After(since)
+}
+
+// tasksModified returns when any of tasks or their subtasks last changed.
+func tasksModified(tasks ...models.Task) time.Time {
+	var modified time.Time
+	for _, t := range tasks {
+		if t.UpdatedAt.After(modified) {
+			modified = t.UpdatedAt
+		}
+		if st := subtasksModified(t.Subtasks); st.After(modified) {
+			modified = st
+		}
+	}
+	return modified
+}
+
+func subtasksModified(subtasks []models.Subtask) time.Time {
+	var modified time.Time
+	for _, st := range subtasks {
+		if st.UpdatedAt.After(modified) {
+			modified = st.UpdatedAt
+		}
+	}
+	return modified
+}
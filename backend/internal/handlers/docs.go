@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"todo/internal/docs"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) OpenAPI(c *fiber.Ctx) error {
+	return c.JSON(docs.Spec())
+}
+
+func (h *Handler) Docs(c *fiber.Ctx) error {
+	c.Type("html")
+	return c.SendString(docs.UI)
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"todo/internal/gql"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GraphQL(c *fiber.Ctx) error {
+	var req gql.Request
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"errors": []fiber.Map{{"message": "Cannot parse JSON"}}})
+	}
+	if req.Query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"errors": []fiber.Map{{"message": "Missing query"}}})
+	}
+	return c.JSON(gql.Execute(c.UserContext(), h.svc, req))
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"context"
+	"todo/internal/health"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// probe serves a health report, with status 503 when a check fails so that
+// orchestrators need not parse the body.
+func probe(report func(context.Context) health.Report) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		r := report(c.UserContext())
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		if !r.OK() {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		return c.JSON(r)
+	}
+}
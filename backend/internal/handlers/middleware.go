@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo/internal/idempotency"
+	"todo/internal/logging"
+	"todo/internal/metrics"
+	"todo/internal/ratelimit"
+	"todo/internal/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// requestIDKey is the Locals key of the request ID, which is also echoed
+	// in the X-Request-ID response header.
+	requestIDKey = "requestid"
+	// userKey is the Locals key under which identifyCaller stores who made
+	// the request, for the access log and clientKey.
+	userKey = "user"
+
+	maxRequestIDLength      = 128
+	maxIdempotencyKeyLength = 255
+
+	instrumentationName = "todo/internal/handlers"
+)
+
+// requestID propagates the caller's X-Request-ID, or assigns a new one when
+// it is missing or does not look like an ID.
+func requestID(c *fiber.Ctx) error {
+	id := c.Get(fiber.HeaderXRequestID)
+	if !validRequestID(id) {
+		id = utils.UUIDv4()
+	}
+	c.Set(fiber.HeaderXRequestID, id)
+	c.Locals(requestIDKey, id)
+	return c.Next()
+}
+
+// identifyCaller records who makes the request. There are no accounts, so
+// callers are their bearer token, hashed so that it stays out of logs,
+// memory and shared stores. Tokens are not verified.
+func identifyCaller(c *fiber.Ctx) error {
+	if token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); ok && token != "" {
+		sum := sha256.Sum256([]byte(token))
+		c.Locals(userKey, "token:"+hex.EncodeToString(sum[:16]))
+	}
+	return c.Next()
+}
+
+// validRequestID accepts short printable ASCII IDs, which keeps forged
+// headers from injecting anything odd into the logs.
+func validRequestID(id string) bool {
+	return printableASCII(id, maxRequestIDLength)
+}
+
+// printableASCII reports whether s is 1 to maxLength printable ASCII
+// characters without spaces.
+func printableASCII(s string, maxLength int) bool {
+	if s == "" || len(s) > maxLength {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// next runs the rest of the handler chain and writes the error response of
+// a failed request right away, so that the caller sees its final status. It
+// returns the template of the matched route, or "" when only middleware ran.
+// That check needs every app.Use to come before the first route, so that
+// Fiber merges all middleware into a single route.
+func next(c *fiber.Ctx) (route string) {
+	middleware := c.Route()
+	if err := c.Next(); err != nil {
+		if err := c.App().ErrorHandler(c, err); err != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+	if r := c.Route(); r != middleware {
+		return r.Path
+	}
+	return ""
+}
+
+// accessLog attaches a logger tagged with the request ID to the request
+// context and logs every request once it is served. Successful requests are
+// sampled at sampleRate; failed ones are always logged.
+func accessLog(logger *slog.Logger, sampleRate float64) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		id, _ := c.Locals(requestIDKey).(string)
+		reqLogger := logger.With(slog.String("request_id", id))
+		if sc := trace.SpanContextFromContext(c.UserContext()); sc.IsValid() {
+			reqLogger = reqLogger.With(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+		c.SetUserContext(logging.WithContext(c.UserContext(), reqLogger))
+
+		route := next(c)
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		case sampleRate < 1 && rand.Float64() >= sampleRate:
+			return nil
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int("bytes", len(c.Response().Body())),
+		}
+		if route != "" {
+			attrs = append(attrs, slog.String("route", route))
+		}
+		if user, ok := c.Locals(userKey).(string); ok && user != "" {
+			attrs = append(attrs, slog.String("user", user))
+		}
+		reqLogger.LogAttrs(c.UserContext(), level, "request", attrs...)
+		return nil
+	}
+}
+
+// requestMetrics counts and times every request by route template.
+func requestMetrics(m *metrics.Metrics) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		route := next(c)
+		if route == "" {
+			route = metrics.UnmatchedRoute
+		}
+		m.ObserveRequest(c.Method(), route, c.Response().StatusCode(), time.Since(start))
+		return nil
+	}
+}
+
+// traceRequests starts a server span per request, continuing the trace of
+// an incoming traceparent header, and returns the trace context in the
+// response headers. The span is named after the route template once the
+// request is served.
+func traceRequests(tp trace.TracerProvider) fiber.Handler {
+	tracer := tp.Tracer(instrumentationName)
+	return func(c *fiber.Ctx) error {
+		ctx := tracing.Propagator.Extract(c.UserContext(), propagation.HeaderCarrier(c.GetReqHeaders()))
+		ctx, span := tracer.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		outgoing := propagation.HeaderCarrier{}
+		tracing.Propagator.Inject(ctx, outgoing)
+		for key := range outgoing {
+			c.Set(key, outgoing.Get(key))
+		}
+
+		route := next(c)
+
+		status := c.Response().StatusCode()
+		if route != "" {
+			span.SetName(c.Method() + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if id, ok := c.Locals(requestIDKey).(string); ok {
+			span.SetAttributes(attribute.String("request_id", id))
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return nil
+	}
+}
+
+// tokensPerIP is how many clients with their own bearer token one IP
+// address has budgets for in total.
+const tokensPerIP = 10
+
+// rateLimit charges every request to the budget of its client, as
+// clientKey tells them apart, except for the paths in exempt, and answers
+// 429 once the budget is spent. Should the store fail, requests are let
+// through rather than failing the API.
+//
+// Tokens are not verified, so requests with one are also charged to a
+// budget of tokensPerIP clients that every token from their IP shares:
+// clients behind one NAT keep budgets of their own, but making tokens up
+// does not escape the limits.
+func rateLimit(l *ratelimit.Limiter, exempt map[string]bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if exempt[c.Path()] {
+			return c.Next()
+		}
+		ctx, key := c.UserContext(), clientKey(c)
+		res, err := l.Take(ctx, key, c.Method(), c.Path())
+		if err == nil && res.Allowed && key != "ip:"+c.IP() {
+			var shared ratelimit.Result
+			if shared, err = l.TakeShared(ctx, "tokens:"+c.IP(), tokensPerIP, c.Method(), c.Path()); err == nil && !shared.Allowed {
+				res = shared
+			}
+		}
+		if err != nil {
+			logging.FromContext(c.UserContext()).ErrorContext(c.UserContext(), "rate limit store failed", slog.Any("error", err))
+			return c.Next()
+		}
+		c.Set("RateLimit-Limit", strconv.Itoa(res.Limit.Requests))
+		c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ratelimit.Seconds(res.Reset)))
+		c.Set("RateLimit-Policy", strconv.Itoa(res.Limit.Requests)+";w="+strconv.Itoa(ratelimit.Seconds(res.Limit.Period)))
+		if !res.Allowed {
+			retry := strconv.Itoa(max(1, ratelimit.Seconds(res.RetryAfter)))
+			c.Set(fiber.HeaderRetryAfter, retry)
+			return problem(fiber.StatusTooManyRequests, CodeRateLimited, "rate_limited", retry)
+		}
+		return c.Next()
+	}
+}
+
+// idempotent carries out a POST request with an Idempotency-Key header only
+// once per client and key: retries within ttl get the stored response of the
+// first request, with an Idempotent-Replayed header. Reusing a key for a
+// different request is a 422, and retrying while the first request is still
+// running a 409. Responses with a 5xx status are not kept, so the request
+// can be retried.
+func idempotent(store idempotency.Store, ttl time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderIdempotencyKey)
+		if c.Method() != fiber.MethodPost || key == "" {
+			return c.Next()
+		}
+		if !printableASCII(key, maxIdempotencyKeyLength) {
+			return problem(fiber.StatusBadRequest, CodeInvalidIdempotencyKey, "invalid_idempotency_key")
+		}
+
+		ctx := c.UserContext()
+		now := time.Now()
+		rec := idempotency.Record{
+			ID:          idempotency.ID(clientKey(c), key),
+			Fingerprint: idempotency.Fingerprint(c.Method(), c.OriginalURL(), c.Body()),
+			ExpiresAt:   now.Add(ttl),
+		}
+		existing, err := store.Claim(ctx, rec, now)
+		if err != nil {
+			return internalError(c, err, "internal_error")
+		}
+		switch {
+		case existing == nil:
+		case existing.Fingerprint != rec.Fingerprint:
+			return problem(fiber.StatusUnprocessableEntity, CodeIdempotencyKeyReused, "idempotency_key_reused")
+		case existing.Pending():
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return problem(fiber.StatusConflict, CodeIdempotencyKeyInUse, "idempotency_key_in_use")
+		default:
+			c.Set(HeaderIdempotentReplayed, "true")
+			c.Set(fiber.HeaderContentType, existing.ContentType)
+			return c.Status(existing.Status).Send(existing.Body)
+		}
+
+		next(c)
+		// The request may have been cancelled; the outcome is stored anyway.
+		ctx = context.WithoutCancel(ctx)
+		if status := c.Response().StatusCode(); status >= fiber.StatusInternalServerError {
+			err = store.Release(ctx, rec.ID)
+		} else {
+			rec.Status = status
+			rec.ContentType = string(c.Response().Header.ContentType())
+			rec.Body = append([]byte(nil), c.Response().Body()...)
+			rec.ExpiresAt = time.Now().Add(ttl)
+			err = store.Complete(ctx, rec)
+		}
+		if err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to store idempotent response", slog.Any("error", err))
+		}
+		return nil
+	}
+}
+
+// clientKey identifies the caller of a request for idempotency keys and
+// saved views: the caller as identifyCaller found them, else the client IP.
+func clientKey(c *fiber.Ctx) string {
+	if user, ok := c.Locals(userKey).(string); ok && user != "" {
+		return user
+	}
+	return "ip:" + c.IP()
+}
+
+// internalError logs the underlying cause of a failed request, which the
+// client never sees, and returns the problem to respond with.
+func internalError(c *fiber.Ctx, err error, key string) *Problem {
+	logging.FromContext(c.UserContext()).ErrorContext(c.UserContext(), "request failed",
+		slog.String("reason", key), slog.Any("error", err))
+	return problem(fiber.StatusInternalServerError, CodeInternal, key)
+}
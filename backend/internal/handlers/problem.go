@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"todo/internal/i18n"
+	"todo/internal/services"
+	"todo/internal/taskquery"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Stable error codes. Clients branch on these rather than on the human
+// readable detail, which may change.
+const (
+	CodeInvalidJSON      = "invalid_json"
+	CodeInvalidID        = "invalid_id"
+	CodeInvalidQuery     = "invalid_query"
+	CodeValidation       = "validation_failed"
+	CodeInvalidTaskQuery = "invalid_task_query"
+	CodeTaskNotFound     = "task_not_found"
+	CodeSubtaskNotFound  = "subtask_not_found"
+	CodeViewNotFound     = "view_not_found"
+	CodeNotViewOwner     = "not_view_owner"
+	CodeTokenRequired    = "token_required"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+
+	CodeInvalidIdempotencyKey = "invalid_idempotency_key"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyKeyInUse   = "idempotency_key_in_use"
+)
+
+// ProblemContentType is the media type of error responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body. Every error response of the
+// REST API is one, written by ErrorHandler.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is one of the Code* constants, or the snake_case status text for
+	// errors raised by Fiber itself, such as not_found for unknown routes.
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists the rejected fields of a validation_failed problem.
+	Errors []services.FieldError `json:"errors,omitempty"`
+	// Column is where an invalid_task_query problem was found in q,
+	// counting characters from 1.
+	Column int `json:"column,omitempty"`
+
+	// key and params name the catalogue message of Detail, and cause is the
+	// validation error behind Errors, so that both can be localized.
+	key    string
+	params []string
+	cause  error
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+// problem returns a Problem whose detail is the catalogue message key,
+// formatted with params in the language the client negotiates.
+func problem(status int, code, key string, params ...string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: i18n.For(i18n.Default).T(key, params...),
+		Code:   code,
+		key:    key,
+		params: params,
+	}
+}
+
+func validationProblem(err error) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(fiber.StatusBadRequest),
+		Status: fiber.StatusBadRequest,
+		Detail: services.ValidationMessage(err),
+		Code:   CodeValidation,
+		Errors: services.FieldErrors(err),
+		cause:  err,
+	}
+}
+
+// taskQueryProblem reports a mistake in the q task query, with a message
+// of the task_query.* catalogue keys.
+func taskQueryProblem(err *taskquery.SyntaxError) *Problem {
+	// The messages that show the text found put it before the column.
+	key, params := "task_query."+err.Code, []string{strconv.Itoa(err.Column)}
+	if err.Near != "" {
+		params = []string{err.Near, params[0]}
+	}
+	p := problem(fiber.StatusBadRequest, CodeInvalidTaskQuery, key, params...)
+	p.Column = err.Column
+	return p
+}
+
+// inputProblem returns the problem of a validation error or a task query
+// mistake, or nil for other errors.
+func inputProblem(err error) *Problem {
+	var syntax *taskquery.SyntaxError
+	switch {
+	case isValidationError(err):
+		return validationProblem(err)
+	case errors.As(err, &syntax):
+		return taskQueryProblem(syntax)
+	}
+	return nil
+}
+
+func (p *Problem) localize(tr i18n.Translator) {
+	if p.key != "" {
+		p.Detail = tr.T(p.key, p.params...)
+	}
+	if p.cause != nil {
+		p.Detail = services.LocalizedValidationMessage(p.cause, tr)
+		p.Errors = services.LocalizedFieldErrors(p.cause, tr)
+	}
+}
+
+// ErrorHandler turns the error returned by a handler into a problem
+// response in the language negotiated from Accept-Language. Errors that are
+// not a *Problem become a 500 without details, except validation errors and
+// Fiber's own errors.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var p *Problem
+	var fe *fiber.Error
+	var syntax *taskquery.SyntaxError
+	switch {
+	case errors.As(err, &p):
+		copied := *p
+		p = &copied
+	case services.IsValidationError(err):
+		p = validationProblem(err)
+	case errors.As(err, &syntax):
+		p = taskQueryProblem(syntax)
+	case errors.As(err, &fe) && fe.Code == fiber.StatusNotFound:
+		p = problem(fe.Code, statusCode(fe.Code), "route_not_found", c.Method(), c.Path())
+	case errors.As(err, &fe):
+		p = problem(fe.Code, statusCode(fe.Code), "")
+		p.Detail = fe.Message
+	default:
+		p = internalError(c, err, "internal_error")
+	}
+	tr := i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+	p.localize(tr)
+	c.Set(fiber.HeaderContentLanguage, tr.Locale())
+	c.Vary(fiber.HeaderAcceptLanguage)
+	p.Instance = c.Path()
+	if id, ok := c.Locals(requestIDKey).(string); ok {
+		p.RequestID = id
+	}
+	return c.Status(p.Status).JSON(p, ProblemContentType)
+}
+
+// statusCode is the code of errors raised by Fiber, such as not_found.
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+	"todo/internal/health"
+	"todo/internal/idempotency"
+	"todo/internal/metrics"
+	"todo/internal/ratelimit"
+	"todo/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Handler serves the HTTP API on top of a Service.
+type Handler struct {
+	svc *services.Service
+	// cache keeps the responses of task reads; nil disables it.
+	cache *responseCache
+}
+
+func New(svc *services.Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// service returns the Service bound to the context of the request.
+func (h *Handler) service(c *fiber.Ctx) *services.Service {
+	return h.svc.WithContext(c.UserContext())
+}
+
+type appOptions struct {
+	logger        *slog.Logger
+	logSampleRate float64
+	metrics       *metrics.Metrics
+	tracer        trace.TracerProvider
+	health        *health.Checker
+	limiter       *ratelimit.Limiter
+	idempotency   idempotency.Store
+	idempotentTTL time.Duration
+	cacheTTL      time.Duration
+}
+
+// Headers of idempotent requests.
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// AppOption configures NewApp.
+type AppOption func(*appOptions)
+
+// WithLogger sets the logger of the access log and of request handlers. The
+// default is slog.Default.
+func WithLogger(logger *slog.Logger) AppOption {
+	return func(o *appOptions) { o.logger = logger }
+}
+
+// WithLogSampleRate logs only that fraction of successful requests. Failed
+// requests are always logged.
+func WithLogSampleRate(rate float64) AppOption {
+	return func(o *appOptions) { o.logSampleRate = rate }
+}
+
+// WithMetrics records the metrics of every request in m and serves m at
+// /metrics.
+func WithMetrics(m *metrics.Metrics) AppOption {
+	return func(o *appOptions) { o.metrics = m }
+}
+
+// WithTracerProvider starts a span per request with tp, continuing the
+// trace of incoming W3C traceparent headers.
+func WithTracerProvider(tp trace.TracerProvider) AppOption {
+	return func(o *appOptions) { o.tracer = tp }
+}
+
+// WithHealth serves the liveness and readiness probes of c at /healthz and
+// /readyz.
+func WithHealth(c *health.Checker) AppOption {
+	return func(o *appOptions) { o.health = c }
+}
+
+// WithRateLimiter charges every request but the health probes and metrics
+// to the budget of its client in l.
+func WithRateLimiter(l *ratelimit.Limiter) AppOption {
+	return func(o *appOptions) { o.limiter = l }
+}
+
+// WithIdempotency stores the responses of POST requests that carry an
+// Idempotency-Key header in store for ttl, and replays them to retries.
+func WithIdempotency(store idempotency.Store, ttl time.Duration) AppOption {
+	return func(o *appOptions) {
+		o.idempotency = store
+		o.idempotentTTL = ttl
+	}
+}
+
+// WithResponseCache keeps the responses of GET /tasks, /tasks/:id and
+// /tasks/:id/subtasks in memory until the next write through the Service,
+// or for at most ttl, which bounds how long writes made by other servers
+// on the same database go unnoticed.
+func WithResponseCache(ttl time.Duration) AppOption {
+	return func(o *appOptions) { o.cacheTTL = ttl }
+}
+
+// NewApp returns a Fiber app serving every route of h, allowing
+// cross-origin requests from corsOrigins.
+func NewApp(h *Handler, corsOrigins []string, opts ...AppOption) *fiber.App {
+	o := appOptions{logger: slog.Default(), logSampleRate: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(requestID)
+	app.Use(identifyCaller)
+	if o.tracer != nil {
+		app.Use(traceRequests(o.tracer))
+	}
+	app.Use(accessLog(o.logger, o.logSampleRate))
+	if o.metrics != nil {
+		app.Use(requestMetrics(o.metrics))
+	}
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:  strings.Join(corsOrigins, ","),
+		AllowMethods:  "GET,POST,PUT,DELETE,PATCH",
+		AllowHeaders:  "Authorization, Content-Type, Idempotency-Key, If-None-Match, If-Modified-Since, X-Request-ID, traceparent, tracestate",
+		ExposeHeaders: fiber.HeaderXRequestID + ", traceparent, tracestate, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, Idempotent-Replayed, ETag",
+	}))
+	// After CORS, so that preflight requests cost nothing and rejections
+	// still carry the CORS headers.
+	if o.limiter != nil {
+		app.Use(rateLimit(o.limiter, map[string]bool{"/metrics": true, "/healthz": true, "/readyz": true}))
+	}
+	// After rate limiting, so that rejected requests do not claim their key.
+	if o.idempotency != nil {
+		app.Use(idempotent(o.idempotency, o.idempotentTTL))
+	}
+	// Registered after all middleware, which next relies on to tell
+	// unmatched requests apart.
+	if o.metrics != nil {
+		app.Get("/metrics", adaptor.HTTPHandler(o.metrics.Handler()))
+	}
+	if o.health != nil {
+		app.Get("/healthz", probe(o.health.Live))
+		app.Get("/readyz", probe(o.health.Ready))
+	}
+	if o.cacheTTL > 0 {
+		h = &Handler{svc: h.svc, cache: newResponseCache(o.cacheTTL)}
+	}
+	h.RegisterRoutes(app)
+	return app
+}
+
+// RegisterRoutes mounts every API route on router. Routes added here must
+// also be described in the docs package; the tests fail when the two drift.
+func (h *Handler) RegisterRoutes(router fiber.Router) {
+	router.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("Server is up and running!")
+	})
+
+	router.Post("/tasks", h.CreateTask)
+	router.Get("/tasks", h.GetTasks)
+	router.Get("/tasks/:id", h.GetTaskByID)
+	router.Put("/tasks/:id", h.UpdateTask)
+	router.Delete("/tasks/:id", h.DeleteTask)
+	router.Patch("/tasks/:id/done", h.UpdateTaskDone)
+
+	router.Post("/tasks/:id/subtasks", h.CreateSubtask)
+	router.Get("/tasks/:id/subtasks", h.GetSubtasks)
+	router.Put("/subtasks/:id", h.UpdateSubtask)
+	router.Delete("/subtasks/:id", h.DeleteSubtask)
+	router.Patch("/subtasks/:id/done", h.UpdateSubtaskDone)
+
+	router.Get("/stats", h.GetStats)
+	router.Get("/analytics/burndown", h.GetBurndown)
+	router.Get("/analytics/throughput", h.GetThroughput)
+	router.Get("/analytics/cycle-time", h.GetCycleTimes)
+	router.Get("/agenda", h.GetAgenda)
+	router.Get("/agenda/:bucket", h.GetAgenda)
+
+	router.Get("/views", h.GetViews)
+	router.Post("/views", h.CreateView)
+	router.Get("/views/:id", h.GetView)
+	router.Put("/views/:id", h.UpdateView)
+	router.Delete("/views/:id", h.DeleteView)
+	router.Get("/views/:id/tasks", h.GetViewTasks)
+
+	router.Post("/graphql", h.GraphQL)
+
+	router.Get("/openapi.json", h.OpenAPI)
+	router.Get("/docs", h.Docs)
+}
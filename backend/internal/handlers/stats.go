@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"time"
+	"todo/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *Handler) GetStats(c *fiber.Ctx) error {
+	var filter models.TaskFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return problem(fiber.StatusBadRequest, CodeInvalidQuery, "invalid_query")
+	}
+	stats, err := h.service(c).TaskStats(filter, time.Now())
+	if err != nil {
+		if p := inputProblem(err); p != nil {
+			return p
+		}
+		return internalError(c, err, "get_stats_failed")
+	}
+	return c.JSON(stats)
+}
@@ -1,110 +1,111 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
-	"todo/internal/database"
+	"time"
 	"todo/internal/models"
+	"todo/internal/services"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 )
 
-func CreateSubtask(c *fiber.Ctx) error {
+func (h *Handler) CreateSubtask(c *fiber.Ctx) error {
 	taskID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid task ID"})
+		return problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_task_id")
 	}
 	var subtask models.Subtask
 	if err := c.BodyParser(&subtask); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		return problem(fiber.StatusBadRequest, CodeInvalidJSON, "invalid_json")
 	}
 	subtask.TaskID = uint(taskID)
-	if err := validate.Struct(&subtask); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
-	}
-	if err := database.DB.Create(&subtask).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not create subtask"})
+	if err := h.service(c).CreateSubtask(&subtask); err != nil {
+		if isValidationError(err) {
+			return validationProblem(err)
+		}
+		return internalError(c, err, "create_subtask_failed")
 	}
 	return c.Status(fiber.StatusCreated).JSON(subtask)
 }
 
-func GetSubtasks(c *fiber.Ctx) error {
+func (h *Handler) GetSubtasks(c *fiber.Ctx) error {
 	taskID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid task ID"})
+		return problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_task_id")
 	}
-	var subtasks []models.Subtask
-	if err := database.DB.Where("task_id = ?", taskID).Find(&subtasks).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve subtasks"})
-	}
-	return c.JSON(subtasks)
+	return h.sendCacheable(c, func(svc *services.Service) (interface{}, time.Time, error) {
+		subtasks, err := svc.ListSubtasks(uint(taskID))
+		if err != nil {
+			return nil, time.Time{}, internalError(c, err, "list_subtasks_failed")
+		}
+		return subtasks, subtasksModified(subtasks), nil
+	})
 }
 
-func UpdateSubtask(c *fiber.Ctx) error {
+func (h *Handler) UpdateSubtask(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid subtask ID"})
+		return problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_subtask_id")
 	}
-	var subtask models.Subtask
-	if err := database.DB.First(&subtask, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Subtask not found"})
+	subtask, err := h.service(c).FindSubtask(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrSubtaskNotFound) {
+			return problem(fiber.StatusNotFound, CodeSubtaskNotFound, "subtask_not_found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve subtask"})
+		return internalError(c, err, "get_subtask_failed")
 	}
 	var updateSubtask models.Subtask
 	if err := c.BodyParser(&updateSubtask); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
-	}
-	if err := validate.Struct(&updateSubtask); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return problem(fiber.StatusBadRequest, CodeInvalidJSON, "invalid_json")
 	}
-	subtask.Title = updateSubtask.Title
-	if err := database.DB.Save(&subtask).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not update subtask"})
+	if err := h.service(c).UpdateSubtask(&subtask, updateSubtask); err != nil {
+		if isValidationError(err) {
+			return validationProblem(err)
+		}
+		return internalError(c, err, "update_subtask_failed")
 	}
 	return c.JSON(subtask)
 }
 
-func DeleteSubtask(c *fiber.Ctx) error {
+func (h *Handler) DeleteSubtask(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid subtask ID"})
+		return problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_subtask_id")
 	}
-	var subtask models.Subtask
-	if err := database.DB.First(&subtask, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Subtask not found"})
+	subtask, err := h.service(c).FindSubtask(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrSubtaskNotFound) {
+			return problem(fiber.StatusNotFound, CodeSubtaskNotFound, "subtask_not_found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not delete subtask"})
+		return internalError(c, err, "delete_subtask_failed")
 	}
-	if err := database.DB.Delete(&subtask).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not delete subtask"})
+	if err := h.service(c).DeleteSubtask(&subtask); err != nil {
+		return internalError(c, err, "delete_subtask_failed")
 	}
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-func UpdateSubtaskDone(c *fiber.Ctx) error {
+func (h *Handler) UpdateSubtaskDone(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid subtask ID"})
+		return problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_subtask_id")
 	}
 	var input struct {
 		Done bool `json:"done"`
 	}
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		return problem(fiber.StatusBadRequest, CodeInvalidJSON, "invalid_json")
 	}
-	var subtask models.Subtask
-	if err := database.DB.First(&subtask, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Subtask not found"})
+	subtask, err := h.service(c).FindSubtask(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrSubtaskNotFound) {
+			return problem(fiber.StatusNotFound, CodeSubtaskNotFound, "subtask_not_found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve subtask"})
+		return internalError(c, err, "get_subtask_failed")
 	}
-	subtask.Done = input.Done
-	if err := database.DB.Save(&subtask).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not update subtask"})
+	if err := h.service(c).SetSubtaskDone(&subtask, input.Done); err != nil {
+		return internalError(c, err, "update_subtask_failed")
 	}
 	return c.JSON(subtask)
-}
\ No newline at end of file
+}
@@ -1,120 +1,126 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
-	"todo/internal/database"
+	"time"
 	"todo/internal/models"
+	"todo/internal/services"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 )
 
-func CreateTask(c *fiber.Ctx) error {
+func (h *Handler) CreateTask(c *fiber.Ctx) error {
 	var task models.Task
 	if err := c.BodyParser(&task); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		return problem(fiber.StatusBadRequest, CodeInvalidJSON, "invalid_json")
 	}
-	if err := validate.Struct(&task); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
-	}
-	if err := database.DB.Create(&task).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not create task"})
+	if err := h.service(c).CreateTask(&task); err != nil {
+		if isValidationError(err) {
+			return validationProblem(err)
+		}
+		return internalError(c, err, "create_task_failed")
 	}
 	return c.Status(fiber.StatusCreated).JSON(task)
 }
 
-func GetTasks(c *fiber.Ctx) error {
-	var tasks []models.Task
-	if err := database.DB.Preload("Subtasks").Find(&tasks).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve tasks"})
-	}
-	return c.JSON(tasks)
+func (h *Handler) GetTasks(c *fiber.Ctx) error {
+	var filter models.TaskFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return problem(fiber.StatusBadRequest, CodeInvalidQuery, "invalid_query")
+	}
+	return h.sendTaskList(c, filter.Query, func(svc *services.Service) (interface{}, time.Time, error) {
+		tasks, err := svc.ListTasks(filter)
+		if err != nil {
+			if p := inputProblem(err); p != nil {
+				return nil, time.Time{}, p
+			}
+			return nil, time.Time{}, internalError(c, err, "list_tasks_failed")
+		}
+		return tasks, tasksModified(tasks...), nil
+	})
 }
 
-func GetTaskByID(c *fiber.Ctx) error {
+func (h *Handler) GetTaskByID(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid task ID"})
-	}
-	var task models.Task
-	if err := database.DB.Preload("Subtasks").First(&task, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+		return problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_task_id")
+	}
+	return h.sendCacheable(c, func(svc *services.Service) (interface{}, time.Time, error) {
+		task, err := svc.GetTask(uint(id))
+		if err != nil {
+			if errors.Is(err, services.ErrTaskNotFound) {
+				return nil, time.Time{}, problem(fiber.StatusNotFound, CodeTaskNotFound, "task_not_found")
+			}
+			return nil, time.Time{}, internalError(c, err, "get_task_failed")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve task"})
-	}
-	return c.JSON(task)
+		return task, tasksModified(task), nil
+	})
 }
 
-func UpdateTask(c *fiber.Ctx) error {
+func (h *Handler) UpdateTask(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid task ID"})
+		return problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_task_id")
 	}
-	var task models.Task
-	if err := database.DB.First(&task, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	task, err := h.service(c).FindTask(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			return problem(fiber.StatusNotFound, CodeTaskNotFound, "task_not_found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve task"})
+		return internalError(c, err, "get_task_failed")
 	}
 	var updateTask models.Task
 	if err := c.BodyParser(&updateTask); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
-	}
-	if err := validate.Struct(&updateTask); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return problem(fiber.StatusBadRequest, CodeInvalidJSON, "invalid_json")
 	}
-	task.Title = updateTask.Title
-	task.Description = updateTask.Description
-	task.Priority = updateTask.Priority
-	task.Assignee = updateTask.Assignee
-	task.DueDate = updateTask.DueDate
-	if err := database.DB.Save(&task).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not update task"})
+	if err := h.service(c).UpdateTask(&task, updateTask); err != nil {
+		if isValidationError(err) {
+			return validationProblem(err)
+		}
+		return internalError(c, err, "update_task_failed")
 	}
 	return c.JSON(task)
 }
 
-func DeleteTask(c *fiber.Ctx) error {
+func (h *Handler) DeleteTask(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid task ID"})
+		return problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_task_id")
 	}
-	var task models.Task
-	if err := database.DB.First(&task, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	task, err := h.service(c).FindTask(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			return problem(fiber.StatusNotFound, CodeTaskNotFound, "task_not_found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not delete task"})
+		return internalError(c, err, "delete_task_failed")
 	}
-	if err := database.DB.Delete(&task).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not delete task"})
+	if err := h.service(c).DeleteTask(&task); err != nil {
+		return internalError(c, err, "delete_task_failed")
 	}
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-func UpdateTaskDone(c *fiber.Ctx) error {
+func (h *Handler) UpdateTaskDone(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid task ID"})
+		return problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_task_id")
 	}
 	var input struct {
 		Done bool `json:"done"`
 	}
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+		return problem(fiber.StatusBadRequest, CodeInvalidJSON, "invalid_json")
 	}
-	var task models.Task
-	if err := database.DB.First(&task, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Task not found"})
+	task, err := h.service(c).FindTask(uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrTaskNotFound) {
+			return problem(fiber.StatusNotFound, CodeTaskNotFound, "task_not_found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not retrieve task"})
+		return internalError(c, err, "get_task_failed")
 	}
-	task.Done = input.Done
-	if err := database.DB.Save(&task).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not update task"})
+	if err := h.service(c).SetTaskDone(&task, input.Done); err != nil {
+		return internalError(c, err, "update_task_failed")
 	}
 	return c.JSON(task)
-}
\ No newline at end of file
+}
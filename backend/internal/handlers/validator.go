@@ -1,5 +1,5 @@
 package handlers
 
-import "github.com/go-playground/validator/v10"
+import "todo/internal/services"
 
-var validate = validator.New()
\ No newline at end of file
+var isValidationError = services.IsValidationError
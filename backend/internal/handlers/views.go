@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"todo/internal/models"
+	"todo/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Views belong to the caller as clientKey identifies it: the authenticated
+// user, else the bearer token, else the client IP. An IP is shared behind
+// NAT and proxies and changes over time, so callers identified only by it
+// may save shared views but not private ones.
+
+func (h *Handler) GetViews(c *fiber.Ctx) error {
+	views, err := h.service(c).ListViews(clientKey(c))
+	if err != nil {
+		return internalError(c, err, "list_views_failed")
+	}
+	return c.JSON(views)
+}
+
+func (h *Handler) CreateView(c *fiber.Ctx) error {
+	var view models.SavedView
+	if err := c.BodyParser(&view); err != nil {
+		return problem(fiber.StatusBadRequest, CodeInvalidJSON, "invalid_json")
+	}
+	if err := requireOwnerFor(c, view); err != nil {
+		return err
+	}
+	if err := h.service(c).CreateView(&view, clientKey(c)); err != nil {
+		if p := inputProblem(err); p != nil {
+			return p
+		}
+		return internalError(c, err, "create_view_failed")
+	}
+	return c.Status(fiber.StatusCreated).JSON(view)
+}
+
+func (h *Handler) GetView(c *fiber.Ctx) error {
+	view, err := h.findView(c, "get_view_failed")
+	if err != nil {
+		return err
+	}
+	return c.JSON(view)
+}
+
+func (h *Handler) UpdateView(c *fiber.Ctx) error {
+	view, err := h.findView(c, "get_view_failed")
+	if err != nil {
+		return err
+	}
+	var input models.SavedView
+	if err := c.BodyParser(&input); err != nil {
+		return problem(fiber.StatusBadRequest, CodeInvalidJSON, "invalid_json")
+	}
+	if err := requireOwnerFor(c, input); err != nil {
+		return err
+	}
+	if err := h.service(c).UpdateView(&view, input, clientKey(c)); err != nil {
+		if p := inputProblem(err); p != nil {
+			return p
+		}
+		switch {
+		case errors.Is(err, services.ErrNotViewOwner):
+			return problem(fiber.StatusForbidden, CodeNotViewOwner, "not_view_owner")
+		case errors.Is(err, services.ErrViewNotFound):
+			return problem(fiber.StatusNotFound, CodeViewNotFound, "view_not_found")
+		}
+		return internalError(c, err, "update_view_failed")
+	}
+	return c.JSON(view)
+}
+
+func (h *Handler) DeleteView(c *fiber.Ctx) error {
+	view, err := h.findView(c, "delete_view_failed")
+	if err != nil {
+		return err
+	}
+	if err := h.service(c).DeleteView(&view, clientKey(c)); err != nil {
+		if errors.Is(err, services.ErrNotViewOwner) {
+			return problem(fiber.StatusForbidden, CodeNotViewOwner, "not_view_owner")
+		}
+		return internalError(c, err, "delete_view_failed")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetViewTasks lists the tasks of a view like GetTasks, grouped as the
+// view asks. The view is looked up first, so that cached responses are
+// only sent to callers who may see it.
+func (h *Handler) GetViewTasks(c *fiber.Ctx) error {
+	view, err := h.findView(c, "get_view_failed")
+	if err != nil {
+		return err
+	}
+	return h.sendTaskList(c, view.Definition.Filter.Query, func(svc *services.Service) (interface{}, time.Time, error) {
+		tasks, err := svc.ViewTasks(view)
+		if err != nil {
+			if p := inputProblem(err); p != nil {
+				return nil, time.Time{}, p
+			}
+			return nil, time.Time{}, internalError(c, err, "list_tasks_failed")
+		}
+		modified := view.UpdatedAt
+		for _, group := range tasks.Groups {
+			if t := tasksModified(group.Tasks...); t.After(modified) {
+				modified = t
+			}
+		}
+		return tasks, modified, nil
+	})
+}
+
+// requireOwnerFor answers 401 when view would be private but the caller
+// is only known by IP address.
+func requireOwnerFor(c *fiber.Ctx, view models.SavedView) error {
+	if view.Visibility == models.VisibilityShared || !strings.HasPrefix(clientKey(c), "ip:") {
+		return nil
+	}
+	c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+	return problem(fiber.StatusUnauthorized, CodeTokenRequired, "private_view_needs_token")
+}
+
+// findView returns the view named by the id parameter if the caller may
+// see it, or the problem to respond with.
+func (h *Handler) findView(c *fiber.Ctx, failure string) (models.SavedView, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return models.SavedView{}, problem(fiber.StatusBadRequest, CodeInvalidID, "invalid_view_id")
+	}
+	view, err := h.service(c).GetView(uint(id), clientKey(c))
+	if err != nil {
+		if errors.Is(err, services.ErrViewNotFound) {
+			return view, problem(fiber.StatusNotFound, CodeViewNotFound, "view_not_found")
+		}
+		return view, internalError(c, err, failure)
+	}
+	return view, nil
+}
@@ -0,0 +1,104 @@
+// Package health answers the liveness (/healthz) and readiness (/readyz)
+// probes of the server from a set of named checks.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+	"todo/internal/logging"
+)
+
+// CheckTimeout bounds each check, so a hanging database fails the probe
+// instead of stalling it.
+const CheckTimeout = 2 * time.Second
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	// StatusDraining is the readiness status once the server is shutting
+	// down, so that load balancers stop sending it new requests.
+	StatusDraining = "draining"
+)
+
+// Check returns an error when a dependency of the server is unusable.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker holds the checks of both probes. Checks are added at startup,
+// before the probes are served.
+type Checker struct {
+	live     []namedCheck
+	ready    []namedCheck
+	draining atomic.Bool
+}
+
+func New() *Checker {
+	return &Checker{}
+}
+
+// AddLiveness adds a check to both probes. A failing liveness probe gets
+// the process restarted, so it should only check the process itself, not
+// dependencies shared with other replicas.
+func (c *Checker) AddLiveness(name string, check Check) {
+	c.live = append(c.live, namedCheck{name, check})
+	c.ready = append(c.ready, namedCheck{name, check})
+}
+
+// AddReadiness adds a check to the readiness probe only.
+func (c *Checker) AddReadiness(name string, check Check) {
+	c.ready = append(c.ready, namedCheck{name, check})
+}
+
+// Drain makes the readiness probe fail from now on. It is called when
+// shutdown starts.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Report is the body of a probe response. Checks maps each check to "ok"
+// or "failing"; the reasons are logged rather than exposed.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Live runs the liveness checks.
+func (c *Checker) Live(ctx context.Context) Report {
+	return run(ctx, c.live)
+}
+
+// Ready runs the readiness checks, failing without running them while the
+// server drains.
+func (c *Checker) Ready(ctx context.Context) Report {
+	if c.draining.Load() {
+		return Report{Status: StatusDraining, Checks: map[string]string{}}
+	}
+	return run(ctx, c.ready)
+}
+
+func run(ctx context.Context, checks []namedCheck) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(checks))}
+	for _, nc := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, CheckTimeout)
+		err := nc.check(checkCtx)
+		cancel()
+		if err != nil {
+			logging.FromContext(ctx).Warn("health check failed", slog.String("check", nc.name), slog.Any("error", err))
+			report.Status = StatusUnavailable
+			report.Checks[nc.name] = "failing"
+			continue
+		}
+		report.Checks[nc.name] = StatusOK
+	}
+	return report
+}
@@ -0,0 +1,173 @@
+// Package i18n holds the message catalogues of the API and picks one from
+// an Accept-Language header.
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fa"
+	ut "github.com/go-playground/universal-translator"
+	"gopkg.in/yaml.v3"
+)
+
+// Default is the language used when a client accepts none of the
+// catalogues, and for messages missing from the negotiated one.
+const Default = "en"
+
+//go:embed locales/*.yaml
+var catalogueFS embed.FS
+
+var (
+	universal  *ut.UniversalTranslator
+	catalogues map[string]map[string]string
+	// supported lists the locales in preference order, Default first, so
+	// that it wins when Accept-Language is absent or a wildcard.
+	supported []string
+)
+
+func init() {
+	translators := []locales.Translator{en.New(), de.New(), fa.New()}
+	universal = ut.New(translators[0], translators...)
+	catalogues = make(map[string]map[string]string, len(translators))
+	for _, l := range translators {
+		locale := l.Locale()
+		messages, err := loadCatalogue(locale)
+		if err != nil {
+			panic(err)
+		}
+		tr, _ := universal.GetTranslator(locale)
+		for key, text := range messages {
+			if err := tr.Add(key, text, false); err != nil {
+				panic(fmt.Errorf("i18n: %s: %s: %w", locale, key, err))
+			}
+		}
+		catalogues[locale] = messages
+		supported = append(supported, locale)
+	}
+}
+
+func loadCatalogue(locale string) (map[string]string, error) {
+	data, err := catalogueFS.ReadFile(path.Join("locales", locale+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("i18n: missing catalogue for %s: %w", locale, err)
+	}
+	var messages map[string]string
+	if err := yaml.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("i18n: %s: %w", locale, err)
+	}
+	return messages, nil
+}
+
+// Locales returns the supported locales, Default first.
+func Locales() []string {
+	return append([]string(nil), supported...)
+}
+
+// Keys returns the sorted message keys of a locale's catalogue, or nil when
+// the locale is not supported.
+func Keys(locale string) []string {
+	messages, ok := catalogues[locale]
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(messages))
+	for key := range messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Message returns the untranslated text of key in a locale's catalogue.
+func Message(locale, key string) (string, bool) {
+	text, ok := catalogues[locale][key]
+	return text, ok
+}
+
+// Translator formats messages in one locale.
+type Translator struct {
+	tr ut.Translator
+}
+
+// For returns the Translator of locale, or of Default when locale is not
+// supported.
+func For(locale string) Translator {
+	tr, ok := universal.GetTranslator(strings.ToLower(locale))
+	if !ok {
+		tr = universal.GetFallback()
+	}
+	return Translator{tr: tr}
+}
+
+// Locale returns the locale messages are formatted in.
+func (t Translator) Locale() string {
+	if t.tr == nil {
+		return Default
+	}
+	return t.tr.Locale()
+}
+
+// T formats the message key, replacing {0}, {1}, ... with params. Keys
+// missing from the catalogue fall back to Default, then to the key itself.
+func (t Translator) T(key string, params ...string) string {
+	if t.tr != nil {
+		if text, err := t.tr.T(key, params...); err == nil {
+			return text
+		}
+	}
+	if text, err := universal.GetFallback().T(key, params...); err == nil {
+		return text
+	}
+	return key
+}
+
+// Negotiate returns the Translator of the most preferred supported language
+// of an Accept-Language header. Regional tags fall back to their language,
+// so de-AT is served in German; anything unsupported gets Default.
+func Negotiate(acceptLanguage string) Translator {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+	var prefs []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			v, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = v
+		}
+		if quality <= 0 {
+			continue
+		}
+		prefs = append(prefs, preference{tag: tag, quality: quality})
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].quality > prefs[j].quality })
+
+	for _, p := range prefs {
+		if p.tag == "*" {
+			break
+		}
+		tag := strings.ReplaceAll(p.tag, "-", "_")
+		base, _, _ := strings.Cut(tag, "_")
+		if tr, ok := universal.FindTranslator(tag, base); ok {
+			return Translator{tr: tr}
+		}
+	}
+	return For(Default)
+}
@@ -0,0 +1,97 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errClaimRace = errors.New("idempotency: key kept changing while claiming it")
+
+// purgeInterval is how often GormStore deletes expired records.
+const purgeInterval = time.Minute
+
+// GormStore keeps the records in the idempotency_keys table, so that they
+// are shared by every server on the database and survive restarts.
+type GormStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Claim(ctx context.Context, rec Record, now time.Time) (*Record, error) {
+	db := s.db.WithContext(ctx)
+	s.purge(db, now)
+	// A few rounds settle races with other claims and releases.
+	for attempt := 0; attempt < 3; attempt++ {
+		// Inserting is the atomic step: of two concurrent claims, the
+		// primary key lets only one through.
+		rec.CreatedAt = now
+		res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rec)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			return nil, nil
+		}
+		var existing Record
+		err := db.Where("id = ?", rec.ID).Take(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Released in the meantime; claim it again.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if existing.live(now) {
+			return &existing, nil
+		}
+		// Expired or abandoned: delete it and insert again, which the
+		// primary key still decides between concurrent claims.
+		if err := db.Where("id = ?", rec.ID).Where(dead(now)).Delete(&Record{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return nil, errClaimRace
+}
+
+func (s *GormStore) Complete(ctx context.Context, rec Record) error {
+	return s.db.WithContext(ctx).Model(&Record{}).Where("id = ?", rec.ID).Updates(map[string]interface{}{
+		"status":       rec.Status,
+		"content_type": rec.ContentType,
+		"body":         rec.Body,
+		"expires_at":   rec.ExpiresAt,
+	}).Error
+}
+
+func (s *GormStore) Release(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Where("id = ?", id).Delete(&Record{}).Error
+}
+
+// purge deletes completed records past their expiry, at most once per
+// purgeInterval. A failure only delays it to the next claim.
+func (s *GormStore) purge(db *gorm.DB, now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastPurge) < purgeInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPurge = now
+	s.mu.Unlock()
+	db.Where(dead(now)).Delete(&Record{})
+}
+
+// dead matches the records that no longer hold their key at now, as
+// Record.live decides.
+func dead(now time.Time) clause.Expr {
+	return gorm.Expr("(status = 0 AND created_at <= ?) OR (status <> 0 AND expires_at <= ?)", now.Add(-LockTimeout), now)
+}
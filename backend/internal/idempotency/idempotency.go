@@ -0,0 +1,75 @@
+// Package idempotency remembers the responses of requests sent with an
+// Idempotency-Key header, so that a retried request gets the first response
+// again instead of being carried out twice.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// LockTimeout is how long a request may hold its key before the key counts
+// as abandoned, as when the server died before the request finished.
+const LockTimeout = time.Minute
+
+// Record is the stored outcome of the first request with a key. Status is 0
+// while that request is still running.
+type Record struct {
+	// ID hashes the client and its key; see ID.
+	ID string `gorm:"primaryKey;size:64"`
+	// Fingerprint hashes the request, to tell retries from a reused key.
+	Fingerprint string `gorm:"size:64;not null"`
+	Status      int    `gorm:"not null;default:0"`
+	ContentType string `gorm:"size:255"`
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+func (Record) TableName() string { return "idempotency_keys" }
+
+// Pending reports whether the first request with the key is still running.
+func (r *Record) Pending() bool {
+	return r.Status == 0
+}
+
+// live reports whether r still holds its key at now.
+func (r *Record) live(now time.Time) bool {
+	if r.Pending() {
+		return now.Before(r.CreatedAt.Add(LockTimeout))
+	}
+	return now.Before(r.ExpiresAt)
+}
+
+// Store keeps the records. Claim must be atomic, so that of two concurrent
+// requests with the same key only one runs.
+type Store interface {
+	// Claim stores rec as pending and returns nil, or returns the live
+	// record already stored under rec.ID and leaves it alone.
+	Claim(ctx context.Context, rec Record, now time.Time) (*Record, error)
+	// Complete stores the response of a claimed record.
+	Complete(ctx context.Context, rec Record) error
+	// Release drops a claimed record, so that the request can be retried.
+	Release(ctx context.Context, id string) error
+}
+
+// ID derives the record ID from the client and the key it sent. Hashing
+// keeps keys of any length and content out of the database.
+func ID(client, key string) string {
+	return hash(client, "\x00", key)
+}
+
+// Fingerprint hashes the parts of a request that a retry repeats exactly.
+func Fingerprint(method, uri string, body []byte) string {
+	return hash(method, " ", uri, "\x00", string(body))
+}
+
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,129 @@
+// Package metrics collects the Prometheus metrics served at /metrics: HTTP
+// traffic, database queries and connections, and task counts.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"todo/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "todo"
+
+// UnmatchedRoute labels requests that matched no route, so that probes for
+// random paths cannot blow up the number of series.
+const UnmatchedRoute = "unmatched"
+
+// Metrics owns a registry with the process metrics and those of the server.
+// Each Metrics is independent, so tests can run several side by side.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	queryErrors     *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests served, by route template and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests, by route template and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Time taken by database queries, by operation and table.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Database queries that failed, by operation and table. Lookups of missing rows are not errors.",
+		}, []string{"operation", "table"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.queryDuration,
+		m.queryErrors,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format. A failing
+// collector, such as the task counts while the database is down, drops only
+// its own metrics from the scrape.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		Registry:      m.registry,
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// ObserveRequest records one served HTTP request. route is the route
+// template, such as /tasks/:id, or UnmatchedRoute.
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.requestDuration.WithLabelValues(method, route, code).Observe(d.Seconds())
+}
+
+// TaskCounter reports the open and overdue tasks per priority at a time,
+// as services.Service.OpenTaskCounts does.
+type TaskCounter func(now time.Time) ([]models.PriorityCount, error)
+
+// RegisterTaskCounts exports the counts of count as gauges, computed afresh
+// on every scrape.
+func (m *Metrics) RegisterTaskCounts(count TaskCounter) error {
+	return m.registry.Register(&taskCollector{count: count})
+}
+
+var (
+	openTasksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "tasks_open"),
+		"Tasks that are not done, by priority.",
+		[]string{"priority"}, nil,
+	)
+	overdueTasksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "tasks_overdue"),
+		"Tasks that are not done and past their due date, by priority.",
+		[]string{"priority"}, nil,
+	)
+)
+
+type taskCollector struct {
+	count TaskCounter
+}
+
+func (c *taskCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openTasksDesc
+	ch <- overdueTasksDesc
+}
+
+func (c *taskCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.count(time.Now())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(openTasksDesc, err)
+		return
+	}
+	for _, pc := range counts {
+		ch <- prometheus.MustNewConstMetric(openTasksDesc, prometheus.GaugeValue, float64(pc.Open), pc.Priority)
+		ch <- prometheus.MustNewConstMetric(overdueTasksDesc, prometheus.GaugeValue, float64(pc.Overdue), pc.Priority)
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+// The buckets of an agenda, in the order they are listed.
+const (
+	BucketOverdue  = "overdue"
+	BucketToday    = "today"
+	BucketTomorrow = "tomorrow"
+	BucketThisWeek = "this-week"
+	BucketLater    = "later"
+	BucketNoDate   = "no-date"
+)
+
+var AgendaBuckets = []string{BucketOverdue, BucketToday, BucketTomorrow, BucketThisWeek, BucketLater, BucketNoDate}
+
+// AgendaQuery selects the open tasks of an agenda and the day it is for.
+type AgendaQuery struct {
+	Assignee string `query:"assignee" json:"assignee"`
+	// TZ is the IANA time zone whose midnights separate the days, UTC when
+	// empty.
+	TZ string `query:"tz" json:"tz" validate:"omitempty,timezone"`
+	// Bucket restricts the agenda to one bucket; every bucket is listed
+	// when empty.
+	Bucket string `json:"bucket" validate:"omitempty,oneof=overdue today tomorrow this-week later no-date"`
+}
+
+// Agenda sorts the open tasks by when they are due.
+type Agenda struct {
+	// Date is today in TZ, as 2006-01-02.
+	Date    string         `json:"date"`
+	TZ      string         `json:"tz"`
+	Buckets []AgendaBucket `json:"buckets"`
+}
+
+// AgendaBucket holds the open tasks due in one span of time: overdue tasks
+// are due before now, or before today for date-only deadlines, this-week
+// runs from the day after tomorrow until Sunday midnight, and no-date
+// collects tasks without a due date. A date-only deadline falls on the same
+// day in every time zone.
+type AgendaBucket struct {
+	Name string `json:"name"`
+	// Tasks are soonest due first, each with only its open subtasks, which
+	// are due with their task.
+	Tasks        []Task `json:"tasks"`
+	OpenSubtasks int    `json:"open_subtasks"`
+}
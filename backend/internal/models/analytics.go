@@ -0,0 +1,73 @@
+package models
+
+// AnalyticsQuery selects the days an analytics report covers.
+type AnalyticsQuery struct {
+	// From and To are the first and the last day, as 2006-01-02. They
+	// default to the 30 days ending today.
+	From string `query:"from" json:"from" validate:"omitempty,datetime=2006-01-02"`
+	To   string `query:"to" json:"to" validate:"omitempty,datetime=2006-01-02"`
+	// TZ is the IANA time zone whose midnights separate the days, UTC when
+	// empty.
+	TZ string `query:"tz" json:"tz" validate:"omitempty,timezone"`
+}
+
+// AnalyticsRange echoes the days a report covers, with defaults filled in.
+type AnalyticsRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	TZ   string `json:"tz"`
+}
+
+// Burndown is the number of open tasks at the end of each day.
+type Burndown struct {
+	AnalyticsRange
+	Points []BurndownPoint `json:"points"`
+}
+
+type BurndownPoint struct {
+	Date string `json:"date"`
+	Open int64  `json:"open"`
+	// Opened counts the tasks created open or reopened during the day, and
+	// Closed those completed or deleted while open.
+	Opened int64 `json:"opened"`
+	Closed int64 `json:"closed"`
+}
+
+// Throughput counts the tasks completed per week and assignee.
+type Throughput struct {
+	AnalyticsRange
+	// Weeks lists the Monday starting each week; every series has one
+	// count per week.
+	Weeks  []string           `json:"weeks"`
+	Series []ThroughputSeries `json:"series"`
+}
+
+type ThroughputSeries struct {
+	// Assignee is who the task was assigned to when it was completed; ""
+	// collects unassigned tasks.
+	Assignee  string  `json:"assignee"`
+	Completed []int64 `json:"completed"`
+	Total     int64   `json:"total"`
+}
+
+// CycleTimes describes how long the tasks completed in a range took. Lead
+// time runs from creation to completion; cycle time from the last time the
+// task was created or reopened to completion, so that reopened tasks only
+// count their last round.
+type CycleTimes struct {
+	AnalyticsRange
+	Completed int           `json:"completed"`
+	LeadTime  DurationStats `json:"lead_time"`
+	CycleTime DurationStats `json:"cycle_time"`
+}
+
+// DurationStats summarizes durations in seconds, with nearest-rank
+// percentiles. Every field is 0 without durations.
+type DurationStats struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P75  float64 `json:"p75"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	Max  float64 `json:"max"`
+}
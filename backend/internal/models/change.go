@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Kinds of TaskChange.
+const (
+	ChangeCreated   = "created"
+	ChangeCompleted = "completed"
+	ChangeReopened  = "reopened"
+	ChangeDeleted   = "deleted"
+)
+
+// TaskChange records a task being created, completed, reopened or deleted,
+// for analytics over time. Changes outlive their task.
+type TaskChange struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	TaskID uint   `gorm:"index;not null" json:"task_id"`
+	Kind   string `gorm:"size:16;not null" json:"kind"`
+	// Assignee is the assignee of the task when it changed.
+	Assignee string `json:"assignee"`
+	// OpenDelta is how the change moved the number of open tasks: 1 for
+	// an open task created or a task reopened, -1 for a task completed or
+	// deleted while open, 0 otherwise.
+	OpenDelta  int       `gorm:"not null" json:"open_delta"`
+	OccurredAt time.Time `gorm:"index;not null" json:"occurred_at"`
+}
+
+// TaskChangeFilter selects task changes. The zero value matches all.
+type TaskChangeFilter struct {
+	// From and To bound OccurredAt, From inclusive and To exclusive, when
+	// not zero.
+	From, To time.Time
+	Kinds    []string
+	TaskIDs  []uint
+}
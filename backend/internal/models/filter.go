@@ -0,0 +1,14 @@
+package models
+
+// TaskFilter narrows and orders the task list. The zero value matches every
+// task in insertion order.
+type TaskFilter struct {
+	Assignee string `query:"assignee" json:"assignee"`
+	// Search matches words in the title or description.
+	Search string `query:"search" json:"search"`
+	Status string `query:"status" json:"status" validate:"omitempty,oneof=completed pending"`
+	SortBy string `query:"sortBy" json:"sortBy" validate:"omitempty,oneof=dueDate priority"`
+	// Query is written in the task query language of package taskquery,
+	// such as priority:High -done due<7d, and narrows the other fields.
+	Query string `query:"q" json:"q"`
+}
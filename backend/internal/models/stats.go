@@ -0,0 +1,53 @@
+package models
+
+// Priorities lists the valid values of Task.Priority, highest first.
+var Priorities = []string{"High", "Medium", "Low"}
+
+// PriorityCount counts the open tasks of one priority.
+type PriorityCount struct {
+	Priority string `json:"priority"`
+	Open     int64  `json:"open"`
+	// Overdue counts the open tasks whose due date has passed.
+	Overdue int64 `json:"overdue"`
+}
+
+// TaskStats summarizes the tasks matching a filter.
+type TaskStats struct {
+	Total int64 `json:"total"`
+	Done  int64 `json:"done"`
+	Open  int64 `json:"open"`
+	// Overdue counts the open tasks whose due date has passed.
+	Overdue int64 `json:"overdue"`
+	// DueThisWeek counts the open tasks due from now until the end of the
+	// week, Sunday midnight.
+	DueThisWeek int64 `json:"due_this_week"`
+	// AverageOpenAge is the mean age of the open tasks in seconds, 0 when
+	// there are none.
+	AverageOpenAge float64 `json:"average_open_age_seconds"`
+	// ByPriority has every priority, highest first, and ByAssignee every
+	// assignee with tasks, in alphabetical order.
+	ByPriority []GroupCount  `json:"by_priority"`
+	ByAssignee []GroupCount  `json:"by_assignee"`
+	Subtasks   SubtaskCounts `json:"subtasks"`
+}
+
+// GroupCount counts the tasks sharing a priority or an assignee.
+type GroupCount struct {
+	// Name is the priority or the assignee; "" collects unassigned tasks.
+	Name  string `json:"name"`
+	Total int64  `json:"total"`
+	Done  int64  `json:"done"`
+	Open  int64  `json:"open"`
+}
+
+// SubtaskCounts counts the subtasks of the tasks matching a filter.
+type SubtaskCounts struct {
+	Total int64 `json:"total"`
+	Done  int64 `json:"done"`
+	// CompletionRatio is Done / Total, 0 without subtasks.
+	CompletionRatio float64 `json:"completion_ratio"`
+	// TasksWithSubtasks counts the tasks that have subtasks, and
+	// AverageTaskCompletion is the mean of their own completion ratios.
+	TasksWithSubtasks     int64   `json:"tasks_with_subtasks"`
+	AverageTaskCompletion float64 `json:"average_task_completion"`
+}
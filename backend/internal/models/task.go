@@ -2,17 +2,61 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Task struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Title       string    `gorm:"not null" json:"title" validate:"required"`
-	Description string    `json:"description"`
-	Priority    string    `gorm:"type:text;default:'Medium'" json:"priority" validate:"oneof=Low Medium High"`
-	Assignee    string    `json:"assignee"`
-	DueDate     time.Time `json:"due_date"`
-	Done        bool      `json:"done"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Subtasks    []Subtask `json:"subtasks"`
-}
\ No newline at end of file
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Title       string `gorm:"not null" json:"title" validate:"required"`
+	Description string `json:"description"`
+	Priority    string `gorm:"size:16;default:'Medium'" json:"priority" validate:"oneof=Low Medium High"`
+	Assignee    string `json:"assignee"`
+	// DueDate is when the task is due, in UTC, or nil when it has no due
+	// date. A date-only deadline (DueAllDay) is due at the midnight that
+	// starts its day in DueTimezone.
+	DueDate   *time.Time `json:"due_date"`
+	DueAllDay bool       `json:"due_all_day"`
+	// DueTimezone is the IANA time zone the due date was set in, which
+	// decides the day of a date-only deadline. It defaults to UTC.
+	DueTimezone string `gorm:"size:64" json:"due_timezone" validate:"omitempty,timezone"`
+	// OverdueAt is when the task becomes overdue: DueDate, or the end of the
+	// day of a date-only deadline. It is derived from the fields above.
+	OverdueAt *time.Time `gorm:"index" json:"overdue_at"`
+	Done      bool       `json:"done"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Subtasks  []Subtask  `json:"subtasks"`
+}
+
+// NormalizeDue stores DueDate in UTC, moves a date-only deadline to the
+// start of the day it falls on in DueTimezone and derives OverdueAt. A zero
+// DueDate, which older clients send for tasks without a due date, is
+// cleared along with the other due fields.
+func (t *Task) NormalizeDue() {
+	if t.DueDate == nil || t.DueDate.IsZero() {
+		t.DueDate, t.DueAllDay, t.DueTimezone, t.OverdueAt = nil, false, "", nil
+		return
+	}
+	if t.DueTimezone == "" {
+		t.DueTimezone = "UTC"
+	}
+	loc, err := time.LoadLocation(t.DueTimezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	due, overdue := *t.DueDate, *t.DueDate
+	if t.DueAllDay {
+		y, m, d := due.In(loc).Date()
+		due = time.Date(y, m, d, 0, 0, 0, 0, loc)
+		overdue = due.AddDate(0, 0, 1)
+	}
+	due, overdue = due.UTC(), overdue.UTC()
+	t.DueDate, t.OverdueAt = &due, &overdue
+}
+
+// BeforeSave normalizes the due date of every task written through GORM.
+func (t *Task) BeforeSave(*gorm.DB) error {
+	t.NormalizeDue()
+	return nil
+}
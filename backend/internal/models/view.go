@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Visibilities of a SavedView.
+const (
+	VisibilityPrivate = "private"
+	VisibilityShared  = "shared"
+)
+
+// SavedView is a named task list definition that its owner can run again,
+// and share with everyone else.
+type SavedView struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"size:100;not null" json:"name" validate:"required,max=100"`
+	// Owner identifies the caller that created the view. It is not
+	// exposed; Owned tells callers whether the view is theirs.
+	Owner string `gorm:"size:100;not null;index" json:"-"`
+	// Visibility is private, the default, or shared: shared views can be
+	// read by everyone, but only changed by their owner.
+	Visibility string         `gorm:"size:16;not null;default:'private'" json:"visibility" validate:"oneof=private shared"`
+	Definition ViewDefinition `gorm:"type:text;serializer:json" json:"definition"`
+	Owned      bool           `gorm:"-" json:"owned"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// ViewDefinition is what a SavedView lists: the tasks matching Filter, in
+// its order, grouped by GroupBy.
+type ViewDefinition struct {
+	Filter  TaskFilter `json:"filter"`
+	GroupBy string     `json:"group_by" validate:"omitempty,oneof=priority assignee status"`
+}
+
+// ViewTasks are the tasks of a SavedView.
+type ViewTasks struct {
+	GroupBy string `json:"group_by"`
+	// Groups have at least one task each and keep the order of the view
+	// within. Priorities come highest first, assignees in alphabetical
+	// order and pending tasks before completed ones; a view without
+	// grouping has a single group named "".
+	Groups []TaskGroup `json:"groups"`
+}
+
+type TaskGroup struct {
+	Name  string `json:"name"`
+	Tasks []Task `json:"tasks"`
+}
@@ -0,0 +1,187 @@
+// Package ratelimit enforces request budgets per client with token
+// buckets. A Limiter picks the budget of each request from its method and
+// route; a Store keeps the buckets, in memory or shared between servers.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a budget of Requests per Period. Its bucket holds up to Requests
+// tokens and refills evenly over Period, so a client may burst Requests at
+// once and then keeps to the average rate.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit reads a budget such as 60/m, 5/s, 1000/h or 100/10s.
+func ParseLimit(s string) (Limit, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%q is not a budget like 60/m", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("%q is not a budget like 60/m: %q is not a positive number", s, count)
+	}
+	var d time.Duration
+	switch period {
+	case "s":
+		d = time.Second
+	case "m":
+		d = time.Minute
+	case "h":
+		d = time.Hour
+	default:
+		d, err = time.ParseDuration(period)
+		if err != nil || d <= 0 {
+			return Limit{}, fmt.Errorf("%q is not a budget like 60/m: %q is not a period like s, m, h or 10s", s, period)
+		}
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Period.String()
+}
+
+// interval is the time it takes to refill one token.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Requests)
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining is the number of whole tokens left after this request.
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token, when not Allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps one token bucket per key. Take must be atomic per key, so
+// that concurrent requests, possibly on several servers, cannot spend the
+// same token twice. Shared deployments implement it on top of Redis or a
+// database; MemoryStore serves a single server.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// Route overrides the budget of one route, given by method and route
+// template such as POST /tasks/:id/subtasks.
+type Route struct {
+	Method string
+	Path   string
+	Limit  Limit
+}
+
+// ParseRoute reads a route key such as "POST /tasks".
+func ParseRoute(key string) (method, path string, err error) {
+	method, path, ok := strings.Cut(strings.TrimSpace(key), " ")
+	path = strings.TrimSpace(path)
+	if !ok || method != strings.ToUpper(method) || !strings.HasPrefix(path, "/") {
+		return "", "", fmt.Errorf("%q is not a route like \"POST /tasks\"", key)
+	}
+	return method, path, nil
+}
+
+// Limiter decides which budget a request spends and takes from it.
+type Limiter struct {
+	store  Store
+	read   Limit
+	write  Limit
+	routes []route
+}
+
+type route struct {
+	Route
+	segments []string
+}
+
+// New returns a Limiter charging GET, HEAD and OPTIONS requests to read and
+// every other request to write, unless routes gives their route a budget
+// of its own. Each client has one bucket for reads, one for writes and one
+// per overridden route.
+func New(store Store, read, write Limit, routes ...Route) *Limiter {
+	l := &Limiter{store: store, read: read, write: write}
+	for _, r := range routes {
+		l.routes = append(l.routes, route{Route: r, segments: splitPath(r.Path)})
+	}
+	return l
+}
+
+// Take spends one token of client for a request to method and path.
+func (l *Limiter) Take(ctx context.Context, client, method, path string) (Result, error) {
+	bucket, limit := l.budget(method, path)
+	return l.store.Take(ctx, client+"|"+bucket, limit, time.Now())
+}
+
+// TakeShared spends one token of a budget that a group of clients has in
+// common, share times the budget of a single client, for a request to
+// method and path.
+func (l *Limiter) TakeShared(ctx context.Context, group string, share int, method, path string) (Result, error) {
+	bucket, limit := l.budget(method, path)
+	limit.Requests *= share
+	return l.store.Take(ctx, group+"|"+bucket, limit, time.Now())
+}
+
+// budget returns the bucket a request to method and path spends from.
+func (l *Limiter) budget(method, path string) (bucket string, limit Limit) {
+	bucket, limit = "write", l.write
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		bucket, limit = "read", l.read
+	}
+	for _, r := range l.routes {
+		if r.matches(method, path) {
+			return r.Method + " " + r.Path, r.Limit
+		}
+	}
+	return bucket, limit
+}
+
+// matches reports whether path fits the route template, where each :param
+// segment stands for one non-empty segment. Other segments ignore case, as
+// the router does, so that /Tasks cannot escape the budget of /tasks.
+func (r route) matches(method, path string) bool {
+	if method != r.Method {
+		return false
+	}
+	segments := splitPath(path)
+	if len(segments) != len(r.segments) {
+		return false
+	}
+	for i, s := range r.segments {
+		if strings.HasPrefix(s, ":") {
+			if segments[i] == "" {
+				return false
+			}
+		} else if !strings.EqualFold(s, segments[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func splitPath(path string) []string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return strings.Split(path, "/")
+}
+
+// Seconds rounds d up to whole seconds, as the RateLimit-Reset and
+// Retry-After headers expect.
+func Seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
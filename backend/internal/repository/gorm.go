@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todo/internal/database"
+	"todo/internal/models"
+	"todo/internal/taskquery"
+
+	"gorm.io/gorm"
+)
+
+var (
+	_ TaskRepository    = (*GormTaskRepository)(nil)
+	_ SubtaskRepository = (*GormSubtaskRepository)(nil)
+	_ ViewRepository    = (*GormViewRepository)(nil)
+)
+
+type GormTaskRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTaskRepository(db *gorm.DB) *GormTaskRepository {
+	return &GormTaskRepository{db: db}
+}
+
+func (r *GormTaskRepository) WithContext(ctx context.Context) TaskRepository {
+	return &GormTaskRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *GormTaskRepository) List(filter models.TaskFilter, withSubtasks bool) ([]models.Task, error) {
+	tx := r.db
+	if withSubtasks {
+		tx = tx.Preload("Subtasks", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") })
+	}
+	var tasks []models.Task
+	err := sortTasks(filterTasks(tx, filter), filter).Find(&tasks).Error
+	return tasks, err
+}
+
+func (r *GormTaskRepository) Count(filter models.TaskFilter) (total, done int64, err error) {
+	var counts struct {
+		Total int64
+		Done  int64
+	}
+	err = filterTasks(r.db.Model(&models.Task{}), filter).
+		Select("COUNT(*) AS total, COALESCE(SUM(CASE WHEN done THEN 1 ELSE 0 END), 0) AS done").
+		Scan(&counts).Error
+	return counts.Total, counts.Done, err
+}
+
+func (r *GormTaskRepository) OpenCounts(now time.Time) ([]models.PriorityCount, error) {
+	counts := []models.PriorityCount{}
+	err := r.db.Model(&models.Task{}).
+		Select("priority, COUNT(*) AS open, COALESCE(SUM(CASE WHEN overdue_at < ? THEN 1 ELSE 0 END), 0) AS overdue", now.UTC()).
+		Where("done = ?", false).
+		Group("priority").
+		Order("priority").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *GormTaskRepository) Stats(filter models.TaskFilter, now, weekEnd time.Time) (models.TaskStats, error) {
+	stats := models.TaskStats{ByPriority: []models.GroupCount{}, ByAssignee: []models.GroupCount{}}
+	var totals struct {
+		Total       int64
+		Done        int64
+		Overdue     int64
+		DueThisWeek int64
+		OpenSince   *float64
+	}
+	// Due dates are stored in UTC, and SQLite compares times as text.
+	err := filterTasks(r.db.Model(&models.Task{}), filter).
+		Select("COUNT(*) AS total, "+
+			"COALESCE(SUM(CASE WHEN done THEN 1 ELSE 0 END), 0) AS done, "+
+			"COALESCE(SUM(CASE WHEN NOT done AND overdue_at < ? THEN 1 ELSE 0 END), 0) AS overdue, "+
+			"COALESCE(SUM(CASE WHEN NOT done AND overdue_at >= ? AND due_date < ? THEN 1 ELSE 0 END), 0) AS due_this_week, "+
+			"AVG(CASE WHEN done THEN NULL ELSE "+database.EpochSeconds(r.db, "created_at")+" END) AS open_since",
+			now.UTC(), now.UTC(), weekEnd.UTC()).
+		Scan(&totals).Error
+	if err != nil {
+		return stats, err
+	}
+	stats.Total, stats.Done, stats.Overdue, stats.DueThisWeek = totals.Total, totals.Done, totals.Overdue, totals.DueThisWeek
+	if totals.OpenSince != nil {
+		stats.AverageOpenAge = float64(now.Unix()) - *totals.OpenSince
+	}
+
+	for column, groups := range map[string]*[]models.GroupCount{"priority": &stats.ByPriority, "assignee": &stats.ByAssignee} {
+		err := filterTasks(r.db.Model(&models.Task{}), filter).
+			Select(column + " AS name, COUNT(*) AS total, COALESCE(SUM(CASE WHEN done THEN 1 ELSE 0 END), 0) AS done").
+			Group(column).
+			Order(column).
+			Scan(groups).Error
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	// Per task first, for the mean of their completion ratios.
+	perTask := r.db.Model(&models.Subtask{}).
+		Select("task_id, COUNT(*) AS total, SUM(CASE WHEN done THEN 1 ELSE 0 END) AS done, AVG(CASE WHEN done THEN 1.0 ELSE 0.0 END) AS ratio").
+		Where("task_id IN (?)", filterTasks(r.db.Model(&models.Task{}), filter).Select("id")).
+		Group("task_id")
+	err = r.db.Table("(?) AS per_task", perTask).
+		Select("COUNT(*) AS tasks_with_subtasks, COALESCE(SUM(total), 0) AS total, COALESCE(SUM(done), 0) AS done, COALESCE(AVG(ratio), 0) AS average_task_completion").
+		Scan(&stats.Subtasks).Error
+	return stats, err
+}
+
+func (r *GormTaskRepository) Get(id uint, withSubtasks bool) (models.Task, error) {
+	tx := r.db
+	if withSubtasks {
+		tx = tx.Preload("Subtasks", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") })
+	}
+	var task models.Task
+	err := tx.First(&task, id).Error
+	return task, notFound(err)
+}
+
+func (r *GormTaskRepository) Create(task *models.Task) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
+		changes := []models.TaskChange{change(task, models.ChangeCreated, 1, task.CreatedAt)}
+		if task.Done {
+			changes = append(changes, change(task, models.ChangeCompleted, -1, task.CreatedAt))
+		}
+		return tx.Create(&changes).Error
+	})
+}
+
+func (r *GormTaskRepository) Save(task *models.Task) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var wasDone []bool
+		if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).Pluck("done", &wasDone).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Subtasks").Save(task).Error; err != nil {
+			return err
+		}
+		if len(wasDone) == 0 || wasDone[0] == task.Done {
+			return nil
+		}
+		c := change(task, models.ChangeReopened, 1, task.UpdatedAt)
+		if task.Done {
+			c = change(task, models.ChangeCompleted, -1, task.UpdatedAt)
+		}
+		return tx.Create(&c).Error
+	})
+}
+
+func (r *GormTaskRepository) Delete(task *models.Task) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var wasDone []bool
+		if err := tx.Model(&models.Task{}).Where("id = ?", task.ID).Pluck("done", &wasDone).Error; err != nil {
+			return err
+		}
+		res := tx.Delete(task)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		c := change(task, models.ChangeDeleted, -1, time.Now())
+		if len(wasDone) > 0 && wasDone[0] {
+			c.OpenDelta = 0
+		}
+		return tx.Create(&c).Error
+	})
+}
+
+func (r *GormTaskRepository) ListChanges(filter models.TaskChangeFilter) ([]models.TaskChange, error) {
+	tx := r.db
+	if !filter.From.IsZero() {
+		tx = tx.Where("occurred_at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		tx = tx.Where("occurred_at < ?", filter.To.UTC())
+	}
+	if len(filter.Kinds) > 0 {
+		tx = tx.Where("kind IN ?", filter.Kinds)
+	}
+	if len(filter.TaskIDs) > 0 {
+		tx = tx.Where("task_id IN ?", filter.TaskIDs)
+	}
+	changes := []models.TaskChange{}
+	err := tx.Order("occurred_at, id").Find(&changes).Error
+	return changes, err
+}
+
+func (r *GormTaskRepository) OpenAt(at time.Time) (int64, error) {
+	var open int64
+	err := r.db.Model(&models.TaskChange{}).
+		Select("COALESCE(SUM(open_delta), 0)").
+		Where("occurred_at < ?", at.UTC()).
+		Scan(&open).Error
+	return open, err
+}
+
+// change is recorded in UTC, like the times it is compared with, as SQLite
+// compares times as text.
+func change(task *models.Task, kind string, openDelta int, at time.Time) models.TaskChange {
+	return models.TaskChange{TaskID: task.ID, Kind: kind, Assignee: task.Assignee, OpenDelta: openDelta, OccurredAt: at.UTC()}
+}
+
+func filterTasks(tx *gorm.DB, filter models.TaskFilter) *gorm.DB {
+	if filter.Assignee != "" {
+		tx = tx.Where("assignee = ?", filter.Assignee)
+	}
+	if filter.Search != "" {
+		tx = database.SearchTasks(tx, filter.Search)
+	}
+	switch filter.Status {
+	case "completed":
+		tx = tx.Where("done = ?", true)
+	case "pending":
+		tx = tx.Where("done = ?", false)
+	}
+	if filter.Query != "" {
+		expr, err := taskquery.Parse(filter.Query)
+		if err != nil {
+			// A new session, so that the error does not stick to r.db.
+			tx = tx.Session(&gorm.Session{})
+			tx.AddError(err)
+			return tx
+		}
+		if expr != nil {
+			sql, args := querySQL(tx, expr, time.Now())
+			tx = tx.Where(sql, args...)
+		}
+	}
+	return tx
+}
+
+func sortTasks(tx *gorm.DB, filter models.TaskFilter) *gorm.DB {
+	switch filter.SortBy {
+	case "dueDate":
+		// Tasks without a due date go last, as NULLs sort first on some
+		// databases and last on others.
+		tx = tx.Order("CASE WHEN due_date IS NULL THEN 1 ELSE 0 END, due_date")
+	case "priority":
+		tx = tx.Order("CASE priority WHEN 'High' THEN 0 WHEN 'Medium' THEN 1 WHEN 'Low' THEN 2 ELSE 3 END")
+	}
+	return tx.Order("id")
+}
+
+type GormSubtaskRepository struct {
+	db *gorm.DB
+}
+
+func NewGormSubtaskRepository(db *gorm.DB) *GormSubtaskRepository {
+	return &GormSubtaskRepository{db: db}
+}
+
+func (r *GormSubtaskRepository) WithContext(ctx context.Context) SubtaskRepository {
+	return &GormSubtaskRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *GormSubtaskRepository) ListByTasks(taskIDs ...uint) ([]models.Subtask, error) {
+	subtasks := []models.Subtask{}
+	if len(taskIDs) == 0 {
+		return subtasks, nil
+	}
+	err := r.db.Where("task_id IN ?", taskIDs).Order("id").Find(&subtasks).Error
+	return subtasks, err
+}
+
+func (r *GormSubtaskRepository) Get(id uint) (models.Subtask, error) {
+	var subtask models.Subtask
+	err := r.db.First(&subtask, id).Error
+	return subtask, notFound(err)
+}
+
+func (r *GormSubtaskRepository) Create(subtask *models.Subtask) error {
+	return r.db.Create(subtask).Error
+}
+
+func (r *GormSubtaskRepository) Save(subtask *models.Subtask) error {
+	return r.db.Save(subtask).Error
+}
+
+func (r *GormSubtaskRepository) Delete(subtask *models.Subtask) error {
+	return r.db.Delete(subtask).Error
+}
+
+type GormViewRepository struct {
+	db *gorm.DB
+}
+
+func NewGormViewRepository(db *gorm.DB) *GormViewRepository {
+	return &GormViewRepository{db: db}
+}
+
+func (r *GormViewRepository) WithContext(ctx context.Context) ViewRepository {
+	return &GormViewRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *GormViewRepository) List(owner string) ([]models.SavedView, error) {
+	views := []models.SavedView{}
+	err := r.db.Where("owner = ? OR visibility = ?", owner, models.VisibilityShared).Order("name, id").Find(&views).Error
+	return views, err
+}
+
+func (r *GormViewRepository) Get(id uint) (models.SavedView, error) {
+	var view models.SavedView
+	err := r.db.First(&view, id).Error
+	return view, notFound(err)
+}
+
+func (r *GormViewRepository) Create(view *models.SavedView) error {
+	return r.db.Create(view).Error
+}
+
+func (r *GormViewRepository) Save(view *models.SavedView) error {
+	return r.db.Save(view).Error
+}
+
+func (r *GormViewRepository) Delete(view *models.SavedView) error {
+	return r.db.Delete(view).Error
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,560 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"todo/internal/models"
+	"todo/internal/taskquery"
+)
+
+var (
+	_ TaskRepository    = (*MemoryTaskRepository)(nil)
+	_ SubtaskRepository = (*MemorySubtaskRepository)(nil)
+	_ ViewRepository    = (*MemoryViewRepository)(nil)
+)
+
+// MemoryStore keeps tasks, subtasks and saved views in memory. Its repositories behave
+// like the GORM ones, except that search is a case-insensitive substring
+// match. The zero value is not usable; call NewMemoryStore.
+type MemoryStore struct {
+	mu            sync.RWMutex
+	tasks         map[uint]models.Task
+	subtasks      map[uint]models.Subtask
+	changes       []models.TaskChange
+	views         map[uint]models.SavedView
+	nextTaskID    uint
+	nextSubtaskID uint
+	nextViewID    uint
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tasks:    make(map[uint]models.Task),
+		subtasks: make(map[uint]models.Subtask),
+		views:    make(map[uint]models.SavedView),
+	}
+}
+
+// Tasks returns a TaskRepository backed by the store.
+func (s *MemoryStore) Tasks() *MemoryTaskRepository {
+	return &MemoryTaskRepository{store: s}
+}
+
+// Subtasks returns a SubtaskRepository backed by the store.
+func (s *MemoryStore) Subtasks() *MemorySubtaskRepository {
+	return &MemorySubtaskRepository{store: s}
+}
+
+// Views returns a ViewRepository backed by the store.
+func (s *MemoryStore) Views() *MemoryViewRepository {
+	return &MemoryViewRepository{store: s}
+}
+
+type MemoryTaskRepository struct {
+	store *MemoryStore
+}
+
+// WithContext returns r; the memory store has nothing to cancel or trace.
+func (r *MemoryTaskRepository) WithContext(context.Context) TaskRepository {
+	return r
+}
+
+func (r *MemoryTaskRepository) List(filter models.TaskFilter, withSubtasks bool) ([]models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	match, err := matcher(filter)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []models.Task{}
+	for _, task := range s.tasks {
+		if match(task) {
+			if withSubtasks {
+				task.Subtasks = s.subtasksOf(task.ID)
+			}
+			tasks = append(tasks, task)
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return less(tasks[i], tasks[j], filter.SortBy) })
+	return tasks, nil
+}
+
+func (r *MemoryTaskRepository) Count(filter models.TaskFilter) (total, done int64, err error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	match, err := matcher(filter)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, task := range s.tasks {
+		if match(task) {
+			total++
+			if task.Done {
+				done++
+			}
+		}
+	}
+	return total, done, nil
+}
+
+func (r *MemoryTaskRepository) OpenCounts(now time.Time) ([]models.PriorityCount, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	byPriority := make(map[string]*models.PriorityCount)
+	for _, task := range s.tasks {
+		if task.Done {
+			continue
+		}
+		count, ok := byPriority[task.Priority]
+		if !ok {
+			count = &models.PriorityCount{Priority: task.Priority}
+			byPriority[task.Priority] = count
+		}
+		count.Open++
+		if task.OverdueAt != nil && task.OverdueAt.Before(now) {
+			count.Overdue++
+		}
+	}
+	counts := make([]models.PriorityCount, 0, len(byPriority))
+	for _, count := range byPriority {
+		counts = append(counts, *count)
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Priority < counts[j].Priority })
+	return counts, nil
+}
+
+func (r *MemoryTaskRepository) Stats(filter models.TaskFilter, now, weekEnd time.Time) (models.TaskStats, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := models.TaskStats{ByPriority: []models.GroupCount{}, ByAssignee: []models.GroupCount{}}
+	byPriority := make(map[string]*models.GroupCount)
+	byAssignee := make(map[string]*models.GroupCount)
+	matched := make(map[uint]bool)
+	var openAge time.Duration
+	match, err := matcher(filter)
+	if err != nil {
+		return stats, err
+	}
+	for _, task := range s.tasks {
+		if !match(task) {
+			continue
+		}
+		matched[task.ID] = true
+		stats.Total++
+		for _, g := range []struct {
+			groups map[string]*models.GroupCount
+			name   string
+		}{{byPriority, task.Priority}, {byAssignee, task.Assignee}} {
+			count, ok := g.groups[g.name]
+			if !ok {
+				count = &models.GroupCount{Name: g.name}
+				g.groups[g.name] = count
+			}
+			count.Total++
+			if task.Done {
+				count.Done++
+			}
+		}
+		if task.Done {
+			stats.Done++
+			continue
+		}
+		openAge += now.Sub(task.CreatedAt)
+		switch {
+		case task.DueDate == nil:
+		case task.OverdueAt.Before(now):
+			stats.Overdue++
+		case task.DueDate.Before(weekEnd):
+			stats.DueThisWeek++
+		}
+	}
+	if open := stats.Total - stats.Done; open > 0 {
+		stats.AverageOpenAge = openAge.Seconds() / float64(open)
+	}
+	stats.ByPriority = sortedGroups(byPriority)
+	stats.ByAssignee = sortedGroups(byAssignee)
+
+	perTask := make(map[uint]*models.SubtaskCounts)
+	for _, st := range s.subtasks {
+		if !matched[st.TaskID] {
+			continue
+		}
+		count, ok := perTask[st.TaskID]
+		if !ok {
+			count = &models.SubtaskCounts{}
+			perTask[st.TaskID] = count
+		}
+		count.Total++
+		if st.Done {
+			count.Done++
+		}
+	}
+	for _, count := range perTask {
+		stats.Subtasks.Total += count.Total
+		stats.Subtasks.Done += count.Done
+		stats.Subtasks.AverageTaskCompletion += float64(count.Done) / float64(count.Total)
+	}
+	if n := len(perTask); n > 0 {
+		stats.Subtasks.TasksWithSubtasks = int64(n)
+		stats.Subtasks.AverageTaskCompletion /= float64(n)
+	}
+	return stats, nil
+}
+
+func sortedGroups(groups map[string]*models.GroupCount) []models.GroupCount {
+	sorted := make([]models.GroupCount, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, *g)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+func (r *MemoryTaskRepository) Get(id uint, withSubtasks bool) (models.Task, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	task, ok := s.tasks[id]
+	if !ok {
+		return models.Task{}, ErrNotFound
+	}
+	if withSubtasks {
+		task.Subtasks = s.subtasksOf(id)
+	}
+	return task, nil
+}
+
+func (r *MemoryTaskRepository) Create(task *models.Task) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.nextTaskID++
+	task.ID = s.nextTaskID
+	task.CreatedAt, task.UpdatedAt = now, now
+	task.NormalizeDue()
+	if task.Priority == "" {
+		task.Priority = "Medium"
+	}
+	for i := range task.Subtasks {
+		st := &task.Subtasks[i]
+		s.nextSubtaskID++
+		st.ID, st.TaskID = s.nextSubtaskID, task.ID
+		st.CreatedAt, st.UpdatedAt = now, now
+		s.subtasks[st.ID] = *st
+	}
+	stored := *task
+	stored.Subtasks = nil
+	s.tasks[task.ID] = stored
+	s.record(task, models.ChangeCreated, 1, now)
+	if task.Done {
+		s.record(task, models.ChangeCompleted, -1, now)
+	}
+	return nil
+}
+
+func (r *MemoryTaskRepository) Save(task *models.Task) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.tasks[task.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	task.CreatedAt = old.CreatedAt
+	task.UpdatedAt = time.Now()
+	task.NormalizeDue()
+	stored := *task
+	stored.Subtasks = nil
+	s.tasks[task.ID] = stored
+	switch {
+	case task.Done && !old.Done:
+		s.record(task, models.ChangeCompleted, -1, task.UpdatedAt)
+	case !task.Done && old.Done:
+		s.record(task, models.ChangeReopened, 1, task.UpdatedAt)
+	}
+	return nil
+}
+
+func (r *MemoryTaskRepository) Delete(task *models.Task) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.tasks[task.ID]
+	if !ok {
+		return nil
+	}
+	delete(s.tasks, task.ID)
+	delta := -1
+	if old.Done {
+		delta = 0
+	}
+	s.record(task, models.ChangeDeleted, delta, time.Now())
+	return nil
+}
+
+func (r *MemoryTaskRepository) ListChanges(filter models.TaskChangeFilter) ([]models.TaskChange, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	kinds := make(map[string]bool, len(filter.Kinds))
+	for _, k := range filter.Kinds {
+		kinds[k] = true
+	}
+	taskIDs := make(map[uint]bool, len(filter.TaskIDs))
+	for _, id := range filter.TaskIDs {
+		taskIDs[id] = true
+	}
+	changes := []models.TaskChange{}
+	for _, c := range s.changes {
+		switch {
+		case !filter.From.IsZero() && c.OccurredAt.Before(filter.From),
+			!filter.To.IsZero() && !c.OccurredAt.Before(filter.To),
+			len(kinds) > 0 && !kinds[c.Kind],
+			len(taskIDs) > 0 && !taskIDs[c.TaskID]:
+			continue
+		}
+		changes = append(changes, c)
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].OccurredAt.Before(changes[j].OccurredAt) })
+	return changes, nil
+}
+
+func (r *MemoryTaskRepository) OpenAt(at time.Time) (int64, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var open int64
+	for _, c := range s.changes {
+		if c.OccurredAt.Before(at) {
+			open += int64(c.OpenDelta)
+		}
+	}
+	return open, nil
+}
+
+// record must be called with s.mu held for writing.
+func (s *MemoryStore) record(task *models.Task, kind string, openDelta int, at time.Time) {
+	s.changes = append(s.changes, models.TaskChange{
+		ID:         uint(len(s.changes) + 1),
+		TaskID:     task.ID,
+		Kind:       kind,
+		Assignee:   task.Assignee,
+		OpenDelta:  openDelta,
+		OccurredAt: at,
+	})
+}
+
+// subtasksOf must be called with s.mu held.
+func (s *MemoryStore) subtasksOf(taskIDs ...uint) []models.Subtask {
+	wanted := make(map[uint]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		wanted[id] = true
+	}
+	subtasks := []models.Subtask{}
+	for _, st := range s.subtasks {
+		if wanted[st.TaskID] {
+			subtasks = append(subtasks, st)
+		}
+	}
+	sort.Slice(subtasks, func(i, j int) bool { return subtasks[i].ID < subtasks[j].ID })
+	return subtasks
+}
+
+// matcher returns matches for filter, with its query parsed once.
+func matcher(filter models.TaskFilter) (func(models.Task) bool, error) {
+	expr, err := taskquery.Parse(filter.Query)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return func(task models.Task) bool {
+		return matches(task, filter) && (expr == nil || matchQuery(task, expr, now))
+	}, nil
+}
+
+func matches(task models.Task, filter models.TaskFilter) bool {
+	if filter.Assignee != "" && task.Assignee != filter.Assignee {
+		return false
+	}
+	if filter.Search != "" {
+		term := strings.ToLower(filter.Search)
+		if !strings.Contains(strings.ToLower(task.Title), term) && !strings.Contains(strings.ToLower(task.Description), term) {
+			return false
+		}
+	}
+	switch filter.Status {
+	case "completed":
+		return task.Done
+	case "pending":
+		return !task.Done
+	}
+	return true
+}
+
+var priorityRank = map[string]int{"High": 0, "Medium": 1, "Low": 2}
+
+func less(a, b models.Task, sortBy string) bool {
+	switch sortBy {
+	case "dueDate":
+		// Tasks without a due date go last, as in the GORM repository.
+		if (a.DueDate == nil) != (b.DueDate == nil) {
+			return b.DueDate == nil
+		}
+		if a.DueDate != nil && !a.DueDate.Equal(*b.DueDate) {
+			return a.DueDate.Before(*b.DueDate)
+		}
+	case "priority":
+		ra, oka := priorityRank[a.Priority]
+		rb, okb := priorityRank[b.Priority]
+		if !oka {
+			ra = 3
+		}
+		if !okb {
+			rb = 3
+		}
+		if ra != rb {
+			return ra < rb
+		}
+	}
+	return a.ID < b.ID
+}
+
+type MemorySubtaskRepository struct {
+	store *MemoryStore
+}
+
+func (r *MemorySubtaskRepository) WithContext(context.Context) SubtaskRepository {
+	return r
+}
+
+func (r *MemorySubtaskRepository) ListByTasks(taskIDs ...uint) ([]models.Subtask, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.subtasksOf(taskIDs...), nil
+}
+
+func (r *MemorySubtaskRepository) Get(id uint) (models.Subtask, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	subtask, ok := s.subtasks[id]
+	if !ok {
+		return models.Subtask{}, ErrNotFound
+	}
+	return subtask, nil
+}
+
+func (r *MemorySubtaskRepository) Create(subtask *models.Subtask) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.nextSubtaskID++
+	subtask.ID = s.nextSubtaskID
+	subtask.CreatedAt, subtask.UpdatedAt = now, now
+	s.subtasks[subtask.ID] = *subtask
+	return nil
+}
+
+func (r *MemorySubtaskRepository) Save(subtask *models.Subtask) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.subtasks[subtask.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	subtask.CreatedAt = old.CreatedAt
+	subtask.UpdatedAt = time.Now()
+	s.subtasks[subtask.ID] = *subtask
+	return nil
+}
+
+func (r *MemorySubtaskRepository) Delete(subtask *models.Subtask) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subtasks, subtask.ID)
+	return nil
+}
+
+type MemoryViewRepository struct {
+	store *MemoryStore
+}
+
+func (r *MemoryViewRepository) WithContext(context.Context) ViewRepository {
+	return r
+}
+
+func (r *MemoryViewRepository) List(owner string) ([]models.SavedView, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	views := []models.SavedView{}
+	for _, view := range s.views {
+		if view.Owner == owner || view.Visibility == models.VisibilityShared {
+			views = append(views, view)
+		}
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].Name != views[j].Name {
+			return views[i].Name < views[j].Name
+		}
+		return views[i].ID < views[j].ID
+	})
+	return views, nil
+}
+
+func (r *MemoryViewRepository) Get(id uint) (models.SavedView, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	view, ok := s.views[id]
+	if !ok {
+		return models.SavedView{}, ErrNotFound
+	}
+	return view, nil
+}
+
+func (r *MemoryViewRepository) Create(view *models.SavedView) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.nextViewID++
+	view.ID = s.nextViewID
+	view.CreatedAt, view.UpdatedAt = now, now
+	s.views[view.ID] = *view
+	return nil
+}
+
+func (r *MemoryViewRepository) Save(view *models.SavedView) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.views[view.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	view.CreatedAt = old.CreatedAt
+	view.UpdatedAt = time.Now()
+	s.views[view.ID] = *view
+	return nil
+}
+
+func (r *MemoryViewRepository) Delete(view *models.SavedView) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.views, view.ID)
+	return nil
+}
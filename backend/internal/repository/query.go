@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"strings"
+	"time"
+	"todo/internal/database"
+	"todo/internal/models"
+	"todo/internal/taskquery"
+
+	"gorm.io/gorm"
+)
+
+// The task query language is translated twice: into SQL for the GORM
+// repository and into a predicate for the memory store. Both must agree,
+// including on tasks without a due date, which never match a comparison,
+// even negated, and text matches, which ignore case.
+
+// timeColumns maps the time fields of the query language to their columns.
+var timeColumns = map[string]string{"due": "due_date", "created": "created_at", "updated": "updated_at"}
+
+// likeEscape escapes the wildcards of a LIKE pattern. It is not a
+// backslash, which MySQL would need doubled in the ESCAPE clause.
+var likeEscape = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// containsPattern matches text anywhere in a column that LOWER was applied
+// to.
+func containsPattern(text string) string {
+	return "%" + likeEscape.Replace(strings.ToLower(text)) + "%"
+}
+
+// querySQL translates expr into a WHERE condition on the database of tx
+// whose values are all bound as parameters. Column names only come from
+// this file.
+func querySQL(tx *gorm.DB, expr taskquery.Expr, now time.Time) (string, []interface{}) {
+	switch e := expr.(type) {
+	case *taskquery.And:
+		return joinSQL(tx, e.Terms, " AND ", now)
+	case *taskquery.Or:
+		return joinSQL(tx, e.Terms, " OR ", now)
+	case *taskquery.Not:
+		sql, args := querySQL(tx, e.Term, now)
+		return "NOT (" + sql + ")", args
+	case *taskquery.Text:
+		pattern := containsPattern(e.Value)
+		return "(LOWER(title) LIKE ? ESCAPE '!' OR LOWER(COALESCE(description, '')) LIKE ? ESCAPE '!')", []interface{}{pattern, pattern}
+	case *taskquery.Flag:
+		switch e.Name {
+		case taskquery.FlagDone:
+			return "done = ?", []interface{}{true}
+		case taskquery.FlagPending:
+			return "done = ?", []interface{}{false}
+		}
+		// Due dates are stored in UTC, and SQLite compares times as text.
+		return "(done = ? AND overdue_at IS NOT NULL AND overdue_at < ?)", []interface{}{false, now.UTC()}
+	case *taskquery.Match:
+		switch e.Field {
+		case "priority", "assignee":
+			return e.Field + " IN ?", []interface{}{e.Values}
+		case "title":
+			return "LOWER(title) LIKE ? ESCAPE '!'", []interface{}{containsPattern(e.Values[0])}
+		case "status":
+			var terms []string
+			var args []interface{}
+			for _, v := range e.Values {
+				terms = append(terms, "done = ?")
+				args = append(args, v == "completed")
+			}
+			return "(" + strings.Join(terms, " OR ") + ")", args
+		case "has":
+			var terms []string
+			for _, v := range e.Values {
+				switch v {
+				case "due":
+					terms = append(terms, "due_date IS NOT NULL")
+				default:
+					terms = append(terms, "COALESCE("+v+", '') <> ''")
+				}
+			}
+			return "(" + strings.Join(terms, " OR ") + ")", nil
+		}
+	case *taskquery.Compare:
+		column := timeColumns[e.Field]
+		sql := column + " IS NOT NULL"
+		var args []interface{}
+		from, to := e.Interval(now)
+		// Due dates are stored in UTC and compared directly, using their
+		// index. SQLite stores creation and update times as text with the
+		// UTC offset of the server that wrote them, which would not compare
+		// in order, so those are compared in Unix seconds everywhere.
+		value, bind := column, func(t time.Time) interface{} { return t.UTC() }
+		if e.Field != "due" {
+			value, bind = database.EpochSeconds(tx, column), func(t time.Time) interface{} { return t.Unix() }
+		}
+		if !from.IsZero() {
+			sql += " AND " + value + " >= ?"
+			args = append(args, bind(from))
+		}
+		if !to.IsZero() {
+			sql += " AND " + value + " < ?"
+			args = append(args, bind(to))
+		}
+		return "(" + sql + ")", args
+	}
+	return "1 = 1", nil
+}
+
+func joinSQL(tx *gorm.DB, terms []taskquery.Expr, op string, now time.Time) (string, []interface{}) {
+	sqls := make([]string, 0, len(terms))
+	var args []interface{}
+	for _, term := range terms {
+		sql, termArgs := querySQL(tx, term, now)
+		sqls = append(sqls, sql)
+		args = append(args, termArgs...)
+	}
+	return "(" + strings.Join(sqls, op) + ")", args
+}
+
+// matchQuery reports whether task matches expr, as querySQL would.
+func matchQuery(task models.Task, expr taskquery.Expr, now time.Time) bool {
+	switch e := expr.(type) {
+	case *taskquery.And:
+		for _, term := range e.Terms {
+			if !matchQuery(task, term, now) {
+				return false
+			}
+		}
+		return true
+	case *taskquery.Or:
+		for _, term := range e.Terms {
+			if matchQuery(task, term, now) {
+				return true
+			}
+		}
+		return false
+	case *taskquery.Not:
+		return !matchQuery(task, e.Term, now)
+	case *taskquery.Text:
+		text := strings.ToLower(e.Value)
+		return strings.Contains(strings.ToLower(task.Title), text) || strings.Contains(strings.ToLower(task.Description), text)
+	case *taskquery.Flag:
+		switch e.Name {
+		case taskquery.FlagDone:
+			return task.Done
+		case taskquery.FlagPending:
+			return !task.Done
+		}
+		return !task.Done && task.OverdueAt != nil && task.OverdueAt.Before(now)
+	case *taskquery.Match:
+		for _, v := range e.Values {
+			if matchField(task, e.Field, v) {
+				return true
+			}
+		}
+		return false
+	case *taskquery.Compare:
+		var t *time.Time
+		switch e.Field {
+		case "due":
+			t = task.DueDate
+		case "created":
+			t = &task.CreatedAt
+		case "updated":
+			t = &task.UpdatedAt
+		}
+		from, to := e.Interval(now)
+		return t != nil && (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+	}
+	return true
+}
+
+func matchField(task models.Task, field, value string) bool {
+	switch field {
+	case "priority":
+		return task.Priority == value
+	case "assignee":
+		return task.Assignee == value
+	case "title":
+		return strings.Contains(strings.ToLower(task.Title), strings.ToLower(value))
+	case "status":
+		return task.Done == (value == "completed")
+	case "has":
+		switch value {
+		case "due":
+			return task.DueDate != nil
+		case "assignee":
+			return task.Assignee != ""
+		case "description":
+			return task.Description != ""
+		}
+	}
+	return false
+}
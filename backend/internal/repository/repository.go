@@ -0,0 +1,69 @@
+// Package repository stores tasks, subtasks and saved views. The services
+// depend on the TaskRepository, SubtaskRepository and ViewRepository
+// interfaces; Gorm* implements them on a database and Memory* in memory,
+// for tests and tools that need no database.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"todo/internal/models"
+)
+
+// ErrNotFound is returned when no row has the requested ID.
+var ErrNotFound = errors.New("record not found")
+
+type TaskRepository interface {
+	// WithContext returns a repository running its queries under ctx, for
+	// cancellation and tracing.
+	WithContext(ctx context.Context) TaskRepository
+	// List returns the tasks matching filter in the order it asks for,
+	// ordered by ID otherwise. The filter is assumed to be valid.
+	List(filter models.TaskFilter, withSubtasks bool) ([]models.Task, error)
+	// Count counts the tasks matching filter, and how many of them are done.
+	Count(filter models.TaskFilter) (total, done int64, err error)
+	// OpenCounts counts the tasks that are not done per priority, and how
+	// many of them were due before now. Priorities without open tasks are
+	// left out.
+	OpenCounts(now time.Time) ([]models.PriorityCount, error)
+	// Stats aggregates the tasks matching filter and their subtasks, taking
+	// tasks due from now until weekEnd as due this week. It fills in every
+	// count but the open ones and the ratios derived from others, and lists
+	// only the priorities and assignees that have tasks.
+	Stats(filter models.TaskFilter, now, weekEnd time.Time) (models.TaskStats, error)
+	Get(id uint, withSubtasks bool) (models.Task, error)
+	// Create inserts task and its subtasks, filling in their IDs. Create,
+	// Save and Delete record the models.TaskChange they make along with it.
+	Create(task *models.Task) error
+	// Save updates every field of an existing task but not its subtasks.
+	Save(task *models.Task) error
+	Delete(task *models.Task) error
+	// ListChanges returns the recorded changes matching filter, oldest
+	// first.
+	ListChanges(filter models.TaskChangeFilter) ([]models.TaskChange, error)
+	// OpenAt counts the tasks that were open just before at, according to
+	// the recorded changes.
+	OpenAt(at time.Time) (int64, error)
+}
+
+type SubtaskRepository interface {
+	WithContext(ctx context.Context) SubtaskRepository
+	// ListByTasks returns the subtasks of the given tasks ordered by ID.
+	ListByTasks(taskIDs ...uint) ([]models.Subtask, error)
+	Get(id uint) (models.Subtask, error)
+	Create(subtask *models.Subtask) error
+	Save(subtask *models.Subtask) error
+	Delete(subtask *models.Subtask) error
+}
+
+type ViewRepository interface {
+	WithContext(ctx context.Context) ViewRepository
+	// List returns the views of owner and the shared views of everyone
+	// else, ordered by name and ID.
+	List(owner string) ([]models.SavedView, error)
+	Get(id uint) (models.SavedView, error)
+	Create(view *models.SavedView) error
+	Save(view *models.SavedView) error
+	Delete(view *models.SavedView) error
+}
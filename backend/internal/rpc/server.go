@@ -0,0 +1,287 @@
+// Package rpc serves the gRPC API defined in proto/todo/v1. It goes through
+// the services package, like the REST handlers, so both APIs apply the same
+// validation and report the same errors.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"todo/internal/models"
+	"todo/internal/rpc/todov1"
+	"todo/internal/services"
+	"todo/internal/taskquery"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type server struct {
+	todov1.UnimplementedTodoServiceServer
+	svc *services.Service
+}
+
+// NewServer returns a gRPC server with TodoService, backed by svc, and
+// server reflection registered.
+func NewServer(svc *services.Service, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	todov1.RegisterTodoServiceServer(s, &server{svc: svc})
+	reflection.Register(s)
+	return s
+}
+
+func (s *server) ListTasks(ctx context.Context, req *todov1.ListTasksRequest) (*todov1.ListTasksResponse, error) {
+	tasks, err := s.svc.WithContext(ctx).ListTasks(models.TaskFilter{
+		Assignee: req.GetAssignee(),
+		Search:   req.GetSearch(),
+		Status:   req.GetStatus(),
+		SortBy:   req.GetSortBy(),
+		Query:    req.GetQ(),
+	})
+	if err != nil {
+		return nil, statusError(err, "Could not retrieve tasks")
+	}
+	resp := &todov1.ListTasksResponse{Tasks: make([]*todov1.Task, 0, len(tasks))}
+	for i := range tasks {
+		resp.Tasks = append(resp.Tasks, taskToProto(&tasks[i]))
+	}
+	return resp, nil
+}
+
+func (s *server) GetTask(ctx context.Context, req *todov1.GetTaskRequest) (*todov1.Task, error) {
+	task, err := s.svc.WithContext(ctx).GetTask(uint(req.GetId()))
+	if err != nil {
+		return nil, statusError(err, "Could not retrieve task")
+	}
+	return taskToProto(&task), nil
+}
+
+func (s *server) CreateTask(ctx context.Context, req *todov1.CreateTaskRequest) (*todov1.Task, error) {
+	task := taskFromProto(req.GetTask())
+	if err := s.svc.WithContext(ctx).CreateTask(&task); err != nil {
+		return nil, statusError(err, "Could not create task")
+	}
+	return taskToProto(&task), nil
+}
+
+func (s *server) UpdateTask(ctx context.Context, req *todov1.UpdateTaskRequest) (*todov1.Task, error) {
+	task, err := s.svc.WithContext(ctx).FindTask(uint(req.GetId()))
+	if err != nil {
+		return nil, statusError(err, "Could not retrieve task")
+	}
+	if err := s.svc.WithContext(ctx).UpdateTask(&task, taskFromProto(req.GetTask())); err != nil {
+		return nil, statusError(err, "Could not update task")
+	}
+	return taskToProto(&task), nil
+}
+
+func (s *server) SetTaskDone(ctx context.Context, req *todov1.SetTaskDoneRequest) (*todov1.Task, error) {
+	task, err := s.svc.WithContext(ctx).FindTask(uint(req.GetId()))
+	if err != nil {
+		return nil, statusError(err, "Could not retrieve task")
+	}
+	if err := s.svc.WithContext(ctx).SetTaskDone(&task, req.GetDone()); err != nil {
+		return nil, statusError(err, "Could not update task")
+	}
+	return taskToProto(&task), nil
+}
+
+func (s *server) DeleteTask(ctx context.Context, req *todov1.DeleteTaskRequest) (*emptypb.Empty, error) {
+	task, err := s.svc.WithContext(ctx).FindTask(uint(req.GetId()))
+	if err != nil {
+		return nil, statusError(err, "Could not delete task")
+	}
+	if err := s.svc.WithContext(ctx).DeleteTask(&task); err != nil {
+		return nil, statusError(err, "Could not delete task")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) ListSubtasks(ctx context.Context, req *todov1.ListSubtasksRequest) (*todov1.ListSubtasksResponse, error) {
+	subtasks, err := s.svc.WithContext(ctx).ListSubtasks(uint(req.GetTaskId()))
+	if err != nil {
+		return nil, statusError(err, "Could not retrieve subtasks")
+	}
+	resp := &todov1.ListSubtasksResponse{Subtasks: make([]*todov1.Subtask, 0, len(subtasks))}
+	for i := range subtasks {
+		resp.Subtasks = append(resp.Subtasks, subtaskToProto(&subtasks[i]))
+	}
+	return resp, nil
+}
+
+func (s *server) CreateSubtask(ctx context.Context, req *todov1.CreateSubtaskRequest) (*todov1.Subtask, error) {
+	subtask := models.Subtask{TaskID: uint(req.GetTaskId()), Title: req.GetTitle()}
+	if err := s.svc.WithContext(ctx).CreateSubtask(&subtask); err != nil {
+		return nil, statusError(err, "Could not create subtask")
+	}
+	return subtaskToProto(&subtask), nil
+}
+
+func (s *server) UpdateSubtask(ctx context.Context, req *todov1.UpdateSubtaskRequest) (*todov1.Subtask, error) {
+	subtask, err := s.svc.WithContext(ctx).FindSubtask(uint(req.GetId()))
+	if err != nil {
+		return nil, statusError(err, "Could not retrieve subtask")
+	}
+	if err := s.svc.WithContext(ctx).UpdateSubtask(&subtask, models.Subtask{Title: req.GetTitle()}); err != nil {
+		return nil, statusError(err, "Could not update subtask")
+	}
+	return subtaskToProto(&subtask), nil
+}
+
+func (s *server) SetSubtaskDone(ctx context.Context, req *todov1.SetSubtaskDoneRequest) (*todov1.Subtask, error) {
+	subtask, err := s.svc.WithContext(ctx).FindSubtask(uint(req.GetId()))
+	if err != nil {
+		return nil, statusError(err, "Could not retrieve subtask")
+	}
+	if err := s.svc.WithContext(ctx).SetSubtaskDone(&subtask, req.GetDone()); err != nil {
+		return nil, statusError(err, "Could not update subtask")
+	}
+	return subtaskToProto(&subtask), nil
+}
+
+func (s *server) DeleteSubtask(ctx context.Context, req *todov1.DeleteSubtaskRequest) (*emptypb.Empty, error) {
+	subtask, err := s.svc.WithContext(ctx).FindSubtask(uint(req.GetId()))
+	if err != nil {
+		return nil, statusError(err, "Could not delete subtask")
+	}
+	if err := s.svc.WithContext(ctx).DeleteSubtask(&subtask); err != nil {
+		return nil, statusError(err, "Could not delete subtask")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *server) WatchTasks(req *todov1.WatchTasksRequest, stream todov1.TodoService_WatchTasksServer) error {
+	events, unsubscribe := s.svc.Subscribe()
+	defer unsubscribe()
+	// Send headers right away so clients can tell the subscription is live.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				if s.svc.Closed() {
+					return status.Error(codes.Unavailable, "Server is shutting down")
+				}
+				return status.Error(codes.ResourceExhausted, "Too many changes were not received in time; watch again and reload the tasks")
+			}
+			msg := &todov1.TaskEvent{Type: eventTypeToProto(event.Type), TaskId: uint64(event.TaskID)}
+			if event.Type != services.TaskDeleted {
+				task, err := s.svc.WithContext(stream.Context()).GetTask(event.TaskID)
+				if errors.Is(err, services.ErrTaskNotFound) {
+					// Deleted again before we got to it; its delete event follows.
+					continue
+				}
+				if err != nil {
+					return statusError(err, "Could not retrieve task")
+				}
+				msg.Task = taskToProto(&task)
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// statusError maps service errors onto gRPC status codes, using the same
+// messages as the REST handlers.
+func statusError(err error, fallback string) error {
+	var syntax *taskquery.SyntaxError
+	switch {
+	case errors.Is(err, services.ErrTaskNotFound):
+		return status.Error(codes.NotFound, "Task not found")
+	case errors.Is(err, services.ErrSubtaskNotFound):
+		return status.Error(codes.NotFound, "Subtask not found")
+	case services.IsValidationError(err):
+		st := status.New(codes.InvalidArgument, services.ValidationMessage(err))
+		var violations []*errdetails.BadRequest_FieldViolation
+		for _, f := range services.FieldErrors(err) {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message})
+		}
+		if detailed, derr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); derr == nil {
+			st = detailed
+		}
+		return st.Err()
+	case errors.As(err, &syntax):
+		st := status.New(codes.InvalidArgument, syntax.Error())
+		violation := &errdetails.BadRequest_FieldViolation{Field: "q", Description: syntax.Error()}
+		if detailed, derr := st.WithDetails(&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{violation}}); derr == nil {
+			st = detailed
+		}
+		return st.Err()
+	}
+	return status.Error(codes.Internal, fallback)
+}
+
+func eventTypeToProto(t services.EventType) todov1.TaskEvent_Type {
+	switch t {
+	case services.TaskCreated:
+		return todov1.TaskEvent_TYPE_CREATED
+	case services.TaskUpdated:
+		return todov1.TaskEvent_TYPE_UPDATED
+	case services.TaskDeleted:
+		return todov1.TaskEvent_TYPE_DELETED
+	}
+	return todov1.TaskEvent_TYPE_UNSPECIFIED
+}
+
+func taskToProto(task *models.Task) *todov1.Task {
+	pb := &todov1.Task{
+		Id:          uint64(task.ID),
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Assignee:    task.Assignee,
+		DueAllDay:   task.DueAllDay,
+		DueTimezone: task.DueTimezone,
+		Done:        task.Done,
+		CreatedAt:   timestamppb.New(task.CreatedAt),
+		UpdatedAt:   timestamppb.New(task.UpdatedAt),
+	}
+	if task.DueDate != nil {
+		pb.DueDate = timestamppb.New(*task.DueDate)
+	}
+	if task.OverdueAt != nil {
+		pb.OverdueAt = timestamppb.New(*task.OverdueAt)
+	}
+	for i := range task.Subtasks {
+		pb.Subtasks = append(pb.Subtasks, subtaskToProto(&task.Subtasks[i]))
+	}
+	return pb
+}
+
+func taskFromProto(in *todov1.TaskInput) models.Task {
+	task := models.Task{
+		Title:       in.GetTitle(),
+		Description: in.GetDescription(),
+		Priority:    in.GetPriority(),
+		Assignee:    in.GetAssignee(),
+		DueAllDay:   in.GetDueAllDay(),
+		DueTimezone: in.GetDueTimezone(),
+	}
+	if in.GetDueDate() != nil {
+		due := in.GetDueDate().AsTime()
+		task.DueDate = &due
+	}
+	return task
+}
+
+func subtaskToProto(subtask *models.Subtask) *todov1.Subtask {
+	return &todov1.Subtask{
+		Id:        uint64(subtask.ID),
+		TaskId:    uint64(subtask.TaskID),
+		Title:     subtask.Title,
+		Done:      subtask.Done,
+		CreatedAt: timestamppb.New(subtask.CreatedAt),
+		UpdatedAt: timestamppb.New(subtask.UpdatedAt),
+	}
+}
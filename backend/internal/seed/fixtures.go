@@ -0,0 +1,155 @@
+package seed
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"todo/internal/models"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed samples.yaml
+var samplesYAML []byte
+
+var validate = validator.New()
+
+// fixtureFile is the layout of a fixture file:
+//
+//	tasks:
+//	  - title: Write report
+//	    priority: High        # Low, Medium (default) or High
+//	    assignee: Alice
+//	    due_date: 2025-09-30  # or due_in_days: 7, relative to today
+//	    subtasks:
+//	      - title: Collect data
+//	        done: true
+type fixtureFile struct {
+	Tasks []fixture `yaml:"tasks" json:"tasks"`
+}
+
+type fixture struct {
+	Title       string           `yaml:"title" json:"title"`
+	Description string           `yaml:"description" json:"description"`
+	Priority    string           `yaml:"priority" json:"priority"`
+	Assignee    string           `yaml:"assignee" json:"assignee"`
+	DueDate     string           `yaml:"due_date" json:"due_date"`
+	DueInDays   *int             `yaml:"due_in_days" json:"due_in_days"`
+	Done        bool             `yaml:"done" json:"done"`
+	Subtasks    []subtaskFixture `yaml:"subtasks" json:"subtasks"`
+}
+
+type subtaskFixture struct {
+	Title string `yaml:"title" json:"title"`
+	Done  bool   `yaml:"done" json:"done"`
+}
+
+// Samples returns the built-in sample tasks with due dates relative to now.
+func Samples(now time.Time) []models.Task {
+	tasks, err := parseFixtures(samplesYAML, ".yaml", now)
+	if err != nil {
+		panic("seed: invalid samples.yaml: " + err.Error())
+	}
+	return tasks
+}
+
+// LoadFile reads the tasks of a .yaml, .yml or .json fixture file. Relative
+// due dates are resolved against now.
+func LoadFile(path string, now time.Time) ([]models.Task, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("seed: %w", err)
+	}
+	tasks, err := parseFixtures(data, strings.ToLower(filepath.Ext(path)), now)
+	if err != nil {
+		return nil, fmt.Errorf("seed: %s: %w", path, err)
+	}
+	return tasks, nil
+}
+
+func parseFixtures(data []byte, ext string, now time.Time) ([]models.Task, error) {
+	var file fixtureFile
+	switch ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&file); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file type %q, use .yaml, .yml or .json", ext)
+	}
+
+	tasks := make([]models.Task, 0, len(file.Tasks))
+	for i, f := range file.Tasks {
+		task, err := f.task(now)
+		if err != nil {
+			return nil, fmt.Errorf("task %d (%q): %w", i+1, f.Title, err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (f fixture) task(now time.Time) (models.Task, error) {
+	task := models.Task{
+		Title:       f.Title,
+		Description: f.Description,
+		Priority:    f.Priority,
+		Assignee:    f.Assignee,
+		Done:        f.Done,
+	}
+	if task.Priority == "" {
+		task.Priority = "Medium"
+	}
+	switch {
+	case f.DueDate != "" && f.DueInDays != nil:
+		return task, errors.New("set due_date or due_in_days, not both")
+	case f.DueDate != "":
+		due, allDay, err := parseDate(f.DueDate)
+		if err != nil {
+			return task, err
+		}
+		task.DueDate, task.DueAllDay = &due, allDay
+	case f.DueInDays != nil:
+		due := now.AddDate(0, 0, *f.DueInDays)
+		task.DueDate = &due
+	}
+	for _, st := range f.Subtasks {
+		if st.Title == "" {
+			return task, errors.New("subtask title must not be empty")
+		}
+		task.Subtasks = append(task.Subtasks, models.Subtask{Title: st.Title, Done: st.Done})
+	}
+	if err := validate.Struct(task); err != nil {
+		return task, err
+	}
+	return task, nil
+}
+
+// parseDate parses a due date, reporting whether it is a date without a
+// time of day, which makes a date-only deadline in UTC.
+func parseDate(s string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return t, false, fmt.Errorf("due_date %q is not a date like 2006-01-02 or an RFC 3339 time", s)
+	}
+	return t, true, nil
+}
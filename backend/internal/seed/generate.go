@@ -0,0 +1,95 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+	"todo/internal/models"
+)
+
+// weighted picks values with the given relative weights.
+type weighted[T any] struct {
+	values  []T
+	weights []int
+	total   int
+}
+
+func newWeighted[T any](values []T, weights []int) weighted[T] {
+	w := weighted[T]{values: values, weights: weights}
+	for _, weight := range weights {
+		w.total += weight
+	}
+	return w
+}
+
+func (w weighted[T]) pick(r *rand.Rand) T {
+	n := r.Intn(w.total)
+	for i, weight := range w.weights {
+		if n < weight {
+			return w.values[i]
+		}
+		n -= weight
+	}
+	return w.values[len(w.values)-1]
+}
+
+var (
+	priorities = newWeighted([]string{"Low", "Medium", "High"}, []int{30, 50, 20})
+	// A few people own most of the work and some tasks are unassigned.
+	assignees = newWeighted(
+		[]string{"Alice", "Bob", "Charlie", "Dana", "Eve", "Frank", "Grace", "Hannah", ""},
+		[]int{22, 18, 14, 11, 9, 7, 5, 4, 10},
+	)
+	// Mostly small tasks, with a long tail of bigger ones.
+	subtaskCounts = newWeighted([]int{0, 1, 2, 3, 4, 5, 6, 8}, []int{25, 15, 20, 15, 10, 7, 5, 3})
+
+	verbs    = []string{"Review", "Fix", "Write", "Update", "Plan", "Test", "Deploy", "Refactor", "Document", "Design"}
+	subjects = []string{"login flow", "invoice export", "search page", "billing API", "onboarding emails", "release notes", "dashboard", "mobile layout", "backup job", "audit log"}
+	steps    = []string{"Gather requirements", "Draft", "Implement", "Write tests", "Get review", "Fix review comments", "Update docs", "Ship"}
+)
+
+// Generate returns n synthetic tasks for load testing. Titles are numbered
+// and the fields come from a generator seeded with seed, so the same n and
+// seed always describe the same tasks and seeding them again is a no-op.
+// Due dates are spread around now.
+func Generate(n int, seed int64, now time.Time) []models.Task {
+	r := rand.New(rand.NewSource(seed))
+	today := now.Truncate(24 * time.Hour)
+	tasks := make([]models.Task, 0, n)
+	for i := 0; i < n; i++ {
+		verb, subject := verbs[r.Intn(len(verbs))], subjects[r.Intn(len(subjects))]
+		task := models.Task{
+			Title:       fmt.Sprintf("%s %s #%d", verb, subject, i+1),
+			Description: fmt.Sprintf("%s the %s.", verb, subject),
+			Priority:    priorities.pick(r),
+			Assignee:    assignees.pick(r),
+		}
+
+		// One task in six has no due date; the rest fall between two weeks
+		// ago and six weeks ahead, bunched around the coming week.
+		overdue := false
+		if r.Intn(6) != 0 {
+			days := int(r.NormFloat64()*10) + 7
+			days = max(-14, min(days, 42))
+			due := today.AddDate(0, 0, days)
+			task.DueDate, task.DueAllDay = &due, true
+			overdue = days < 0
+		}
+		// Overdue work is more likely to be finished already.
+		if overdue {
+			task.Done = r.Intn(100) < 60
+		} else {
+			task.Done = r.Intn(100) < 25
+		}
+
+		count := subtaskCounts.pick(r)
+		for j := 0; j < count; j++ {
+			task.Subtasks = append(task.Subtasks, models.Subtask{
+				Title: steps[j%len(steps)],
+				Done:  task.Done || r.Intn(100) < 35,
+			})
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
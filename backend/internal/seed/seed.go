@@ -0,0 +1,139 @@
+// Package seed fills the database with tasks: the built-in samples, YAML or
+// JSON fixture files, or synthetic tasks for load testing.
+//
+// Seeding is idempotent. A task is identified by its title and assignee and
+// a subtask by its task and title; rows that already exist are left alone,
+// so seeding the same tasks twice inserts them once.
+package seed
+
+import (
+	"fmt"
+	"todo/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Result counts what Apply inserted and skipped.
+type Result struct {
+	TasksCreated    int
+	TasksSkipped    int
+	SubtasksCreated int
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("created %d tasks and %d subtasks, skipped %d existing tasks",
+		r.TasksCreated, r.SubtasksCreated, r.TasksSkipped)
+}
+
+// batchSize bounds the number of tasks looked up and inserted per query.
+const batchSize = 500
+
+type taskKey struct {
+	title    string
+	assignee string
+}
+
+type subtaskKey struct {
+	taskID uint
+	title  string
+}
+
+// Apply inserts the tasks and subtasks that are not in the database yet.
+func Apply(db *gorm.DB, tasks []models.Task) (Result, error) {
+	var result Result
+	err := db.Transaction(func(tx *gorm.DB) error {
+		seen := make(map[taskKey]bool)
+		for start := 0; start < len(tasks); start += batchSize {
+			end := min(start+batchSize, len(tasks))
+			if err := applyBatch(tx, tasks[start:end], seen, &result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func applyBatch(tx *gorm.DB, batch []models.Task, seen map[taskKey]bool, result *Result) error {
+	titles := make([]string, 0, len(batch))
+	for _, task := range batch {
+		titles = append(titles, task.Title)
+	}
+	var rows []models.Task
+	if err := tx.Select("id", "title", "assignee").Where("title IN ?", titles).Find(&rows).Error; err != nil {
+		return err
+	}
+	existing := make(map[taskKey]uint, len(rows))
+	for _, row := range rows {
+		existing[taskKey{row.Title, row.Assignee}] = row.ID
+	}
+
+	var created []models.Task
+	var missing []models.Subtask
+	var existingIDs []uint
+	wanted := make(map[uint][]models.Subtask)
+	for _, task := range batch {
+		key := taskKey{task.Title, task.Assignee}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		id, ok := existing[key]
+		if !ok {
+			task.ID = 0
+			created = append(created, task)
+			continue
+		}
+		result.TasksSkipped++
+		existingIDs = append(existingIDs, id)
+		wanted[id] = task.Subtasks
+	}
+
+	// Tasks that already exist may still be missing subtasks added to the
+	// fixtures since they were seeded.
+	if len(existingIDs) > 0 {
+		var subtasks []models.Subtask
+		if err := tx.Select("task_id", "title").Where("task_id IN ?", existingIDs).Find(&subtasks).Error; err != nil {
+			return err
+		}
+		have := make(map[subtaskKey]bool, len(subtasks))
+		for _, st := range subtasks {
+			have[subtaskKey{st.TaskID, st.Title}] = true
+		}
+		for _, id := range existingIDs {
+			for _, st := range wanted[id] {
+				if key := (subtaskKey{id, st.Title}); !have[key] {
+					have[key] = true
+					st.ID, st.TaskID = 0, id
+					missing = append(missing, st)
+				}
+			}
+		}
+	}
+
+	if len(created) > 0 {
+		if err := tx.CreateInBatches(&created, 100).Error; err != nil {
+			return err
+		}
+		result.TasksCreated += len(created)
+		// Recorded as the repositories would, for the analytics.
+		var changes []models.TaskChange
+		for _, task := range created {
+			result.SubtasksCreated += len(task.Subtasks)
+			changes = append(changes, models.TaskChange{TaskID: task.ID, Kind: models.ChangeCreated, Assignee: task.Assignee, OpenDelta: 1, OccurredAt: task.CreatedAt})
+			if task.Done {
+				changes = append(changes, models.TaskChange{TaskID: task.ID, Kind: models.ChangeCompleted, Assignee: task.Assignee, OpenDelta: -1, OccurredAt: task.UpdatedAt})
+			}
+		}
+		if err := tx.CreateInBatches(&changes, 100).Error; err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		if err := tx.CreateInBatches(&missing, 100).Error; err != nil {
+			return err
+		}
+		result.SubtasksCreated += len(missing)
+	}
+	return nil
+}
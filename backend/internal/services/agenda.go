@@ -0,0 +1,96 @@
+package services
+
+import (
+	"time"
+	"todo/internal/models"
+)
+
+// Agenda buckets the open tasks matching q by their due date at now.
+func (s *Service) Agenda(q models.AgendaQuery, now time.Time) (models.Agenda, error) {
+	if err := validate.Struct(&q); err != nil {
+		return models.Agenda{}, err
+	}
+	loc := time.UTC
+	if q.TZ != "" {
+		loc, _ = time.LoadLocation(q.TZ)
+	}
+	now = now.In(loc)
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	tomorrow := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	afterTomorrow := time.Date(y, m, d+2, 0, 0, 0, 0, loc)
+	later := weekEnd(now)
+	if later.Before(afterTomorrow) {
+		later = afterTomorrow
+	}
+
+	tasks, err := s.tasks.List(models.TaskFilter{Assignee: q.Assignee, Status: "pending", SortBy: "dueDate"}, true)
+	if err != nil {
+		return models.Agenda{}, err
+	}
+	buckets := make(map[string]*models.AgendaBucket, len(models.AgendaBuckets))
+	agenda := models.Agenda{Date: now.Format(dateLayout), TZ: loc.String(), Buckets: []models.AgendaBucket{}}
+	for _, name := range models.AgendaBuckets {
+		if q.Bucket == "" || q.Bucket == name {
+			agenda.Buckets = append(agenda.Buckets, models.AgendaBucket{Name: name, Tasks: []models.Task{}})
+		}
+	}
+	for i := range agenda.Buckets {
+		buckets[agenda.Buckets[i].Name] = &agenda.Buckets[i]
+	}
+	for _, task := range tasks {
+		due := dueIn(task, loc)
+		// Date-only deadlines are overdue once their day is over.
+		overdueBefore := now
+		if task.DueAllDay {
+			overdueBefore = today
+		}
+		var name string
+		switch {
+		case task.DueDate == nil:
+			name = models.BucketNoDate
+		case due.Before(overdueBefore):
+			name = models.BucketOverdue
+		case due.Before(tomorrow):
+			name = models.BucketToday
+		case due.Before(afterTomorrow):
+			name = models.BucketTomorrow
+		case due.Before(later):
+			name = models.BucketThisWeek
+		default:
+			name = models.BucketLater
+		}
+		bucket, ok := buckets[name]
+		if !ok {
+			continue
+		}
+		open := make([]models.Subtask, 0, len(task.Subtasks))
+		for _, st := range task.Subtasks {
+			if !st.Done {
+				open = append(open, st)
+			}
+		}
+		task.Subtasks = open
+		bucket.Tasks = append(bucket.Tasks, task)
+		bucket.OpenSubtasks += len(open)
+	}
+	return agenda, nil
+}
+
+// dueIn returns when task is due as seen from loc. Date-only deadlines keep
+// their day wherever they are seen, like all-day events in a calendar, so
+// they are due at its midnight in loc.
+func dueIn(task models.Task, loc *time.Location) time.Time {
+	if task.DueDate == nil {
+		return time.Time{}
+	}
+	if !task.DueAllDay {
+		return *task.DueDate
+	}
+	created, err := time.LoadLocation(task.DueTimezone)
+	if err != nil {
+		created = time.UTC
+	}
+	y, m, d := task.DueDate.In(created).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
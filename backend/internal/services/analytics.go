@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+	"todo/internal/models"
+
+	// Time zones are named by clients, so the server must not depend on
+	// the zone database of its host.
+	_ "time/tzdata"
+)
+
+// ErrInvalidRange is returned for analytics ranges that end before they
+// start or span more than maxAnalyticsDays.
+var ErrInvalidRange = errors.New("invalid analytics range")
+
+const (
+	defaultAnalyticsDays = 30
+	maxAnalyticsDays     = 366
+	dateLayout           = "2006-01-02"
+)
+
+// analyticsRange is a resolved models.AnalyticsQuery: from is the first
+// midnight and to the midnight ending the last day.
+type analyticsRange struct {
+	from, to time.Time
+	loc      *time.Location
+	days     int
+}
+
+func resolveRange(q models.AnalyticsQuery, now time.Time) (analyticsRange, error) {
+	if err := validate.Struct(&q); err != nil {
+		return analyticsRange{}, err
+	}
+	loc := time.UTC
+	if q.TZ != "" {
+		loc, _ = time.LoadLocation(q.TZ)
+	}
+	y, m, d := now.In(loc).Date()
+	last := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if q.To != "" {
+		last, _ = time.ParseInLocation(dateLayout, q.To, loc)
+	}
+	first := last.AddDate(0, 0, 1-defaultAnalyticsDays)
+	if q.From != "" {
+		first, _ = time.ParseInLocation(dateLayout, q.From, loc)
+	}
+	// Counted on the calendar, as days around a DST change are not 24 hours.
+	days := 0
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if days++; days > maxAnalyticsDays {
+			return analyticsRange{}, ErrInvalidRange
+		}
+	}
+	if days == 0 {
+		return analyticsRange{}, ErrInvalidRange
+	}
+	return analyticsRange{from: first, to: last.AddDate(0, 0, 1), loc: loc, days: days}, nil
+}
+
+func (r analyticsRange) echo() models.AnalyticsRange {
+	return models.AnalyticsRange{
+		From: r.from.Format(dateLayout),
+		To:   r.to.AddDate(0, 0, -1).Format(dateLayout),
+		TZ:   r.loc.String(),
+	}
+}
+
+// Burndown counts the open tasks at the end of every day of q.
+func (s *Service) Burndown(q models.AnalyticsQuery, now time.Time) (models.Burndown, error) {
+	r, err := resolveRange(q, now)
+	if err != nil {
+		return models.Burndown{}, err
+	}
+	open, err := s.tasks.OpenAt(r.from)
+	if err != nil {
+		return models.Burndown{}, err
+	}
+	changes, err := s.tasks.ListChanges(models.TaskChangeFilter{From: r.from, To: r.to})
+	if err != nil {
+		return models.Burndown{}, err
+	}
+
+	burndown := models.Burndown{AnalyticsRange: r.echo(), Points: make([]models.BurndownPoint, 0, r.days)}
+	for i, day := 0, r.from; i < r.days; i++ {
+		next := day.AddDate(0, 0, 1)
+		point := models.BurndownPoint{Date: day.Format(dateLayout)}
+		for len(changes) > 0 && changes[0].OccurredAt.Before(next) {
+			switch delta := int64(changes[0].OpenDelta); {
+			case delta > 0:
+				point.Opened += delta
+			case delta < 0:
+				point.Closed -= delta
+			}
+			open += int64(changes[0].OpenDelta)
+			changes = changes[1:]
+		}
+		point.Open = open
+		burndown.Points = append(burndown.Points, point)
+		day = next
+	}
+	return burndown, nil
+}
+
+// Throughput counts the tasks completed in every week that overlaps q, per
+// assignee. Weeks start on Monday; the first and last may be partial.
+func (s *Service) Throughput(q models.AnalyticsQuery, now time.Time) (models.Throughput, error) {
+	r, err := resolveRange(q, now)
+	if err != nil {
+		return models.Throughput{}, err
+	}
+	completed, err := s.tasks.ListChanges(models.TaskChangeFilter{From: r.from, To: r.to, Kinds: []string{models.ChangeCompleted}})
+	if err != nil {
+		return models.Throughput{}, err
+	}
+
+	throughput := models.Throughput{AnalyticsRange: r.echo(), Weeks: []string{}, Series: []models.ThroughputSeries{}}
+	// Mondays are at most 6 days back.
+	monday := r.from.AddDate(0, 0, -((int(r.from.Weekday()) + 6) % 7))
+	var weekStarts []time.Time
+	for week := monday; week.Before(r.to); week = week.AddDate(0, 0, 7) {
+		weekStarts = append(weekStarts, week)
+		throughput.Weeks = append(throughput.Weeks, week.Format(dateLayout))
+	}
+	byAssignee := make(map[string]*models.ThroughputSeries)
+	for _, c := range completed {
+		series, ok := byAssignee[c.Assignee]
+		if !ok {
+			series = &models.ThroughputSeries{Assignee: c.Assignee, Completed: make([]int64, len(weekStarts))}
+			byAssignee[c.Assignee] = series
+		}
+		week := sort.Search(len(weekStarts), func(i int) bool { return weekStarts[i].After(c.OccurredAt) }) - 1
+		series.Completed[week]++
+		series.Total++
+	}
+	for _, series := range byAssignee {
+		throughput.Series = append(throughput.Series, *series)
+	}
+	sort.Slice(throughput.Series, func(i, j int) bool { return throughput.Series[i].Assignee < throughput.Series[j].Assignee })
+	return throughput, nil
+}
+
+// CycleTimes measures the lead and cycle times of the tasks completed
+// during q. A task completed twice counts twice.
+func (s *Service) CycleTimes(q models.AnalyticsQuery, now time.Time) (models.CycleTimes, error) {
+	r, err := resolveRange(q, now)
+	if err != nil {
+		return models.CycleTimes{}, err
+	}
+	completed, err := s.tasks.ListChanges(models.TaskChangeFilter{From: r.from, To: r.to, Kinds: []string{models.ChangeCompleted}})
+	if err != nil {
+		return models.CycleTimes{}, err
+	}
+	times := models.CycleTimes{AnalyticsRange: r.echo()}
+	if len(completed) == 0 {
+		return times, nil
+	}
+	taskIDs := make([]uint, 0, len(completed))
+	seen := make(map[uint]bool)
+	for _, c := range completed {
+		if !seen[c.TaskID] {
+			seen[c.TaskID] = true
+			taskIDs = append(taskIDs, c.TaskID)
+		}
+	}
+	starts, err := s.tasks.ListChanges(models.TaskChangeFilter{To: r.to, Kinds: []string{models.ChangeCreated, models.ChangeReopened}, TaskIDs: taskIDs})
+	if err != nil {
+		return models.CycleTimes{}, err
+	}
+	startsOf := make(map[uint][]models.TaskChange)
+	for _, c := range starts {
+		startsOf[c.TaskID] = append(startsOf[c.TaskID], c)
+	}
+
+	var lead, cycle []float64
+	for _, c := range completed {
+		taskStarts := startsOf[c.TaskID]
+		// Changes are oldest first, so the first is the creation.
+		if len(taskStarts) == 0 || taskStarts[0].Kind != models.ChangeCreated {
+			continue
+		}
+		last := taskStarts[0]
+		for _, start := range taskStarts[1:] {
+			if start.OccurredAt.After(c.OccurredAt) {
+				break
+			}
+			last = start
+		}
+		lead = append(lead, c.OccurredAt.Sub(taskStarts[0].OccurredAt).Seconds())
+		cycle = append(cycle, c.OccurredAt.Sub(last.OccurredAt).Seconds())
+	}
+	times.Completed = len(lead)
+	times.LeadTime = durationStats(lead)
+	times.CycleTime = durationStats(cycle)
+	return times, nil
+}
+
+func durationStats(seconds []float64) models.DurationStats {
+	if len(seconds) == 0 {
+		return models.DurationStats{}
+	}
+	sort.Float64s(seconds)
+	var sum float64
+	for _, s := range seconds {
+		sum += s
+	}
+	percentile := func(p float64) float64 {
+		return seconds[int(math.Ceil(p*float64(len(seconds))))-1]
+	}
+	return models.DurationStats{
+		Mean: sum / float64(len(seconds)),
+		P50:  percentile(0.50),
+		P75:  percentile(0.75),
+		P90:  percentile(0.90),
+		P95:  percentile(0.95),
+		Max:  seconds[len(seconds)-1],
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+type EventType int
+
+const (
+	TaskCreated EventType = iota + 1
+	TaskUpdated
+	TaskDeleted
+)
+
+// TaskEvent reports a change to a task. Changes to subtasks are reported as
+// TaskUpdated for their parent task.
+type TaskEvent struct {
+	Type   EventType
+	TaskID uint
+}
+
+// eventBuffer is how many events a subscriber may fall behind before its
+// subscription ends.
+const eventBuffer = 64
+
+// eventBus fans task events out to subscribers and counts them, so that
+// readers can tell whether anything changed.
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan TaskEvent]struct{}
+	closed      bool
+	revision    uint64
+	changed     time.Time
+}
+
+func newEventBus() *eventBus {
+	// Changes made before the process started are unknown, so they count
+	// as made at startup.
+	return &eventBus{subscribers: make(map[chan TaskEvent]struct{}), changed: time.Now()}
+}
+
+// Revision returns a number that grows with every change made through s,
+// and when the last change was made, or when s was created. Changes made
+// by other processes on the same database are not counted.
+func (s *Service) Revision() (revision uint64, changed time.Time) {
+	b := s.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.revision, b.changed
+}
+
+// Subscribe returns a channel receiving every task change made through s,
+// and a function that unsubscribes and closes the channel. The channel is
+// also closed by Close, and when the subscriber falls eventBuffer events
+// behind: it would miss changes, so it must subscribe again and reload what
+// it shows. Closed tells the two apart.
+func (s *Service) Subscribe() (<-chan TaskEvent, func()) {
+	b := s.events
+	ch := make(chan TaskEvent, eventBuffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Closed reports whether Close was called.
+func (s *Service) Closed() bool {
+	b := s.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// Close ends every subscription, now and in future, so that watchers such
+// as the gRPC WatchTasks streams return when the server shuts down.
+func (s *Service) Close() {
+	b := s.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (b *eventBus) publish(eventType EventType, taskID uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.touchLocked()
+	for ch := range b.subscribers {
+		select {
+		case ch <- TaskEvent{Type: eventType, TaskID: taskID}:
+		default:
+			// Dropping the event would leave the subscriber out of sync
+			// without knowing it.
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// touch counts a change that is not a task event, such as to a saved view.
+func (b *eventBus) touch() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.touchLocked()
+}
+
+func (b *eventBus) touchLocked() {
+	b.revision++
+	b.changed = time.Now()
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"todo/internal/repository"
+)
+
+// Service holds the business logic shared by the REST, GraphQL and gRPC
+// APIs. Each Service has its own repositories and event subscribers, so
+// several can run side by side.
+type Service struct {
+	tasks    repository.TaskRepository
+	subtasks repository.SubtaskRepository
+	views    repository.ViewRepository
+	events   *eventBus
+}
+
+func New(tasks repository.TaskRepository, subtasks repository.SubtaskRepository, views repository.ViewRepository) *Service {
+	return &Service{
+		tasks:    tasks,
+		subtasks: subtasks,
+		views:    views,
+		events:   newEventBus(),
+	}
+}
+
+// WithContext returns a copy of s whose repositories run under ctx, so that
+// queries are cancelled with the request and traced as part of it. The copy
+// shares the event subscribers of s.
+func (s *Service) WithContext(ctx context.Context) *Service {
+	return &Service{
+		tasks:    s.tasks.WithContext(ctx),
+		subtasks: s.subtasks.WithContext(ctx),
+		views:    s.views.WithContext(ctx),
+		events:   s.events,
+	}
+}
+
+func notFound(err, sentinel error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return sentinel
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"todo/internal/models"
+)
+
+var ErrSubtaskNotFound = errors.New("subtask not found")
+
+func (s *Service) ListSubtasks(taskID uint) ([]models.Subtask, error) {
+	return s.subtasks.ListByTasks(taskID)
+}
+
+// SubtasksByTaskIDs loads the subtasks of several tasks in a single query,
+// keyed by task ID. Every requested ID is present in the result.
+func (s *Service) SubtasksByTaskIDs(taskIDs []uint) (map[uint][]models.Subtask, error) {
+	byTask := make(map[uint][]models.Subtask, len(taskIDs))
+	for _, id := range taskIDs {
+		byTask[id] = []models.Subtask{}
+	}
+	if len(taskIDs) == 0 {
+		return byTask, nil
+	}
+	subtasks, err := s.subtasks.ListByTasks(taskIDs...)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range subtasks {
+		byTask[st.TaskID] = append(byTask[st.TaskID], st)
+	}
+	return byTask, nil
+}
+
+func (s *Service) FindSubtask(id uint) (models.Subtask, error) {
+	subtask, err := s.subtasks.Get(id)
+	return subtask, notFound(err, ErrSubtaskNotFound)
+}
+
+func (s *Service) CreateSubtask(subtask *models.Subtask) error {
+	if err := validate.Struct(subtask); err != nil {
+		return err
+	}
+	if err := s.subtasks.Create(subtask); err != nil {
+		return err
+	}
+	s.events.publish(TaskUpdated, subtask.TaskID)
+	return nil
+}
+
+// UpdateSubtask copies the editable fields of input onto subtask and saves it.
+func (s *Service) UpdateSubtask(subtask *models.Subtask, input models.Subtask) error {
+	if err := validate.Struct(&input); err != nil {
+		return err
+	}
+	subtask.Title = input.Title
+	return s.saveSubtask(subtask)
+}
+
+func (s *Service) SetSubtaskDone(subtask *models.Subtask, done bool) error {
+	subtask.Done = done
+	return s.saveSubtask(subtask)
+}
+
+func (s *Service) DeleteSubtask(subtask *models.Subtask) error {
+	if err := s.subtasks.Delete(subtask); err != nil {
+		return err
+	}
+	s.events.publish(TaskUpdated, subtask.TaskID)
+	return nil
+}
+
+func (s *Service) saveSubtask(subtask *models.Subtask) error {
+	if err := s.subtasks.Save(subtask); err != nil {
+		return notFound(err, ErrSubtaskNotFound)
+	}
+	s.events.publish(TaskUpdated, subtask.TaskID)
+	return nil
+}
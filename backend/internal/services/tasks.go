@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"time"
+	"todo/internal/models"
+)
+
+var ErrTaskNotFound = errors.New("task not found")
+
+// ListTasks returns the tasks matching filter with their subtasks preloaded.
+func (s *Service) ListTasks(filter models.TaskFilter) ([]models.Task, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	return s.tasks.List(filter, true)
+}
+
+// ListTaskRows is ListTasks without the subtask preload, for callers that
+// batch-load subtasks themselves.
+func (s *Service) ListTaskRows(filter models.TaskFilter) ([]models.Task, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	return s.tasks.List(filter, false)
+}
+
+// TaskSummary holds aggregate counts over a filtered task list.
+type TaskSummary struct {
+	Total int64 `json:"total"`
+	Done  int64 `json:"done"`
+	Open  int64 `json:"open"`
+}
+
+// SummarizeTasks counts the tasks matching filter without loading them.
+func (s *Service) SummarizeTasks(filter models.TaskFilter) (TaskSummary, error) {
+	var summary TaskSummary
+	if err := validateFilter(filter); err != nil {
+		return summary, err
+	}
+	total, done, err := s.tasks.Count(filter)
+	if err != nil {
+		return summary, err
+	}
+	return TaskSummary{Total: total, Done: done, Open: total - done}, nil
+}
+
+// OpenTaskCounts counts the open and overdue tasks of every priority,
+// including priorities without open tasks.
+func (s *Service) OpenTaskCounts(now time.Time) ([]models.PriorityCount, error) {
+	counts, err := s.tasks.OpenCounts(now)
+	if err != nil {
+		return nil, err
+	}
+	byPriority := make(map[string]models.PriorityCount, len(counts))
+	for _, c := range counts {
+		byPriority[c.Priority] = c
+	}
+	all := make([]models.PriorityCount, 0, len(models.Priorities))
+	for _, p := range models.Priorities {
+		c := byPriority[p]
+		c.Priority = p
+		all = append(all, c)
+	}
+	return all, nil
+}
+
+// TaskStats aggregates the tasks matching filter at now. The week that
+// DueThisWeek covers ends on Sunday midnight in the location of now.
+func (s *Service) TaskStats(filter models.TaskFilter, now time.Time) (models.TaskStats, error) {
+	if err := validateFilter(filter); err != nil {
+		return models.TaskStats{}, err
+	}
+	stats, err := s.tasks.Stats(filter, now, weekEnd(now))
+	if err != nil {
+		return stats, err
+	}
+	stats.Open = stats.Total - stats.Done
+	for i := range stats.ByAssignee {
+		stats.ByAssignee[i].Open = stats.ByAssignee[i].Total - stats.ByAssignee[i].Done
+	}
+	byPriority := make(map[string]models.GroupCount, len(stats.ByPriority))
+	for _, g := range stats.ByPriority {
+		byPriority[g.Name] = g
+	}
+	stats.ByPriority = make([]models.GroupCount, 0, len(models.Priorities))
+	for _, p := range models.Priorities {
+		g := byPriority[p]
+		g.Name = p
+		g.Open = g.Total - g.Done
+		stats.ByPriority = append(stats.ByPriority, g)
+	}
+	if stats.Subtasks.Total > 0 {
+		stats.Subtasks.CompletionRatio = float64(stats.Subtasks.Done) / float64(stats.Subtasks.Total)
+	}
+	return stats, nil
+}
+
+// weekEnd returns the Monday midnight following now.
+func weekEnd(now time.Time) time.Time {
+	daysLeft := (7-int(now.Weekday()))%7 + 1
+	y, m, d := now.Date()
+	return time.Date(y, m, d+daysLeft, 0, 0, 0, 0, now.Location())
+}
+
+// GetTask returns the task with the given ID and its subtasks.
+func (s *Service) GetTask(id uint) (models.Task, error) {
+	task, err := s.tasks.Get(id, true)
+	return task, notFound(err, ErrTaskNotFound)
+}
+
+// FindTask returns the task with the given ID without its subtasks.
+func (s *Service) FindTask(id uint) (models.Task, error) {
+	task, err := s.tasks.Get(id, false)
+	return task, notFound(err, ErrTaskNotFound)
+}
+
+func (s *Service) CreateTask(task *models.Task) error {
+	if err := validate.Struct(task); err != nil {
+		return err
+	}
+	if err := s.tasks.Create(task); err != nil {
+		return err
+	}
+	s.events.publish(TaskCreated, task.ID)
+	return nil
+}
+
+// UpdateTask copies the editable fields of input onto task and saves it.
+func (s *Service) UpdateTask(task *models.Task, input models.Task) error {
+	if err := validate.Struct(&input); err != nil {
+		return err
+	}
+	task.Title = input.Title
+	task.Description = input.Description
+	task.Priority = input.Priority
+	task.Assignee = input.Assignee
+	task.DueDate = input.DueDate
+	task.DueAllDay = input.DueAllDay
+	task.DueTimezone = input.DueTimezone
+	return s.saveTask(task)
+}
+
+func (s *Service) SetTaskDone(task *models.Task, done bool) error {
+	task.Done = done
+	return s.saveTask(task)
+}
+
+func (s *Service) DeleteTask(task *models.Task) error {
+	if err := s.tasks.Delete(task); err != nil {
+		return err
+	}
+	s.events.publish(TaskDeleted, task.ID)
+	return nil
+}
+
+func (s *Service) saveTask(task *models.Task) error {
+	if err := s.tasks.Save(task); err != nil {
+		return notFound(err, ErrTaskNotFound)
+	}
+	s.events.publish(TaskUpdated, task.ID)
+	return nil
+}
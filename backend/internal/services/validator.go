@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"todo/internal/i18n"
+	"todo/internal/models"
+	"todo/internal/taskquery"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// Name fields as clients see them in JSON, not as Go struct fields.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return f.Name
+		}
+		return name
+	})
+	return v
+}
+
+// validateFilter validates filter like validate.Struct, and parses its
+// query, reporting mistakes in it as a *taskquery.SyntaxError.
+func validateFilter(filter models.TaskFilter) error {
+	if err := validate.Struct(&filter); err != nil {
+		return err
+	}
+	_, err := taskquery.Parse(filter.Query)
+	return err
+}
+
+// IsValidationError reports whether err was produced by validating user
+// input, as opposed to a storage failure.
+func IsValidationError(err error) bool {
+	var verrs validator.ValidationErrors
+	return errors.As(err, &verrs)
+}
+
+// FieldError describes why one input field was rejected.
+type FieldError struct {
+	// Field is the JSON name of the field.
+	Field string `json:"field"`
+	// Code is the rule that failed, such as "required" or "oneof".
+	Code string `json:"code"`
+	// Param is the argument of the rule, such as the allowed values of oneof.
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// FieldErrors lists the rejected fields of a validation error, or nil when
+// err is not one. Messages are in the default language.
+func FieldErrors(err error) []FieldError {
+	return LocalizedFieldErrors(err, i18n.For(i18n.Default))
+}
+
+// LocalizedFieldErrors is FieldErrors with messages formatted by tr.
+func LocalizedFieldErrors(err error, tr i18n.Translator) []FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, FieldError{
+			Field:   fe.Field(),
+			Code:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fieldMessage(tr, fe.Tag(), fe.Param()),
+		})
+	}
+	return fields
+}
+
+func fieldMessage(tr i18n.Translator, code, param string) string {
+	switch code {
+	case "required":
+		return tr.T("validation.required")
+	case "oneof":
+		return tr.T("validation.oneof", strings.Join(strings.Fields(param), ", "))
+	case "max":
+		return tr.T("validation.max", param)
+	default:
+		return tr.T("validation.invalid")
+	}
+}
+
+// ValidationMessage summarizes a validation error in one line, such as
+// "priority must be one of Low, Medium, High".
+func ValidationMessage(err error) string {
+	return LocalizedValidationMessage(err, i18n.For(i18n.Default))
+}
+
+// LocalizedValidationMessage is ValidationMessage formatted by tr.
+func LocalizedValidationMessage(err error, tr i18n.Translator) string {
+	fields := LocalizedFieldErrors(err, tr)
+	if fields == nil {
+		return err.Error()
+	}
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"todo/internal/models"
+)
+
+var (
+	ErrViewNotFound = errors.New("view not found")
+	// ErrNotViewOwner is returned when changing a shared view of someone
+	// else.
+	ErrNotViewOwner = errors.New("view belongs to someone else")
+)
+
+// ListViews returns the views owner can see: their own and the shared
+// ones.
+func (s *Service) ListViews(owner string) ([]models.SavedView, error) {
+	views, err := s.views.List(owner)
+	for i := range views {
+		views[i].Owned = views[i].Owner == owner
+	}
+	return views, err
+}
+
+// GetView returns the view with the given ID if owner can see it. The
+// private views of others are reported as not found.
+func (s *Service) GetView(id uint, owner string) (models.SavedView, error) {
+	view, err := s.views.Get(id)
+	if err != nil {
+		return view, notFound(err, ErrViewNotFound)
+	}
+	if view.Owner != owner && view.Visibility != models.VisibilityShared {
+		return models.SavedView{}, ErrViewNotFound
+	}
+	view.Owned = view.Owner == owner
+	return view, nil
+}
+
+// CreateView validates view, private unless it says otherwise, and stores
+// it as owned by owner.
+func (s *Service) CreateView(view *models.SavedView, owner string) error {
+	if view.Visibility == "" {
+		view.Visibility = models.VisibilityPrivate
+	}
+	if err := validateView(view); err != nil {
+		return err
+	}
+	view.Owner, view.Owned = owner, true
+	if err := s.views.Create(view); err != nil {
+		return err
+	}
+	s.events.touch()
+	return nil
+}
+
+// UpdateView copies the name, visibility and definition of input onto
+// view, which must belong to owner, and saves it.
+func (s *Service) UpdateView(view *models.SavedView, input models.SavedView, owner string) error {
+	if view.Owner != owner {
+		return ErrNotViewOwner
+	}
+	if input.Visibility == "" {
+		input.Visibility = models.VisibilityPrivate
+	}
+	if err := validateView(&input); err != nil {
+		return err
+	}
+	view.Name = input.Name
+	view.Visibility = input.Visibility
+	view.Definition = input.Definition
+	if err := s.views.Save(view); err != nil {
+		return notFound(err, ErrViewNotFound)
+	}
+	s.events.touch()
+	return nil
+}
+
+// validateView validates view and the query of its filter, so that running
+// it cannot fail on its definition.
+func validateView(view *models.SavedView) error {
+	if err := validate.Struct(view); err != nil {
+		return err
+	}
+	return validateFilter(view.Definition.Filter)
+}
+
+// DeleteView deletes view, which must belong to owner.
+func (s *Service) DeleteView(view *models.SavedView, owner string) error {
+	if view.Owner != owner {
+		return ErrNotViewOwner
+	}
+	if err := s.views.Delete(view); err != nil {
+		return err
+	}
+	s.events.touch()
+	return nil
+}
+
+// ViewTasks lists the tasks of view as ListTasks does for its filter, and
+// groups them.
+func (s *Service) ViewTasks(view models.SavedView) (models.ViewTasks, error) {
+	def := view.Definition
+	tasks, err := s.ListTasks(def.Filter)
+	if err != nil {
+		return models.ViewTasks{}, err
+	}
+	result := models.ViewTasks{GroupBy: def.GroupBy, Groups: []models.TaskGroup{}}
+	if def.GroupBy == "" {
+		if len(tasks) > 0 {
+			result.Groups = append(result.Groups, models.TaskGroup{Name: "", Tasks: tasks})
+		}
+		return result, nil
+	}
+
+	byName := make(map[string]*models.TaskGroup)
+	var names []string
+	for _, task := range tasks {
+		name := groupName(task, def.GroupBy)
+		group, ok := byName[name]
+		if !ok {
+			group = &models.TaskGroup{Name: name}
+			byName[name] = group
+			names = append(names, name)
+		}
+		group.Tasks = append(group.Tasks, task)
+	}
+	rank := groupRanks[def.GroupBy]
+	sort.Slice(names, func(i, j int) bool {
+		if rank != nil {
+			ri, oki := rank[names[i]]
+			rj, okj := rank[names[j]]
+			if oki != okj {
+				return oki
+			}
+			if ri != rj {
+				return ri < rj
+			}
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		result.Groups = append(result.Groups, *byName[name])
+	}
+	return result, nil
+}
+
+// groupRanks orders the groups of fields with a natural order; others are
+// in alphabetical order.
+var groupRanks = map[string]map[string]int{
+	"priority": {"High": 0, "Medium": 1, "Low": 2},
+	"status":   {"pending": 0, "completed": 1},
+}
+
+func groupName(task models.Task, groupBy string) string {
+	switch groupBy {
+	case "priority":
+		return task.Priority
+	case "assignee":
+		return task.Assignee
+	case "status":
+		if task.Done {
+			return "completed"
+		}
+		return "pending"
+	}
+	return ""
+}
@@ -0,0 +1,314 @@
+package taskquery
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokMinus
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	col   int
+	width int
+}
+
+// operators start a comparison. Two-character ones come first so that <=
+// is not read as <.
+var operators = []string{"<=", ">=", ":", "=", "<", ">"}
+
+// special ends a bare word.
+func special(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("\"():<>=", r)
+}
+
+// lex splits q into tokens. A - negates only at the start of a term, so
+// that due<-3d keeps it in the value.
+func lex(q string) ([]token, error) {
+	runes := []rune(q)
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r, col := runes[i], i+1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			kind := tokLParen
+			if r == ')' {
+				kind = tokRParen
+			}
+			tokens = append(tokens, token{kind, string(r), col, 1})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, &SyntaxError{Column: col, Code: ErrUnterminatedString}
+			}
+			tokens = append(tokens, token{tokString, string(runes[i+1 : end]), col, end + 1 - i})
+			i = end + 1
+		case strings.ContainsRune(":<>=", r):
+			op := string(r)
+			for _, o := range operators {
+				if strings.HasPrefix(string(runes[i:min(i+2, len(runes))]), o) {
+					op = o
+					break
+				}
+			}
+			tokens = append(tokens, token{tokOp, op, col, len(op)})
+			i += len(op)
+		case r == '-' && (i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '(' || runes[i-1] == '-'):
+			tokens = append(tokens, token{tokMinus, "-", col, 1})
+			i++
+		default:
+			end := i
+			for end < len(runes) && !special(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, token{tokWord, string(runes[i:end]), col, end - i})
+			i = end
+		}
+	}
+	return append(tokens, token{kind: tokEOF, col: len(runes) + 1}), nil
+}
+
+// Parse parses q into its syntax tree. An empty or blank q gives a nil
+// Expr, which matches every task.
+func Parse(q string) (Expr, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, nil
+	}
+	expr, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &SyntaxError{Column: t.col, Code: ErrUnexpectedToken, Near: t.text}
+	}
+	return expr, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the bare word kw.
+func (p *parser) keyword(kw string) bool {
+	t := p.peek()
+	return t.kind == tokWord && t.text == kw
+}
+
+// or := and ("OR" and)*
+func (p *parser) or() (Expr, error) {
+	var terms []Expr
+	for {
+		term, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if !p.keyword("OR") {
+			break
+		}
+		p.next()
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return &Or{Terms: terms}, nil
+}
+
+// and := unary (["AND"] unary)*
+func (p *parser) and() (Expr, error) {
+	var terms []Expr
+	for {
+		term, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if p.keyword("AND") {
+			p.next()
+			continue
+		}
+		if t := p.peek(); t.kind == tokEOF || t.kind == tokRParen || p.keyword("OR") {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return &And{Terms: terms}, nil
+}
+
+// unary := ("-" | "NOT") unary | primary
+func (p *parser) unary() (Expr, error) {
+	if t := p.peek(); t.kind == tokMinus || p.keyword("NOT") {
+		p.next()
+		term, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{At: t.col, Term: term}, nil
+	}
+	return p.primary()
+}
+
+// primary := "(" or ")" | string | word [op value]
+func (p *parser) primary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokEOF:
+		return nil, &SyntaxError{Column: t.col, Code: ErrUnexpectedEnd}
+	case tokLParen:
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, &SyntaxError{Column: t.col, Code: ErrUnclosedParen}
+		}
+		return expr, nil
+	case tokString:
+		return &Text{At: t.col, Value: t.text}, nil
+	case tokWord:
+		if op := p.peek(); op.kind == tokOp && op.col == t.col+t.width {
+			p.next()
+			return p.field(t, op)
+		}
+		switch t.text {
+		case FlagDone, FlagPending, FlagOverdue:
+			return &Flag{At: t.col, Name: t.text}, nil
+		case "OR", "AND":
+			return nil, &SyntaxError{Column: t.col, Code: ErrUnexpectedToken, Near: t.text}
+		}
+		return &Text{At: t.col, Value: t.text}, nil
+	}
+	return nil, &SyntaxError{Column: t.col, Code: ErrUnexpectedToken, Near: t.text}
+}
+
+// timeFields are compared with a Moment, matchFields with a list of
+// values.
+var (
+	timeFields  = map[string]bool{"due": true, "created": true, "updated": true}
+	matchFields = map[string]bool{"priority": true, "assignee": true, "status": true, "title": true, "has": true}
+	priorities  = map[string]string{"low": "Low", "medium": "Medium", "high": "High"}
+)
+
+// field parses the value of the field term name op, which must follow op
+// without a space.
+func (p *parser) field(name, op token) (Expr, error) {
+	v := p.peek()
+	if (v.kind != tokWord && v.kind != tokString) || v.col != op.col+op.width {
+		return nil, &SyntaxError{Column: op.col, Code: ErrMissingValue, Near: name.text + op.text}
+	}
+	p.next()
+	invalid := &SyntaxError{Column: v.col, Code: ErrInvalidValue, Near: v.text}
+
+	if timeFields[name.text] {
+		m, ok := parseMoment(v.text)
+		if !ok || v.kind == tokString {
+			return nil, invalid
+		}
+		if (op.text == ":" || op.text == "=") && m.Date == "" {
+			return nil, &SyntaxError{Column: op.col, Code: ErrInvalidOperator, Near: op.text}
+		}
+		return &Compare{At: name.col, Field: name.text, Op: op.text, Moment: m}, nil
+	}
+	if !matchFields[name.text] {
+		return nil, &SyntaxError{Column: name.col, Code: ErrUnknownField, Near: name.text}
+	}
+	if op.text != ":" && op.text != "=" {
+		return nil, &SyntaxError{Column: op.col, Code: ErrInvalidOperator, Near: op.text}
+	}
+
+	// Titles are searched for as a whole; other fields take a list.
+	values := []string{v.text}
+	if v.kind == tokWord && name.text != "title" {
+		values = strings.Split(v.text, ",")
+	}
+	for i, value := range values {
+		switch name.text {
+		case "priority":
+			value = priorities[strings.ToLower(value)]
+		case "status":
+			if value == FlagDone {
+				value = "completed"
+			}
+			if value != "completed" && value != "pending" {
+				return nil, invalid
+			}
+		case "has":
+			if value != "due" && value != "assignee" && value != "description" {
+				return nil, invalid
+			}
+		}
+		if value == "" {
+			return nil, invalid
+		}
+		values[i] = value
+	}
+	return &Match{At: name.col, Field: name.text, Values: values}, nil
+}
+
+var (
+	durationPattern = regexp.MustCompile(`^([+-]?\d+)([hdw])$`)
+	datePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	units           = map[string]time.Duration{"h": time.Hour, "d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+)
+
+func parseMoment(s string) (Moment, bool) {
+	switch {
+	case s == "today" || s == "tomorrow" || s == "yesterday":
+		return Moment{Date: s}, true
+	case datePattern.MatchString(s):
+		_, err := time.Parse("2006-01-02", s)
+		return Moment{Date: s}, err == nil
+	}
+	m := durationPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Moment{}, false
+	}
+	// Offsets beyond time.Duration's ±292 years would wrap around.
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	unit := units[m[2]]
+	if err != nil || n > math.MaxInt64/int64(unit) || n < -math.MaxInt64/int64(unit) {
+		return Moment{}, false
+	}
+	return Moment{Offset: time.Duration(n) * unit}, true
+}
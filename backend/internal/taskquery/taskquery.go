@@ -0,0 +1,212 @@
+// Package taskquery parses the compact task query language accepted as q
+// by the task list, such as
+//
+//	priority:High,Medium assignee:Alice due<7d -done "landing page"
+//
+// Terms separated by spaces must all match; OR between terms matches
+// either, binds looser than the spaces, and parentheses group. A leading -
+// or NOT negates a term. The terms are:
+//
+//	word, "quoted text"    the title or description contains the text
+//	done, pending          the task is (not) done
+//	overdue                the task is open and past its deadline
+//	priority:High,Low      the priority is one of these, in any case
+//	assignee:Alice,Bob     the task is assigned to one of these people
+//	status:completed       completed or pending, as the status parameter
+//	title:text             the title contains the text
+//	has:due                the task has a due date, assignee or description
+//	due<7d, created>=-2w   the time compares with a moment, see Moment
+//
+// Quote keywords and text with spaces or the characters :<>=() to search
+// for them. Parse reports mistakes as a *SyntaxError with their column.
+// Translating the tree into a database query is left to the repositories.
+package taskquery
+
+import (
+	"fmt"
+	"time"
+)
+
+// Expr is a node of a parsed query. Its concrete type is one of *And, *Or,
+// *Not, *Text, *Flag, *Match and *Compare.
+type Expr interface {
+	// Column is where the node starts in the query, counting runes from 1.
+	Column() int
+}
+
+// And matches when all of its terms do.
+type And struct {
+	Terms []Expr
+}
+
+// Or matches when any of its terms does.
+type Or struct {
+	Terms []Expr
+}
+
+// Not matches when its term does not.
+type Not struct {
+	At   int
+	Term Expr
+}
+
+// Text matches tasks whose title or description contains Value, ignoring
+// case.
+type Text struct {
+	At    int
+	Value string
+}
+
+// Flags of a task, written as bare keywords.
+const (
+	FlagDone    = "done"
+	FlagPending = "pending"
+	FlagOverdue = "overdue"
+)
+
+// Flag matches tasks with one of the Flag* states.
+type Flag struct {
+	At   int
+	Name string
+}
+
+// Match compares a field with a list of values using ":". Values are
+// canonical: priorities are capitalized as stored, and the status is
+// completed or pending.
+type Match struct {
+	At     int
+	Field  string
+	Values []string
+}
+
+// Compare matches tasks whose time Field (due, created or updated) falls
+// in Interval. Tasks without the time never match.
+type Compare struct {
+	At     int
+	Field  string
+	Op     string
+	Moment Moment
+}
+
+func (e *And) Column() int     { return e.Terms[0].Column() }
+func (e *Or) Column() int      { return e.Terms[0].Column() }
+func (e *Not) Column() int     { return e.At }
+func (e *Text) Column() int    { return e.At }
+func (e *Flag) Column() int    { return e.At }
+func (e *Match) Column() int   { return e.At }
+func (e *Compare) Column() int { return e.At }
+
+// Moment is the right-hand side of a time comparison: a duration from now,
+// such as 7d, -2w or 12h, or a day, such as today, tomorrow, yesterday or
+// 2030-01-31. Days start at midnight in the location of now.
+type Moment struct {
+	// Offset from now, when Date is empty.
+	Offset time.Duration
+	// Date is a day as YYYY-MM-DD, or today, tomorrow or yesterday.
+	Date string
+}
+
+// span returns the moment as the half-open range [start, end), which is
+// empty for a duration.
+func (m Moment) span(now time.Time) (start, end time.Time) {
+	if m.Date == "" {
+		t := now.Add(m.Offset)
+		return t, t
+	}
+	loc := now.Location()
+	var day time.Time
+	switch m.Date {
+	case "today", "tomorrow", "yesterday":
+		y, mo, d := now.Date()
+		day = time.Date(y, mo, d+map[string]int{"yesterday": -1, "today": 0, "tomorrow": 1}[m.Date], 0, 0, 0, 0, loc)
+	default:
+		day, _ = time.ParseInLocation("2006-01-02", m.Date, loc)
+	}
+	return day, day.AddDate(0, 0, 1)
+}
+
+// Interval returns the times matched by c as the half-open range
+// [from, to); a zero from or to leaves that side open. Against a duration
+// <= is the same as <, and > the same as >=.
+func (c *Compare) Interval(now time.Time) (from, to time.Time) {
+	start, end := c.Moment.span(now)
+	switch c.Op {
+	case "<":
+		return time.Time{}, start
+	case "<=":
+		return time.Time{}, end
+	case ">":
+		return end, time.Time{}
+	case ">=":
+		return start, time.Time{}
+	}
+	return start, end
+}
+
+// DependsOnClock reports whether the tasks expr matches can change with the
+// time alone: it uses overdue, a duration or a day relative to today. A nil
+// expr does not.
+func DependsOnClock(expr Expr) bool {
+	switch e := expr.(type) {
+	case *And:
+		return anyDependsOnClock(e.Terms)
+	case *Or:
+		return anyDependsOnClock(e.Terms)
+	case *Not:
+		return DependsOnClock(e.Term)
+	case *Flag:
+		return e.Name == FlagOverdue
+	case *Compare:
+		return e.Moment.Date == "" || e.Moment.Date == "today" || e.Moment.Date == "tomorrow" || e.Moment.Date == "yesterday"
+	}
+	return false
+}
+
+func anyDependsOnClock(terms []Expr) bool {
+	for _, term := range terms {
+		if DependsOnClock(term) {
+			return true
+		}
+	}
+	return false
+}
+
+// Codes of a SyntaxError. They double as the suffix of the message keys of
+// the API catalogues.
+const (
+	ErrUnterminatedString = "unterminated_string"
+	ErrUnexpectedEnd      = "unexpected_end"
+	ErrUnexpectedToken    = "unexpected_token"
+	ErrUnclosedParen      = "unclosed_paren"
+	ErrUnknownField       = "unknown_field"
+	ErrInvalidOperator    = "invalid_operator"
+	ErrInvalidValue       = "invalid_value"
+	ErrMissingValue       = "missing_value"
+)
+
+var messages = map[string]string{
+	ErrUnterminatedString: "quoted text is not closed",
+	ErrUnexpectedEnd:      "the query ends too early",
+	ErrUnexpectedToken:    "unexpected %s",
+	ErrUnclosedParen:      "( is never closed",
+	ErrUnknownField:       "unknown field %s",
+	ErrInvalidOperator:    "%s does not apply to this field or value",
+	ErrInvalidValue:       "invalid value %s",
+	ErrMissingValue:       "missing value after %s",
+}
+
+// SyntaxError is a mistake in a query at Column, counting runes from 1.
+// Near is the offending text, if any.
+type SyntaxError struct {
+	Column int
+	Code   string
+	Near   string
+}
+
+func (e *SyntaxError) Error() string {
+	msg := messages[e.Code]
+	if e.Near != "" {
+		msg = fmt.Sprintf(msg, e.Near)
+	}
+	return fmt.Sprintf("task query: column %d: %s", e.Column, msg)
+}
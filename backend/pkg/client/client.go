@@ -0,0 +1,265 @@
+// Package client is a typed Go client for the todo REST API.
+//
+//	c := client.New("http://localhost:8080", client.WithTimeout(5*time.Second))
+//	tasks, err := c.ListTasks(ctx, client.ListTasksOptions{Status: client.StatusPending})
+package client
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout    = 10 * time.Second
+	defaultMaxRetries = 2
+	defaultBackoff    = 200 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+)
+
+// Client talks to one todo server. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	userAgent  string
+	language   string
+	maxRetries int
+	backoff    time.Duration
+	retryPOST  bool
+	sleep      func(context.Context, time.Duration) error
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the underlying HTTP client. Its Timeout is left
+// untouched unless WithTimeout is also given.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithTimeout bounds every attempt of a request, including reading the body.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		hc := *c.httpClient
+		hc.Timeout = d
+		c.httpClient = &hc
+	}
+}
+
+// WithToken sends token as a bearer token on every request.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithLanguage sends lang as Accept-Language, so that error messages come
+// back in that language when the server has a catalogue for it.
+func WithLanguage(lang string) Option {
+	return func(c *Client) { c.language = lang }
+}
+
+// WithRetries sets how many times a failed request is retried and the initial
+// backoff, which doubles (with jitter) after every attempt. Only network
+// errors, 429 and 5xx responses are retried, and POST requests only when
+// WithRetryPOST is given, since they are not idempotent. Every attempt of a
+// POST carries the same Idempotency-Key, so servers that support it carry
+// the request out only once.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+func WithRetryPOST() Option {
+	return func(c *Client) { c.retryPOST = true }
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		userAgent:  "todo-go-client",
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is returned for every non-2xx response. The server describes
+// errors as RFC 7807 problem details; Message carries their detail.
+type APIError struct {
+	StatusCode int
+	Message    string
+	// Code is the server's stable error code, such as "task_not_found".
+	Code      string
+	RequestID string
+	// Fields lists the rejected fields of a "validation_failed" error.
+	Fields []FieldError
+}
+
+// FieldError describes why the server rejected one input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("todo API: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends the request, retrying according to the client's policy, and
+// decodes a JSON response into out unless out is nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("todo API: encoding request: %w", err)
+		}
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	retries := c.maxRetries
+	var idempotencyKey string
+	if method == http.MethodPost {
+		if !c.retryPOST {
+			retries = 0
+		}
+		idempotencyKey = newIdempotencyKey()
+	}
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, method, u, idempotencyKey, body, out)
+		if err == nil || attempt >= retries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		// Up to 50% jitter so retries from many clients spread out.
+		wait := delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+		if delay *= 2; delay > maxBackoff {
+			delay = maxBackoff
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, u, idempotencyKey string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.language != "" {
+		req.Header.Set("Accept-Language", c.language)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var problem struct {
+			Detail    string       `json:"detail"`
+			Code      string       `json:"code"`
+			RequestID string       `json:"request_id"`
+			Errors    []FieldError `json:"errors"`
+		}
+		if json.Unmarshal(data, &problem) == nil && problem.Code != "" {
+			apiErr.Message = problem.Detail
+			apiErr.Code = problem.Code
+			apiErr.RequestID = problem.RequestID
+			apiErr.Fields = problem.Errors
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return apiErr
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("todo API: decoding response: %w", err)
+	}
+	return nil
+}
+
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		// A 409 idempotency_key_in_use means an earlier attempt is still
+		// running; the retry gets its response once it is done.
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500 ||
+			apiErr.Code == "idempotency_key_in_use"
+	}
+	// Anything else is a transport error.
+	return !errors.Is(err, context.Canceled)
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := crand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Subtask struct {
+	ID        uint      `json:"id"`
+	TaskID    uint      `json:"task_id"`
+	Title     string    `json:"title"`
+	Done      bool      `json:"done"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SubtaskInput struct {
+	Title string `json:"title"`
+}
+
+func subtaskPath(id uint) string {
+	return "/subtasks/" + strconv.FormatUint(uint64(id), 10)
+}
+
+func (c *Client) ListSubtasks(ctx context.Context, taskID uint) ([]Subtask, error) {
+	var subtasks []Subtask
+	err := c.do(ctx, http.MethodGet, taskPath(taskID)+"/subtasks", nil, nil, &subtasks)
+	return subtasks, err
+}
+
+func (c *Client) CreateSubtask(ctx context.Context, taskID uint, in SubtaskInput) (*Subtask, error) {
+	var subtask Subtask
+	if err := c.do(ctx, http.MethodPost, taskPath(taskID)+"/subtasks", nil, in, &subtask); err != nil {
+		return nil, err
+	}
+	return &subtask, nil
+}
+
+func (c *Client) UpdateSubtask(ctx context.Context, id uint, in SubtaskInput) (*Subtask, error) {
+	var subtask Subtask
+	if err := c.do(ctx, http.MethodPut, subtaskPath(id), nil, in, &subtask); err != nil {
+		return nil, err
+	}
+	return &subtask, nil
+}
+
+func (c *Client) SetSubtaskDone(ctx context.Context, id uint, done bool) (*Subtask, error) {
+	var subtask Subtask
+	body := map[string]bool{"done": done}
+	if err := c.do(ctx, http.MethodPatch, subtaskPath(id)+"/done", nil, body, &subtask); err != nil {
+		return nil, err
+	}
+	return &subtask, nil
+}
+
+func (c *Client) DeleteSubtask(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, subtaskPath(id), nil, nil, nil)
+}
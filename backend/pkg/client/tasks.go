@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Priority string
+
+const (
+	PriorityLow    Priority = "Low"
+	PriorityMedium Priority = "Medium"
+	PriorityHigh   Priority = "High"
+)
+
+type Task struct {
+	ID          uint     `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Priority    Priority `json:"priority"`
+	Assignee    string   `json:"assignee"`
+	// DueDate is nil for tasks without a due date. Date-only deadlines
+	// (DueAllDay) are due at the midnight starting their day in
+	// DueTimezone.
+	DueDate     *time.Time `json:"due_date"`
+	DueAllDay   bool       `json:"due_all_day"`
+	DueTimezone string     `json:"due_timezone"`
+	// OverdueAt is when the task becomes overdue, nil without a due date.
+	OverdueAt *time.Time `json:"overdue_at"`
+	Done      bool       `json:"done"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Subtasks  []Subtask  `json:"subtasks"`
+}
+
+// TaskInput holds the fields accepted by CreateTask and UpdateTask. Title and
+// Priority are required by the server.
+type TaskInput struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Priority    Priority `json:"priority"`
+	Assignee    string   `json:"assignee,omitempty"`
+	// DueDate is nil for no due date. With DueAllDay only its day in
+	// DueTimezone, an IANA name that defaults to UTC, counts.
+	DueDate     *time.Time `json:"due_date"`
+	DueAllDay   bool       `json:"due_all_day,omitempty"`
+	DueTimezone string     `json:"due_timezone,omitempty"`
+}
+
+type Status string
+
+const (
+	StatusCompleted Status = "completed"
+	StatusPending   Status = "pending"
+)
+
+type SortBy string
+
+const (
+	SortByDueDate  SortBy = "dueDate"
+	SortByPriority SortBy = "priority"
+)
+
+// ListTasksOptions mirrors the query parameters of GET /tasks. Zero fields
+// are not sent.
+type ListTasksOptions struct {
+	Assignee string
+	Search   string
+	Status   Status
+	SortBy   SortBy
+	// Query is a task query, such as priority:High -done due<7d.
+	Query string
+}
+
+func (o ListTasksOptions) values() url.Values {
+	q := url.Values{}
+	if o.Assignee != "" {
+		q.Set("assignee", o.Assignee)
+	}
+	if o.Search != "" {
+		q.Set("search", o.Search)
+	}
+	if o.Status != "" {
+		q.Set("status", string(o.Status))
+	}
+	if o.SortBy != "" {
+		q.Set("sortBy", string(o.SortBy))
+	}
+	if o.Query != "" {
+		q.Set("q", o.Query)
+	}
+	return q
+}
+
+func taskPath(id uint) string {
+	return "/tasks/" + strconv.FormatUint(uint64(id), 10)
+}
+
+func (c *Client) ListTasks(ctx context.Context, opts ListTasksOptions) ([]Task, error) {
+	var tasks []Task
+	err := c.do(ctx, http.MethodGet, "/tasks", opts.values(), nil, &tasks)
+	return tasks, err
+}
+
+func (c *Client) GetTask(ctx context.Context, id uint) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodGet, taskPath(id), nil, nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) CreateTask(ctx context.Context, in TaskInput) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodPost, "/tasks", nil, in, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) UpdateTask(ctx context.Context, id uint, in TaskInput) (*Task, error) {
+	var task Task
+	if err := c.do(ctx, http.MethodPut, taskPath(id), nil, in, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) SetTaskDone(ctx context.Context, id uint, done bool) (*Task, error) {
+	var task Task
+	body := map[string]bool{"done": done}
+	if err := c.do(ctx, http.MethodPatch, taskPath(id)+"/done", nil, body, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) DeleteTask(ctx context.Context, id uint) error {
+	return c.do(ctx, http.MethodDelete, taskPath(id), nil, nil, nil)
+}
@@ -0,0 +1,27 @@
+#!/bin/sh
+# Runs the test suite against every database driver with an instance
+# available. SQLite always runs; MySQL and PostgreSQL run when
+# TEST_MYSQL_DSN / TEST_POSTGRES_DSN point at a scratch database, e.g.
+#
+#   TEST_MYSQL_DSN='root:@tcp(localhost:3306)/todo_test?parseTime=True' \
+#   TEST_POSTGRES_DSN='host=localhost user=postgres dbname=todo_test sslmode=disable' \
+#   scripts/test-all-drivers.sh
+set -e
+cd "$(dirname "$0")/.."
+
+echo "== sqlite"
+TEST_DB_DRIVER= go test -count=1 ./...
+
+if [ -n "$TEST_MYSQL_DSN" ]; then
+  echo "== mysql"
+  TEST_DB_DRIVER=mysql TEST_DB_DSN="$TEST_MYSQL_DSN" go test -count=1 -p 1 ./...
+else
+  echo "== mysql (skipped, TEST_MYSQL_DSN not set)"
+fi
+
+if [ -n "$TEST_POSTGRES_DSN" ]; then
+  echo "== postgres"
+  TEST_DB_DRIVER=postgres TEST_DB_DSN="$TEST_POSTGRES_DSN" go test -count=1 -p 1 ./...
+else
+  echo "== postgres (skipped, TEST_POSTGRES_DSN not set)"
+fi
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo/internal/handlers"
+	"todo/internal/models"
+)
+
+func TestAgenda(t *testing.T) {
+	for name, newService := range repositoryBackends {
+		t.Run(name, func(t *testing.T) {
+			svc := newService()
+			ny, _ := time.LoadLocation("America/New_York")
+			// A Wednesday noon in New York, 17:00 UTC.
+			now := time.Date(2030, 1, 2, 12, 0, 0, 0, ny)
+
+			for _, task := range []models.Task{
+				{Title: "Overdue", Priority: "High", Assignee: "Alice", DueDate: dueAt(now.Add(-time.Hour))},
+				{Title: "Tonight", Priority: "Low", DueDate: dueAt(time.Date(2030, 1, 2, 23, 30, 0, 0, ny))},
+				{Title: "Tomorrow", Priority: "Low", DueDate: dueAt(time.Date(2030, 1, 3, 9, 0, 0, 0, ny))},
+				{Title: "Saturday", Priority: "Low", Assignee: "Alice", DueDate: dueAt(time.Date(2030, 1, 5, 10, 0, 0, 0, ny))},
+				{Title: "Monday", Priority: "Low", DueDate: dueAt(time.Date(2030, 1, 7, 0, 0, 0, 0, ny))},
+				{Title: "Someday", Priority: "Low", Assignee: "Alice",
+					Subtasks: []models.Subtask{{Title: "Started", Done: true}, {Title: "Open"}}},
+				{Title: "Done", Priority: "Low", Done: true, DueDate: dueAt(now)},
+			} {
+				if err := svc.CreateTask(&task); err != nil {
+					t.Fatalf("CreateTask failed: %v", err)
+				}
+			}
+
+			agenda, err := svc.Agenda(models.AgendaQuery{TZ: "America/New_York"}, now)
+			if err != nil {
+				t.Fatalf("Agenda failed: %v", err)
+			}
+			expected := map[string]string{
+				models.BucketOverdue:  "Overdue",
+				models.BucketToday:    "Tonight",
+				models.BucketTomorrow: "Tomorrow",
+				models.BucketThisWeek: "Saturday",
+				models.BucketLater:    "Monday",
+				models.BucketNoDate:   "Someday",
+			}
+			if agenda.Date != "2030-01-02" || agenda.TZ != "America/New_York" || len(agenda.Buckets) != len(models.AgendaBuckets) {
+				t.Fatalf("Unexpected agenda %+v", agenda)
+			}
+			for i, bucket := range agenda.Buckets {
+				if bucket.Name != models.AgendaBuckets[i] || len(bucket.Tasks) != 1 || bucket.Tasks[0].Title != expected[bucket.Name] {
+					t.Errorf("Expected %s in %s, got %+v", expected[bucket.Name], models.AgendaBuckets[i], bucket)
+				}
+			}
+			if noDate := agenda.Buckets[5]; noDate.OpenSubtasks != 1 || noDate.Tasks[0].Subtasks[0].Title != "Open" {
+				t.Errorf("Expected only the open subtask, got %+v", noDate)
+			}
+
+			// In UTC it is already tomorrow where tonight's task is due.
+			agenda, err = svc.Agenda(models.AgendaQuery{Bucket: models.BucketTomorrow}, now)
+			if err != nil || len(agenda.Buckets) != 1 || len(agenda.Buckets[0].Tasks) != 2 || agenda.Buckets[0].Tasks[0].Title != "Tonight" {
+				t.Errorf("Expected both tasks tomorrow in UTC, got %+v, %v", agenda.Buckets, err)
+			}
+
+			agenda, err = svc.Agenda(models.AgendaQuery{Assignee: "Alice", TZ: "America/New_York"}, now)
+			if err != nil {
+				t.Fatalf("Agenda failed: %v", err)
+			}
+			count := 0
+			for _, bucket := range agenda.Buckets {
+				count += len(bucket.Tasks)
+			}
+			if count != 3 || len(agenda.Buckets[1].Tasks) != 0 {
+				t.Errorf("Expected Alice's 3 tasks, got %+v", agenda.Buckets)
+			}
+		})
+	}
+}
+
+func TestGetAgenda(t *testing.T) {
+	app, db := setupTestApp()
+	db.Create(&models.Task{Title: "Undated", Priority: "Low"})
+	db.Create(&models.Task{Title: "Overdue", Priority: "Low", DueDate: dueAt(time.Now().Add(-time.Hour))})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/agenda/no-date?tz=Europe/Berlin", nil))
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	var agenda models.Agenda
+	if err := json.NewDecoder(resp.Body).Decode(&agenda); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || len(agenda.Buckets) != 1 || len(agenda.Buckets[0].Tasks) != 1 || agenda.Buckets[0].Tasks[0].Title != "Undated" {
+		t.Errorf("Expected the undated task, got %d %+v", resp.StatusCode, agenda)
+	}
+
+	for _, path := range []string{"/agenda/someday", "/agenda?tz=Mars/Olympus"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		var p handlers.Problem
+		if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || resp.StatusCode != http.StatusBadRequest || p.Code != handlers.CodeValidation {
+			t.Errorf("%s: expected a validation problem, got %d %+v", path, resp.StatusCode, p)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo/internal/handlers"
+	"todo/internal/models"
+	"todo/internal/repository"
+	"todo/internal/services"
+)
+
+func TestTaskChangesAreRecorded(t *testing.T) {
+	for name, newService := range repositoryBackends {
+		t.Run(name, func(t *testing.T) {
+			svc := newService()
+			open := models.Task{Title: "Open", Priority: "Low", Assignee: "Alice"}
+			done := models.Task{Title: "Done", Priority: "Low", Done: true}
+			for _, task := range []*models.Task{&open, &done} {
+				if err := svc.CreateTask(task); err != nil {
+					t.Fatalf("CreateTask failed: %v", err)
+				}
+			}
+			for _, d := range []bool{true, true, false, true} {
+				if err := svc.SetTaskDone(&open, d); err != nil {
+					t.Fatalf("SetTaskDone failed: %v", err)
+				}
+			}
+			if err := svc.DeleteTask(&done); err != nil {
+				t.Fatalf("DeleteTask failed: %v", err)
+			}
+
+			burndown, err := svc.Burndown(models.AnalyticsQuery{}, time.Now())
+			if err != nil || len(burndown.Points) != 30 {
+				t.Fatalf("Expected 30 days of burndown, got %+v, %v", burndown, err)
+			}
+			// Created twice and reopened once; completed three times, as
+			// setting done twice in a row is no change.
+			if today := burndown.Points[29]; today.Open != 0 || today.Opened != 3 || today.Closed != 3 {
+				t.Errorf("Unexpected burndown for today %+v", today)
+			}
+			throughput, err := svc.Throughput(models.AnalyticsQuery{}, time.Now())
+			if err != nil || len(throughput.Series) != 2 || throughput.Series[0].Total != 1 || throughput.Series[1].Assignee != "Alice" || throughput.Series[1].Total != 2 {
+				t.Errorf("Unexpected throughput %+v, %v", throughput.Series, err)
+			}
+		})
+	}
+}
+
+// recordChanges stores a task history directly, at chosen times.
+func recordChanges(t *testing.T, svc *services.Service, changes []models.TaskChange) {
+	t.Helper()
+	db := openTestDB()
+	if err := db.Create(&changes).Error; err != nil {
+		t.Fatalf("Failed to record changes: %v", err)
+	}
+	*svc = *services.New(repository.NewGormTaskRepository(db), repository.NewGormSubtaskRepository(db), repository.NewGormViewRepository(db))
+}
+
+func TestAnalytics(t *testing.T) {
+	var svc services.Service
+	// Monday 7 January 2030, in UTC.
+	day := func(d, hour int) time.Time { return time.Date(2030, 1, 7+d, hour, 0, 0, 0, time.UTC) }
+	recordChanges(t, &svc, []models.TaskChange{
+		{TaskID: 1, Kind: models.ChangeCreated, OpenDelta: 1, OccurredAt: day(-3, 9)},
+		{TaskID: 2, Kind: models.ChangeCreated, Assignee: "Alice", OpenDelta: 1, OccurredAt: day(0, 9)},
+		{TaskID: 3, Kind: models.ChangeCreated, Assignee: "Bob", OpenDelta: 1, OccurredAt: day(0, 10)},
+		{TaskID: 2, Kind: models.ChangeCompleted, Assignee: "Alice", OpenDelta: -1, OccurredAt: day(1, 9)},
+		{TaskID: 2, Kind: models.ChangeReopened, Assignee: "Alice", OpenDelta: 1, OccurredAt: day(2, 9)},
+		{TaskID: 2, Kind: models.ChangeCompleted, Assignee: "Alice", OpenDelta: -1, OccurredAt: day(2, 21)},
+		{TaskID: 3, Kind: models.ChangeCompleted, Assignee: "Bob", OpenDelta: -1, OccurredAt: day(7, 10)},
+		{TaskID: 1, Kind: models.ChangeDeleted, OpenDelta: -1, OccurredAt: day(8, 23)},
+	})
+	q := models.AnalyticsQuery{From: "2030-01-07", To: "2030-01-15"}
+
+	burndown, err := svc.Burndown(q, time.Now())
+	if err != nil {
+		t.Fatalf("Burndown failed: %v", err)
+	}
+	expectedOpen := []int64{3, 2, 2, 2, 2, 2, 2, 1, 0}
+	if len(burndown.Points) != len(expectedOpen) || burndown.From != "2030-01-07" || burndown.TZ != "UTC" {
+		t.Fatalf("Unexpected burndown %+v", burndown)
+	}
+	for i, p := range burndown.Points {
+		if p.Open != expectedOpen[i] {
+			t.Errorf("Expected %d open tasks on %s, got %d", expectedOpen[i], p.Date, p.Open)
+		}
+	}
+	if p := burndown.Points[2]; p.Opened != 1 || p.Closed != 1 {
+		t.Errorf("Expected a reopening and a completion on %s, got %+v", p.Date, p)
+	}
+
+	// Nine hours east, the deletion at 23:00 UTC falls on the next day.
+	q.TZ = "Asia/Tokyo"
+	burndown, err = svc.Burndown(q, time.Now())
+	if err != nil || burndown.Points[8].Open != 1 {
+		t.Errorf("Expected the deletion to move to the next Tokyo day, got %+v, %v", burndown.Points, err)
+	}
+	q.TZ = ""
+
+	throughput, err := svc.Throughput(q, time.Now())
+	if err != nil {
+		t.Fatalf("Throughput failed: %v", err)
+	}
+	if len(throughput.Weeks) != 2 || throughput.Weeks[1] != "2030-01-14" || len(throughput.Series) != 2 {
+		t.Fatalf("Unexpected throughput %+v", throughput)
+	}
+	if alice, bob := throughput.Series[0], throughput.Series[1]; alice.Assignee != "Alice" || alice.Completed[0] != 2 || alice.Completed[1] != 0 ||
+		bob.Completed[0] != 0 || bob.Completed[1] != 1 {
+		t.Errorf("Unexpected throughput series %+v", throughput.Series)
+	}
+
+	times, err := svc.CycleTimes(q, time.Now())
+	if err != nil {
+		t.Fatalf("CycleTimes failed: %v", err)
+	}
+	hours := func(h float64) float64 { return h * 3600 }
+	// Lead times of 24h, 60h and 168h; cycle times of 24h, 12h and 168h.
+	if times.Completed != 3 || times.LeadTime.P50 != hours(60) || times.LeadTime.Max != hours(168) ||
+		times.CycleTime.P50 != hours(24) || times.CycleTime.Mean != hours(68) || times.CycleTime.P95 != hours(168) {
+		t.Errorf("Unexpected cycle times %+v", times)
+	}
+}
+
+func TestAnalyticsRanges(t *testing.T) {
+	app, _ := setupTestApp()
+
+	for _, tt := range []struct {
+		query string
+		code  string
+	}{
+		{"from=2030-01-10&to=2030-01-09", handlers.CodeInvalidQuery},
+		{"from=2029-01-01&to=2030-01-09", handlers.CodeInvalidQuery},
+		{"from=10.01.2030", handlers.CodeValidation},
+		{"tz=Mars/Olympus", handlers.CodeValidation},
+	} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/analytics/burndown?"+tt.query, nil))
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		var p handlers.Problem
+		if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || resp.StatusCode != http.StatusBadRequest || p.Code != tt.code {
+			t.Errorf("%s: expected a 400 %s problem, got %d %+v", tt.query, tt.code, resp.StatusCode, p)
+		}
+	}
+
+	for _, path := range []string{"/analytics/burndown", "/analytics/throughput", "/analytics/cycle-time"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path+"?from=2030-01-01&to=2030-01-31&tz=Europe/Berlin", nil))
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		var report models.AnalyticsRange
+		if err := json.NewDecoder(resp.Body).Decode(&report); err != nil || resp.StatusCode != http.StatusOK || report.TZ != "Europe/Berlin" || report.To != "2030-01-31" {
+			t.Errorf("%s: unexpected response %d %+v", path, resp.StatusCode, report)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"todo/internal/handlers"
+	"todo/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func getWithHeaders(t *testing.T, app *fiber.App, path string, headers map[string]string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	return resp
+}
+
+func TestConditionalGet(t *testing.T) {
+	app, db := setupTestApp()
+	db.Create(&models.Task{Title: "Poll me", Priority: "Low", Subtasks: []models.Subtask{{Title: "Child"}}})
+
+	for _, path := range []string{"/tasks", "/tasks/1", "/tasks/1/subtasks"} {
+		t.Run(path, func(t *testing.T) {
+			resp := getWithHeaders(t, app, path, nil)
+			etag, modified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+			if resp.StatusCode != http.StatusOK || etag == "" || modified == "" {
+				t.Fatalf("Expected 200 with validators, got %d, ETag %q, Last-Modified %q", resp.StatusCode, etag, modified)
+			}
+			if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+				t.Errorf("Expected Cache-Control no-cache, got %q", cc)
+			}
+
+			for name, headers := range map[string]map[string]string{
+				"If-None-Match":      {"If-None-Match": etag},
+				"weak If-None-Match": {"If-None-Match": `"other", W/` + etag},
+				"If-Modified-Since":  {"If-Modified-Since": modified},
+			} {
+				resp := getWithHeaders(t, app, path, headers)
+				if resp.StatusCode != http.StatusNotModified || resp.Header.Get("ETag") != etag {
+					t.Errorf("%s: expected 304 with the same ETag, got %d", name, resp.StatusCode)
+				}
+			}
+			// If-None-Match wins over If-Modified-Since.
+			resp = getWithHeaders(t, app, path, map[string]string{"If-None-Match": `"stale"`, "If-Modified-Since": modified})
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Expected a mismatched ETag to get 200, got %d", resp.StatusCode)
+			}
+		})
+	}
+
+	resp := getWithHeaders(t, app, "/tasks/2", nil)
+	if resp.StatusCode != http.StatusNotFound || resp.Header.Get("ETag") != "" {
+		t.Errorf("Expected a 404 without ETag, got %d %q", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+}
+
+func TestConditionalGetAfterWrites(t *testing.T) {
+	app, db := setupTestApp()
+	db.Create(&models.Task{Title: "First", Priority: "Low", Subtasks: []models.Subtask{{Title: "Child"}}})
+	db.Create(&models.Task{Title: "Second", Priority: "Low"})
+
+	writes := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"update subtask", http.MethodPatch, "/subtasks/1/done", `{"done":true}`},
+		{"create subtask", http.MethodPost, "/tasks/1/subtasks", `{"title":"Another"}`},
+		{"delete subtask", http.MethodDelete, "/subtasks/2", ""},
+		{"delete other task", http.MethodDelete, "/tasks/2", ""},
+	}
+	etag := getWithHeaders(t, app, "/tasks", nil).Header.Get("ETag")
+	for _, w := range writes {
+		req := httptest.NewRequest(w.method, w.path, strings.NewReader(w.body))
+		req.Header.Set("Content-Type", "application/json")
+		if resp, err := app.Test(req); err != nil || resp.StatusCode >= 400 {
+			t.Fatalf("%s failed: %v", w.name, err)
+		}
+		resp := getWithHeaders(t, app, "/tasks", map[string]string{"If-None-Match": etag})
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("After %s: expected 200, got %d", w.name, resp.StatusCode)
+		}
+		etag = resp.Header.Get("ETag")
+	}
+}
+
+// newCachedTestApp returns the HTTP API with a response cache, and counts
+// the task queries that reach the database.
+func newCachedTestApp(t *testing.T, ttl time.Duration) (*fiber.App, *gorm.DB, *int) {
+	t.Helper()
+	svc, db := newTestService()
+	queries := 0
+	db.Callback().Query().After("gorm:query").Register("test:count_task_queries", func(tx *gorm.DB) {
+		if tx.Statement.Table == "tasks" {
+			queries++
+		}
+	})
+	app := handlers.NewApp(handlers.New(svc), nil, handlers.WithLogger(discardLogger), handlers.WithResponseCache(ttl))
+	return app, db, &queries
+}
+
+func TestResponseCache(t *testing.T) {
+	app, db, queries := newCachedTestApp(t, time.Hour)
+	db.Create(&models.Task{Title: "Cached", Priority: "Low"})
+
+	first, _ := io.ReadAll(getWithHeaders(t, app, "/tasks", nil).Body)
+	*queries = 0
+	for i := 0; i < 3; i++ {
+		if got, _ := io.ReadAll(getWithHeaders(t, app, "/tasks", nil).Body); string(got) != string(first) {
+			t.Errorf("Expected the cached body %s, got %s", first, got)
+		}
+	}
+	if resp := getWithHeaders(t, app, "/tasks?status=pending", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	if *queries != 1 {
+		t.Errorf("Expected only the new URL to be queried, got %d queries", *queries)
+	}
+
+	// Writes through the API invalidate the cache.
+	if resp := postJSON(t, app, "/tasks", `{"title":"Fresh","priority":"High"}`, nil); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected the task to be created, got %d", resp.StatusCode)
+	}
+	var tasks []models.Task
+	if err := json.NewDecoder(getWithHeaders(t, app, "/tasks", nil).Body).Decode(&tasks); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Expected the new task after a write, got %d tasks", len(tasks))
+	}
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	app, db, _ := newCachedTestApp(t, 50*time.Millisecond)
+	db.Create(&models.Task{Title: "Cached", Priority: "Low"})
+	getWithHeaders(t, app, "/tasks", nil)
+
+	// Writes that bypass the Service, as by another server, show up once
+	// the TTL has passed.
+	db.Create(&models.Task{Title: "Elsewhere", Priority: "Low"})
+	time.Sleep(100 * time.Millisecond)
+	var tasks []models.Task
+	if err := json.NewDecoder(getWithHeaders(t, app, "/tasks", nil).Body).Decode(&tasks); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Expected the cached response to expire, got %d tasks", len(tasks))
+	}
+}
+
+func TestResponseCacheSkipsClockQueries(t *testing.T) {
+	app, db, queries := newCachedTestApp(t, time.Hour)
+	db.Create(&models.Task{Title: "Soon", Priority: "Low", DueDate: dueAt(time.Now().Add(time.Hour))})
+	resp := postJSON(t, app, "/views", `{"name":"Late","visibility":"shared","definition":{"filter":{"q":"overdue"}}}`, nil)
+	var view models.SavedView
+	if err := json.NewDecoder(resp.Body).Decode(&view); err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a view, got %d", resp.StatusCode)
+	}
+
+	// Whether the task is due within the hour, or overdue, changes without
+	// a write, so these are read every time and never answered with 304.
+	future := map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}
+	for _, path := range []string{"/tasks?q=" + url.QueryEscape("due<1h"), fmt.Sprintf("/views/%d/tasks", view.ID)} {
+		*queries = 0
+		for i := 0; i < 2; i++ {
+			resp := getWithHeaders(t, app, path, future)
+			if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != "" || resp.Header.Get("Cache-Control") != "no-store" {
+				t.Errorf("%s: expected an uncached 200, got %d %v", path, resp.StatusCode, resp.Header)
+			}
+		}
+		if *queries != 2 {
+			t.Errorf("%s: expected every request to be queried, got %d queries", path, *queries)
+		}
+	}
+
+	// Absolute days still are cached.
+	path := "/tasks?q=" + url.QueryEscape("due<2030-01-01")
+	getWithHeaders(t, app, path, nil)
+	*queries = 0
+	if resp := getWithHeaders(t, app, path, nil); resp.Header.Get("ETag") == "" || *queries != 0 {
+		t.Errorf("Expected a cached response for an absolute day, got %d queries", *queries)
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"todo/internal/cli"
+	"todo/pkg/client"
+
+	"testing"
+)
+
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := cli.NewRootCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestCLITaskLifecycle(t *testing.T) {
+	srv := setupClientTestServer(t)
+	t.Setenv("TODO_CONFIG", filepath.Join(t.TempDir(), "missing.yaml"))
+	t.Setenv("TODO_SERVER", srv.URL)
+
+	if _, err := runCLI(t, "add", "Write docs", "--priority", "High", "--assignee", "Alice", "--due", "2025-12-31"); err == nil {
+		t.Fatal("Expected an error for a missing explicit config file")
+	}
+	os.WriteFile(os.Getenv("TODO_CONFIG"), []byte("server: http://127.0.0.1:1\noutput: json\n"), 0o600)
+
+	out, err := runCLI(t, "add", "Write docs", "--priority", "High", "--assignee", "Alice", "--due", "2025-12-31")
+	if err != nil {
+		t.Fatalf("add failed: %v\n%s", err, out)
+	}
+	var task client.Task
+	if err := json.Unmarshal([]byte(out), &task); err != nil {
+		t.Fatalf("Expected JSON output from the config file setting, got %q", out)
+	}
+	if task.Priority != client.PriorityHigh || task.DueDate.Format("2006-01-02") != "2025-12-31" {
+		t.Errorf("Unexpected task %+v", task)
+	}
+
+	if out, err := runCLI(t, "subtask", "add", "1", "Outline"); err != nil {
+		t.Fatalf("subtask add failed: %v\n%s", err, out)
+	}
+	if out, err := runCLI(t, "subtask", "done", "1"); err != nil {
+		t.Fatalf("subtask done failed: %v\n%s", err, out)
+	}
+	if out, err := runCLI(t, "edit", "1", "--title", "Write API docs"); err != nil {
+		t.Fatalf("edit failed: %v\n%s", err, out)
+	}
+	if out, err := runCLI(t, "done", "1"); err != nil {
+		t.Fatalf("done failed: %v\n%s", err, out)
+	}
+
+	out, err = runCLI(t, "list", "--status", "completed", "-o", "table")
+	if err != nil {
+		t.Fatalf("list failed: %v\n%s", err, out)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "Write API docs") || !strings.Contains(lines[1], "1/1") {
+		t.Errorf("Unexpected table output:\n%s", out)
+	}
+
+	out, err = runCLI(t, "list", "-o", "yaml")
+	if err != nil || !strings.Contains(out, "title: Write API docs") {
+		t.Errorf("Unexpected YAML output %q (%v)", out, err)
+	}
+
+	if out, err := runCLI(t, "rm", "1"); err != nil {
+		t.Fatalf("rm failed: %v\n%s", err, out)
+	}
+	_, err = runCLI(t, "show", "1")
+	if !client.IsNotFound(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestCLICompletion(t *testing.T) {
+	out, err := runCLI(t, "completion", "bash")
+	if err != nil || !strings.Contains(out, "bash completion") {
+		t.Errorf("Expected a bash completion script, got error %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"todo/pkg/client"
+
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"testing"
+	"time"
+)
+
+func setupClientTestServer(t *testing.T) *httptest.Server {
+	app, _ := setupTestApp()
+
+	srv := httptest.NewServer(adaptor.FiberApp(app))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientTasksAndSubtasks(t *testing.T) {
+	srv := setupClientTestServer(t)
+	c := client.New(srv.URL, client.WithTimeout(5*time.Second))
+	ctx := context.Background()
+
+	task, err := c.CreateTask(ctx, client.TaskInput{Title: "Test Task", Priority: client.PriorityHigh, Assignee: "Alice"})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if _, err := c.UpdateTask(ctx, task.ID, client.TaskInput{Title: "Updated Task", Priority: client.PriorityLow, Assignee: "Alice"}); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	subtask, err := c.CreateSubtask(ctx, task.ID, client.SubtaskInput{Title: "Test Subtask"})
+	if err != nil {
+		t.Fatalf("CreateSubtask failed: %v", err)
+	}
+	if _, err := c.UpdateSubtask(ctx, subtask.ID, client.SubtaskInput{Title: "Updated Subtask"}); err != nil {
+		t.Fatalf("UpdateSubtask failed: %v", err)
+	}
+	if _, err := c.SetSubtaskDone(ctx, subtask.ID, true); err != nil {
+		t.Fatalf("SetSubtaskDone failed: %v", err)
+	}
+	if _, err := c.SetTaskDone(ctx, task.ID, true); err != nil {
+		t.Fatalf("SetTaskDone failed: %v", err)
+	}
+
+	tasks, err := c.ListTasks(ctx, client.ListTasksOptions{Assignee: "Alice", Status: client.StatusCompleted})
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "Updated Task" || len(tasks[0].Subtasks) != 1 || !tasks[0].Subtasks[0].Done {
+		t.Errorf("Unexpected tasks %+v", tasks)
+	}
+	got, err := c.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if got.Priority != client.PriorityLow || !got.Done {
+		t.Errorf("Unexpected task %+v", got)
+	}
+	subtasks, err := c.ListSubtasks(ctx, task.ID)
+	if err != nil || len(subtasks) != 1 || subtasks[0].Title != "Updated Subtask" {
+		t.Errorf("Unexpected subtasks %+v (%v)", subtasks, err)
+	}
+
+	var data struct {
+		Tasks []struct {
+			Title string `json:"title"`
+		} `json:"tasks"`
+	}
+	if err := c.GraphQL(ctx, `{ tasks { title } }`, nil, &data); err != nil || len(data.Tasks) != 1 {
+		t.Errorf("Unexpected GraphQL result %+v (%v)", data, err)
+	}
+
+	if err := c.DeleteSubtask(ctx, subtask.ID); err != nil {
+		t.Fatalf("DeleteSubtask failed: %v", err)
+	}
+	if err := c.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if _, err := c.GetTask(ctx, task.ID); !client.IsNotFound(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	srv := setupClientTestServer(t)
+	c := client.New(srv.URL)
+	ctx := context.Background()
+
+	_, err := c.CreateTask(ctx, client.TaskInput{Title: "Test Task", Priority: "Invalid"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, apiErr.StatusCode)
+	}
+	expected := "priority must be one of Low, Medium, High"
+	if apiErr.Message != expected {
+		t.Errorf("Expected error %q, got %q", expected, apiErr.Message)
+	}
+
+	err = c.DeleteSubtask(ctx, 999)
+	if !errors.As(err, &apiErr) || apiErr.Message != "Subtask not found" {
+		t.Errorf("Expected %q, got %v", "Subtask not found", err)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	srv := setupClientTestServer(t)
+	var calls int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		resp, err := http.Get(srv.URL + r.URL.String())
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer flaky.Close()
+
+	c := client.New(flaky.URL, client.WithRetries(3, time.Millisecond))
+	if _, err := c.ListTasks(context.Background(), client.ListTasksOptions{}); err != nil {
+		t.Fatalf("Expected retries to succeed, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	c = client.New(flaky.URL, client.WithRetries(3, time.Millisecond))
+	_, err := c.CreateTask(context.Background(), client.TaskInput{Title: "Test Task", Priority: client.PriorityLow})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || calls != 1 {
+		t.Errorf("Expected a single unretried POST, got %v after %d attempts", err, calls)
+	}
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"todo/internal/config"
+	"todo/internal/database"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func clearConfigEnv(t *testing.T) {
+	for _, env := range []string{"CONFIG_FILE", "PORT", "CORS_ORIGINS", "GRPC_PORT", "DB_DRIVER", "DB_DSN", "DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME", "DB_AUTO_MIGRATE", "LOG_LEVEL", "LOG_SAMPLE_RATE", "TRACING_EXPORTER", "TRACING_ENDPOINT", "TRACING_SAMPLE_RATIO", "SEED_DATABASE", "DB_CONNECT_TIMEOUT", "SHUTDOWN_TIMEOUT", "RATE_LIMIT_ENABLED", "RATE_LIMIT_READ", "RATE_LIMIT_WRITE", "RATE_LIMIT_ROUTES", "IDEMPOTENCY_TTL", "RESPONSE_CACHE_ENABLED", "RESPONSE_CACHE_TTL"} {
+		t.Setenv(env, "")
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	clearConfigEnv(t)
+	yamlFile := writeConfigFile(t, "config.yaml", `
+http:
+  port: 9000
+  cors_origins: [https://todo.example.com]
+database:
+  host: db.internal
+  name: from_file
+log:
+  level: debug
+  sample_rate: 0.25
+shutdown_timeout: 1m
+`)
+	tomlFile := writeConfigFile(t, "config.toml", `
+seed = true
+
+[database]
+host = "toml.internal"
+connect_timeout = "5s"
+`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected func(*config.Config) bool
+	}{
+		{
+			name: "Defaults",
+			expected: func(c *config.Config) bool {
+				return c.HTTP.Port == 3000 && c.Database.Host == "localhost" && !c.Seed && c.Log.Level == "info" && c.Log.SampleRate == 1 &&
+					c.Tracing.Exporter == "none" && c.Database.ConnectTimeout == 30*time.Second && c.ShutdownTimeout == 10*time.Second &&
+					c.Idempotency.TTL == 24*time.Hour && !c.ResponseCache.Enabled && c.ResponseCache.TTL == 5*time.Second
+			},
+		},
+		{
+			name: "YAML file",
+			args: []string{"--config", yamlFile},
+			expected: func(c *config.Config) bool {
+				return c.HTTP.Port == 9000 && c.HTTP.CORSOrigins[0] == "https://todo.example.com" && c.Database.Name == "from_file" &&
+					c.Log.Level == "debug" && c.Log.SampleRate == 0.25 && c.ShutdownTimeout == time.Minute
+			},
+		},
+		{
+			name: "TOML file from environment",
+			env:  map[string]string{"CONFIG_FILE": tomlFile},
+			expected: func(c *config.Config) bool {
+				return c.Database.Host == "toml.internal" && c.Seed && c.HTTP.Port == 3000 && c.Database.ConnectTimeout == 5*time.Second
+			},
+		},
+		{
+			name:     "Environment overrides file",
+			args:     []string{"--config", yamlFile},
+			env:      map[string]string{"DB_NAME": "from_env", "PORT": "9100"},
+			expected: func(c *config.Config) bool { return c.Database.Name == "from_env" && c.HTTP.Port == 9100 },
+		},
+		{
+			name:     "Flags override environment",
+			args:     []string{"--config", yamlFile, "--db-name", "from_flag"},
+			env:      map[string]string{"DB_NAME": "from_env"},
+			expected: func(c *config.Config) bool { return c.Database.Name == "from_flag" && c.Database.Host == "db.internal" },
+		},
+		{
+			name: "Rate limit routes",
+			env:  map[string]string{"RATE_LIMIT_ROUTES": "POST /tasks=10/m, DELETE /tasks/:id=5/s"},
+			expected: func(c *config.Config) bool {
+				return c.RateLimit.Enabled && c.RateLimit.Write == "60/m" &&
+					c.RateLimit.Routes["POST /tasks"] == "10/m" && c.RateLimit.Routes["DELETE /tasks/:id"] == "5/s"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := config.Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if !tt.expected(cfg) {
+				t.Errorf("Unexpected config %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	clearConfigEnv(t)
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectedError []string
+	}{
+		{
+			name:          "Invalid values",
+			args:          []string{"--port", "70000", "--db-name", "", "--cors-origins", "localhost:5173"},
+			expectedError: []string{"http.port: 70000 is not a valid port", "database.name: must not be empty", `"localhost:5173" is not an origin`},
+		},
+		{
+			name:          "Port clash",
+			args:          []string{"--port", "5000", "--grpc-port", "5000"},
+			expectedError: []string{"grpc.port: 5000 is already used by http.port"},
+		},
+		{
+			name:          "SQLite without DSN",
+			args:          []string{"--db-driver", "sqlite"},
+			expectedError: []string{"database.dsn: must name the database file"},
+		},
+		{
+			name:          "Unknown driver",
+			args:          []string{"--db-driver", "oracle"},
+			expectedError: []string{`database.driver: "oracle" is not one of mysql, postgres, sqlite`},
+		},
+		{
+			name:          "Log settings",
+			args:          []string{"--log-level", "verbose", "--log-sample-rate", "1.5"},
+			expectedError: []string{`log.level: "verbose" is not one of debug, info, warn, error`, "log.sample_rate: 1.5 is not between 0 and 1"},
+		},
+		{
+			name:          "Tracing settings",
+			args:          []string{"--tracing-exporter", "jaeger", "--tracing-endpoint", "collector:4317"},
+			expectedError: []string{`tracing.exporter: "jaeger" is not one of none, stdout, otlp-grpc, otlp-http`, `tracing.endpoint: "collector:4317" is not a URL`},
+		},
+		{
+			name:          "Timeouts",
+			args:          []string{"--db-connect-timeout", "-1s", "--shutdown-timeout", "0s", "--idempotency-ttl", "0s", "--response-cache-enabled", "true", "--response-cache-ttl", "0s"},
+			expectedError: []string{"database.connect_timeout: -1s must not be negative", "shutdown_timeout: 0s must be positive", "idempotency.ttl: 0s must be positive", "response_cache.ttl: 0s must be positive"},
+		},
+		{
+			name:          "Rate limit settings",
+			args:          []string{"--rate-limit-read", "300", "--rate-limit-routes", "tasks=10/m,POST /tasks=10/day"},
+			expectedError: []string{`rate_limit.read: "300" is not a budget like 60/m`, `rate_limit.routes: "tasks" is not a route`, `rate_limit.routes[POST /tasks]: "10/day"`},
+		},
+		{
+			name:          "Not a duration",
+			args:          []string{"--shutdown-timeout", "10"},
+			expectedError: []string{`--shutdown-timeout: "10" is not a duration like 30s`},
+		},
+		{
+			name:          "Not a number",
+			args:          []string{"--db-port", "abc"},
+			expectedError: []string{`--db-port: "abc" is not a number`},
+		},
+		{
+			name:          "Unknown file key",
+			args:          []string{"--config", writeConfigFile(t, "bad.yaml", "http:\n  prot: 80\n")},
+			expectedError: []string{"field prot not found"},
+		},
+		{
+			name:          "Unsupported file type",
+			args:          []string{"--config", writeConfigFile(t, "config.json", "{}")},
+			expectedError: []string{`unsupported file type ".json"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.Load(tt.args)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			for _, expected := range tt.expectedError {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error to contain %q, got %q", expected, err)
+				}
+			}
+		})
+	}
+
+	_, err := config.Load([]string{"--port", "0", "--db-host", ""})
+	var verr *config.ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 2 {
+		t.Errorf("Expected two validation problems, got %v", err)
+	}
+}
+
+func TestPostgresDSNQuotesValues(t *testing.T) {
+	cfg := config.Database{Driver: "postgres", Host: "db.internal", User: "o'brien", Password: `p@ss word\'`, Name: "todo app"}
+	dsn, err := database.DSN(cfg)
+	if err != nil {
+		t.Fatalf("DSN failed: %v", err)
+	}
+	parsed, err := pgconn.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("Failed to parse %q: %v", dsn, err)
+	}
+	if parsed.Host != cfg.Host || parsed.Port != 5432 || parsed.User != cfg.User || parsed.Password != cfg.Password || parsed.Database != cfg.Name {
+		t.Errorf("Expected %q to keep every value, got %+v", dsn, parsed)
+	}
+}
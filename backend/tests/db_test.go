@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"todo/internal/config"
+	"todo/internal/database"
+	"todo/internal/handlers"
+	"todo/internal/models"
+	"todo/internal/repository"
+	"todo/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// discardLogger keeps access logs out of the test output.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// testDBConfig describes the database the tests run against: an in-memory
+// SQLite database by default, or TEST_DB_DRIVER/TEST_DB_DSN when set (see
+// scripts/test-all-drivers.sh).
+func testDBConfig() config.Database {
+	if driver := os.Getenv("TEST_DB_DRIVER"); driver != "" {
+		return config.Database{Driver: driver, DSN: os.Getenv("TEST_DB_DSN")}
+	}
+	return config.Database{Driver: "sqlite", DSN: ":memory:"}
+}
+
+// openTestDB connects to the test database, drops whatever an earlier test
+// left behind and migrates it.
+func openTestDB() *gorm.DB {
+	cfg := testDBConfig()
+	db, err := database.Open(cfg)
+	if err != nil {
+		panic("failed to connect to test database: " + err.Error())
+	}
+	if cfg.Driver == "sqlite" && cfg.DSN == ":memory:" {
+		// Every connection to :memory: opens a database of its own, so the
+		// pool must never open a second one.
+		sqlDB, err := db.DB()
+		if err != nil {
+			panic("failed to get test database pool: " + err.Error())
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+	if err := resetTestDB(db); err != nil {
+		panic("failed to reset test database: " + err.Error())
+	}
+	if err := database.Migrate(db); err != nil {
+		panic("failed to migrate test database: " + err.Error())
+	}
+	return db
+}
+
+func resetTestDB(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.Subtask{}, &models.Task{}, "idempotency_keys", "task_changes", "saved_views", "schema_migrations")
+}
+
+// newTestService returns a Service on a fresh test database, and the
+// database itself for inserting fixtures.
+func newTestService() (*services.Service, *gorm.DB) {
+	db := openTestDB()
+	return services.New(repository.NewGormTaskRepository(db), repository.NewGormSubtaskRepository(db), repository.NewGormViewRepository(db)), db
+}
+
+// setupTestApp returns the full HTTP API on a fresh test database, and the
+// database itself for inserting fixtures.
+func setupTestApp() (*fiber.App, *gorm.DB) {
+	svc, db := newTestService()
+	return handlers.NewApp(handlers.New(svc), []string{"http://localhost:5173"}, handlers.WithLogger(discardLogger)), db
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"todo/internal/docs"
+
+	"testing"
+)
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	app, _ := setupTestApp()
+	spec := docs.Spec()
+
+	registered := make(map[string]bool)
+	for _, route := range app.GetRoutes(true) {
+		// Fiber registers HEAD alongside every GET.
+		if route.Method == http.MethodHead {
+			continue
+		}
+		key := strings.ToLower(route.Method) + " " + docs.OpenAPIPath(route.Path)
+		registered[key] = true
+		path, ok := spec.Paths[docs.OpenAPIPath(route.Path)]
+		if !ok || (*path)[strings.ToLower(route.Method)] == nil {
+			t.Errorf("Route %s %s is not described in the OpenAPI spec", route.Method, route.Path)
+		}
+	}
+	for path, item := range spec.Paths {
+		for method := range *item {
+			if !registered[method+" "+path] {
+				t.Errorf("OpenAPI spec describes %s %s, which is not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	app, _ := setupTestApp()
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var spec docs.Document
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	task := spec.Components.Schemas["Task"]
+	if task == nil {
+		t.Fatal("Expected a Task schema")
+	}
+	if strings.Join(task.Required, ",") != "title" {
+		t.Errorf("Expected Task to require title, got %v", task.Required)
+	}
+	if got := strings.Join(task.Properties["priority"].Enum, ","); got != "Low,Medium,High" {
+		t.Errorf("Expected priority enum Low,Medium,High, got %q", got)
+	}
+	if task.Properties["subtasks"].Items.Ref != "#/components/schemas/Subtask" {
+		t.Errorf("Expected subtasks to reference Subtask, got %+v", task.Properties["subtasks"].Items)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	resp, err = app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Expected HTML docs page, got %q", resp.Header.Get("Content-Type"))
+	}
+}
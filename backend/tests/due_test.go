@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"todo/internal/database"
+	"todo/internal/models"
+)
+
+func dueAt(t time.Time) *time.Time {
+	return &t
+}
+
+func TestDueDates(t *testing.T) {
+	for name, newService := range repositoryBackends {
+		t.Run(name, func(t *testing.T) {
+			svc := newService()
+			tokyo, _ := time.LoadLocation("Asia/Tokyo")
+			tasks := []models.Task{
+				{Title: "Undated", Priority: "Low"},
+				// Sent as the zero time by older clients.
+				{Title: "Zero", Priority: "Low", DueDate: dueAt(time.Time{}), DueTimezone: "Asia/Tokyo"},
+				{Title: "Exact", Priority: "Low", DueDate: dueAt(time.Date(2030, 1, 2, 18, 0, 0, 0, tokyo)), DueTimezone: "Asia/Tokyo"},
+				// 23:00 UTC is already 2 January in Tokyo.
+				{Title: "All day", Priority: "Low", DueDate: dueAt(time.Date(2030, 1, 1, 23, 0, 0, 0, time.UTC)), DueAllDay: true, DueTimezone: "Asia/Tokyo"},
+				{Title: "UTC", Priority: "Low", DueDate: dueAt(time.Date(2030, 1, 2, 12, 0, 0, 0, tokyo)), DueAllDay: true},
+			}
+			for i := range tasks {
+				if err := svc.CreateTask(&tasks[i]); err != nil {
+					t.Fatalf("CreateTask failed: %v", err)
+				}
+			}
+
+			for _, task := range tasks[:2] {
+				got, err := svc.GetTask(task.ID)
+				if err != nil || got.DueDate != nil || got.OverdueAt != nil || got.DueTimezone != "" {
+					t.Errorf("Expected %s to have no due date, got %+v, %v", task.Title, got, err)
+				}
+			}
+			for _, tt := range []struct {
+				task               models.Task
+				due, overdue, zone string
+			}{
+				{tasks[2], "2030-01-02T09:00:00Z", "2030-01-02T09:00:00Z", "Asia/Tokyo"},
+				{tasks[3], "2030-01-01T15:00:00Z", "2030-01-02T15:00:00Z", "Asia/Tokyo"},
+				{tasks[4], "2030-01-02T00:00:00Z", "2030-01-03T00:00:00Z", "UTC"},
+			} {
+				got, err := svc.GetTask(tt.task.ID)
+				if err != nil || got.DueDate == nil || got.OverdueAt == nil {
+					t.Fatalf("Expected %s to have a due date, got %+v, %v", tt.task.Title, got, err)
+				}
+				if due := got.DueDate.UTC().Format(time.RFC3339); due != tt.due {
+					t.Errorf("%s: expected due date %s, got %s", tt.task.Title, tt.due, due)
+				}
+				if overdue := got.OverdueAt.UTC().Format(time.RFC3339); overdue != tt.overdue {
+					t.Errorf("%s: expected overdue at %s, got %s", tt.task.Title, tt.overdue, overdue)
+				}
+				if got.DueTimezone != tt.zone {
+					t.Errorf("%s: expected time zone %s, got %s", tt.task.Title, tt.zone, got.DueTimezone)
+				}
+			}
+
+			// Date-only deadlines are overdue when their day is over.
+			evening := time.Date(2030, 1, 2, 20, 0, 0, 0, tokyo)
+			stats, err := svc.TaskStats(models.TaskFilter{}, evening)
+			if err != nil || stats.Overdue != 1 || stats.DueThisWeek != 2 {
+				t.Errorf("Expected only the exact deadline to be overdue in the evening in Tokyo, got %+v, %v", stats, err)
+			}
+			agenda, err := svc.Agenda(models.AgendaQuery{TZ: "Asia/Tokyo", Bucket: models.BucketToday}, evening)
+			if err != nil || len(agenda.Buckets[0].Tasks) != 2 {
+				t.Errorf("Expected both date-only deadlines today in Tokyo, got %+v, %v", agenda.Buckets, err)
+			}
+		})
+	}
+}
+
+func TestDueDatesOverHTTP(t *testing.T) {
+	app, _ := setupTestApp()
+
+	resp := postJSON(t, app, "/tasks", `{"title":"Undated","priority":"Low","due_date":null}`, nil)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated || !strings.Contains(string(body), `"due_date":null`) || !strings.Contains(string(body), `"overdue_at":null`) {
+		t.Errorf("Expected a null due date, got %d %s", resp.StatusCode, body)
+	}
+
+	resp = postJSON(t, app, "/tasks", `{"title":"Dated","priority":"Low","due_date":"2030-01-02T00:00:00+01:00","due_all_day":true,"due_timezone":"Europe/Berlin"}`, nil)
+	var task map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if task["due_date"] != "2030-01-01T23:00:00Z" || task["overdue_at"] != "2030-01-02T23:00:00Z" || task["due_timezone"] != "Europe/Berlin" {
+		t.Errorf("Expected the due date in UTC, got %v", task)
+	}
+
+	resp = postJSON(t, app, "/tasks", `{"title":"Elsewhere","priority":"Low","due_date":"2030-01-02T00:00:00Z","due_timezone":"Mars/Olympus"}`, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected an unknown time zone to be rejected, got %d", resp.StatusCode)
+	}
+}
+
+func TestMigrateMakesDueDatesOptional(t *testing.T) {
+	db, _ := setupMigrateTestDB(t)
+	if _, err := database.MigrateUp(db, 4); err != nil {
+		t.Fatalf("Failed to migrate to version 4: %v", err)
+	}
+	due := time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)
+	now := time.Now()
+	for _, row := range []map[string]interface{}{
+		{"title": "Undated", "priority": "Low", "due_date": time.Time{}, "created_at": now, "updated_at": now},
+		{"title": "Dated", "priority": "Low", "due_date": due, "created_at": now, "updated_at": now},
+	} {
+		if err := db.Table("tasks").Create(row).Error; err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	var tasks []models.Task
+	if err := db.Order("id").Find(&tasks).Error; err != nil {
+		t.Fatalf("Failed to load tasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].DueDate != nil || tasks[1].DueDate == nil || !tasks[1].DueDate.Equal(due) ||
+		tasks[1].OverdueAt == nil || !tasks[1].OverdueAt.Equal(due) || tasks[1].DueAllDay || tasks[1].DueTimezone != "UTC" {
+		t.Errorf("Unexpected migrated tasks %+v", tasks)
+	}
+
+	if _, err := database.MigrateDown(db, len(database.Migrations())-4); err != nil {
+		t.Fatalf("Failed to revert to version 4: %v", err)
+	}
+	if db.Migrator().HasColumn("tasks", "overdue_at") {
+		t.Error("Expected overdue_at to be dropped")
+	}
+}
@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"todo/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func doGraphQL(t *testing.T, app *fiber.App, query string, variables map[string]interface{}) graphQLResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var result graphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return result
+}
+
+func TestGraphQLTasksBatchesSubtasks(t *testing.T) {
+	app, db := setupTestApp()
+
+	// Create tasks with subtasks for testing
+	for _, assignee := range []string{"Alice", "Alice", "Bob"} {
+		task := models.Task{Title: "Task for " + assignee, Priority: "Medium", Assignee: assignee,
+			Subtasks: []models.Subtask{{Title: "First"}, {Title: "Second"}}}
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatalf("Failed to create test task: %v", err)
+		}
+	}
+
+	subtaskQueries := 0
+	db.Callback().Query().After("gorm:query").Register("test:count_subtasks", func(tx *gorm.DB) {
+		if tx.Statement.Table == "subtasks" {
+			subtaskQueries++
+		}
+	})
+
+	result := doGraphQL(t, app, `{ tasks(assignee: "Alice") { id title subtasks { title } } }`, nil)
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	var tasks []struct {
+		Title    string `json:"title"`
+		Subtasks []struct {
+			Title string `json:"title"`
+		} `json:"subtasks"`
+	}
+	if err := json.Unmarshal(result.Data["tasks"], &tasks); err != nil {
+		t.Fatalf("Failed to decode tasks: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("Expected 2 tasks, got %d", len(tasks))
+	}
+	for _, task := range tasks {
+		if len(task.Subtasks) != 2 {
+			t.Errorf("Expected 2 subtasks on %q, got %d", task.Title, len(task.Subtasks))
+		}
+	}
+	if subtaskQueries != 1 {
+		t.Errorf("Expected 1 subtask query, got %d", subtaskQueries)
+	}
+}
+
+func TestGraphQLTaskByID(t *testing.T) {
+	app, db := setupTestApp()
+
+	// Create a task for testing
+	task := models.Task{Title: "Test Task", Priority: "High", Subtasks: []models.Subtask{{Title: "Test Subtask"}}}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatalf("Failed to create test task: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		id            string
+		expectedTitle string
+		expectedError string
+	}{
+		{
+			name:          "Valid ID",
+			id:            "1",
+			expectedTitle: "Test Task",
+		},
+		{
+			name:          "Invalid ID",
+			id:            "invalid",
+			expectedError: "Invalid task ID",
+		},
+		{
+			name:          "Non-existent ID",
+			id:            "999",
+			expectedError: "Task not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := doGraphQL(t, app, `query($id: ID!) { task(id: $id) { title subtasks { title } } }`,
+				map[string]interface{}{"id": tt.id})
+			if tt.expectedError != "" {
+				if len(result.Errors) == 0 || result.Errors[0].Message != tt.expectedError {
+					t.Errorf("Expected error %q, got %v", tt.expectedError, result.Errors)
+				}
+				return
+			}
+			var got struct {
+				Title    string           `json:"title"`
+				Subtasks []models.Subtask `json:"subtasks"`
+			}
+			if err := json.Unmarshal(result.Data["task"], &got); err != nil {
+				t.Fatalf("Failed to decode task: %v", err)
+			}
+			if got.Title != tt.expectedTitle || len(got.Subtasks) != 1 {
+				t.Errorf("Expected %q with 1 subtask, got %q with %d", tt.expectedTitle, got.Title, len(got.Subtasks))
+			}
+		})
+	}
+}
+
+func TestGraphQLMutations(t *testing.T) {
+	app, _ := setupTestApp()
+
+	result := doGraphQL(t, app, `mutation { createTask(input: {title: "Test Task", priority: "High"}) { id priority } }`, nil)
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+
+	result = doGraphQL(t, app, `mutation { createTask(input: {title: "Test Task", priority: "Invalid"}) { id } }`, nil)
+	expected := "priority must be one of Low, Medium, High"
+	if len(result.Errors) == 0 || result.Errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %v", expected, result.Errors)
+	}
+
+	// Like REST, a missing priority is rejected rather than defaulted.
+	result = doGraphQL(t, app, `mutation { createTask(input: {title: "Test Task"}) { id } }`, nil)
+	if len(result.Errors) == 0 || result.Errors[0].Message != expected {
+		t.Errorf("Expected error %q, got %v", expected, result.Errors)
+	}
+
+	result = doGraphQL(t, app, `mutation {
+		createSubtask(taskId: 1, input: {title: "Test Subtask"}) { id }
+		updateSubtaskDone(id: 1, done: true) { done }
+		updateTaskDone(id: 1, done: true) { done subtasks { done } }
+	}`, nil)
+	if len(result.Errors) > 0 {
+		t.Fatalf("Unexpected errors: %v", result.Errors)
+	}
+	if got := string(result.Data["updateTaskDone"]); got != `{"done":true,"subtasks":[{"done":true}]}` {
+		t.Errorf("Unexpected updateTaskDone result %s", got)
+	}
+
+	result = doGraphQL(t, app, `mutation { deleteSubtask(id: 999) }`, nil)
+	if len(result.Errors) == 0 || result.Errors[0].Message != "Subtask not found" {
+		t.Errorf("Expected error %q, got %v", "Subtask not found", result.Errors)
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	app, _ := setupTestApp()
+
+	result := doGraphQL(t, app, `{ tasks { subtasks { a { b { c { d { e } } } } } } }`, nil)
+	if len(result.Errors) == 0 || !strings.Contains(result.Errors[0].Message, "depth") {
+		t.Errorf("Expected depth error, got %v", result.Errors)
+	}
+
+	result = doGraphQL(t, app, `{ a: tasks { subtasks { id } } b: tasks { subtasks { id } } c: tasks { subtasks { id } }
+		d: tasks { subtasks { id } } e: tasks { subtasks { id } } f: tasks { subtasks { id } } }`, nil)
+	if len(result.Errors) == 0 || !strings.Contains(result.Errors[0].Message, "complexity") {
+		t.Errorf("Expected complexity error, got %v", result.Errors)
+	}
+
+	result = doGraphQL(t, app, `fragment F on Task { subtasks { id } } { tasks { ...F } }`, nil)
+	if len(result.Errors) > 0 {
+		t.Errorf("Unexpected errors: %v", result.Errors)
+	}
+}
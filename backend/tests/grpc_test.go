@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"todo/internal/handlers"
+	"todo/internal/rpc"
+	"todo/internal/rpc/todov1"
+	"todo/internal/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func setupGRPCTestClient(t *testing.T) todov1.TodoServiceClient {
+	svc, _ := newTestService()
+	return newGRPCTestClient(t, svc)
+}
+
+// newGRPCTestClient serves svc over an in-memory gRPC connection.
+func newGRPCTestClient(t *testing.T, svc *services.Service) todov1.TodoServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := rpc.NewServer(svc)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial gRPC server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return todov1.NewTodoServiceClient(conn)
+}
+
+func TestGRPCTasks(t *testing.T) {
+	client := setupGRPCTestClient(t)
+	ctx := context.Background()
+
+	task, err := client.CreateTask(ctx, &todov1.CreateTaskRequest{Task: &todov1.TaskInput{Title: "Test Task", Priority: "High"}})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if _, err := client.CreateSubtask(ctx, &todov1.CreateSubtaskRequest{TaskId: task.Id, Title: "Test Subtask"}); err != nil {
+		t.Fatalf("CreateSubtask failed: %v", err)
+	}
+	if _, err := client.SetTaskDone(ctx, &todov1.SetTaskDoneRequest{Id: task.Id, Done: true}); err != nil {
+		t.Fatalf("SetTaskDone failed: %v", err)
+	}
+
+	list, err := client.ListTasks(ctx, &todov1.ListTasksRequest{Status: "completed"})
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(list.Tasks) != 1 || len(list.Tasks[0].Subtasks) != 1 {
+		t.Errorf("Expected 1 completed task with 1 subtask, got %v", list.Tasks)
+	}
+	if list, err := client.ListTasks(ctx, &todov1.ListTasksRequest{Q: "priority:high done"}); err != nil || len(list.Tasks) != 1 {
+		t.Errorf("Expected the query to match the task, got %v, %v", list, err)
+	}
+	if list, err := client.ListTasks(ctx, &todov1.ListTasksRequest{Q: "priority:low"}); err != nil || len(list.Tasks) != 0 {
+		t.Errorf("Expected the query to match nothing, got %v, %v", list, err)
+	}
+
+	tests := []struct {
+		name          string
+		call          func() error
+		expectedCode  codes.Code
+		expectedError string
+	}{
+		{
+			name: "Invalid priority",
+			call: func() error {
+				_, err := client.CreateTask(ctx, &todov1.CreateTaskRequest{Task: &todov1.TaskInput{Title: "Test Task", Priority: "Invalid"}})
+				return err
+			},
+			expectedCode:  codes.InvalidArgument,
+			expectedError: "priority must be one of Low, Medium, High",
+		},
+		{
+			name: "Non-existent task",
+			call: func() error {
+				_, err := client.GetTask(ctx, &todov1.GetTaskRequest{Id: 999})
+				return err
+			},
+			expectedCode:  codes.NotFound,
+			expectedError: "Task not found",
+		},
+		{
+			name: "Non-existent subtask",
+			call: func() error {
+				_, err := client.DeleteSubtask(ctx, &todov1.DeleteSubtaskRequest{Id: 999})
+				return err
+			},
+			expectedCode:  codes.NotFound,
+			expectedError: "Subtask not found",
+		},
+		{
+			name: "Invalid task query",
+			call: func() error {
+				_, err := client.ListTasks(ctx, &todov1.ListTasksRequest{Q: "done OR colour:red"})
+				return err
+			},
+			expectedCode:  codes.InvalidArgument,
+			expectedError: "task query: column 9: unknown field colour",
+		},
+		{
+			name: "Invalid status filter",
+			call: func() error {
+				_, err := client.ListTasks(ctx, &todov1.ListTasksRequest{Status: "unknown"})
+				return err
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(tt.call())
+			if st.Code() != tt.expectedCode {
+				t.Errorf("Expected code %v, got %v", tt.expectedCode, st.Code())
+			}
+			if tt.expectedError != "" && st.Message() != tt.expectedError {
+				t.Errorf("Expected error %q, got %q", tt.expectedError, st.Message())
+			}
+		})
+	}
+}
+
+func TestGRPCAllDayDueDate(t *testing.T) {
+	client := setupGRPCTestClient(t)
+	ctx := context.Background()
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	start := time.Date(2030, 3, 10, 0, 0, 0, 0, berlin)
+
+	task, err := client.CreateTask(ctx, &todov1.CreateTaskRequest{Task: &todov1.TaskInput{Title: "Tax return", Priority: "High",
+		DueDate: timestamppb.New(time.Date(2030, 3, 10, 12, 0, 0, 0, berlin)), DueAllDay: true, DueTimezone: "Europe/Berlin"}})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if !task.DueAllDay || task.DueTimezone != "Europe/Berlin" || !task.DueDate.AsTime().Equal(start) || !task.OverdueAt.AsTime().Equal(start.AddDate(0, 0, 1)) {
+		t.Fatalf("Expected an all-day deadline on 10 March in Berlin, got %v", task)
+	}
+
+	// Sending the task back with only a new title keeps the deadline.
+	updated, err := client.UpdateTask(ctx, &todov1.UpdateTaskRequest{Id: task.Id, Task: &todov1.TaskInput{
+		Title: "File tax return", Priority: task.Priority, DueDate: task.DueDate, DueAllDay: task.DueAllDay, DueTimezone: task.DueTimezone}})
+	if err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	got, err := client.GetTask(ctx, &todov1.GetTaskRequest{Id: task.Id})
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	for _, pb := range []*todov1.Task{updated, got} {
+		if pb.Title != "File tax return" || !pb.DueAllDay || pb.DueTimezone != "Europe/Berlin" ||
+			!pb.DueDate.AsTime().Equal(start) || !pb.OverdueAt.AsTime().Equal(start.AddDate(0, 0, 1)) {
+			t.Errorf("Expected the all-day deadline to survive the update, got %v", pb)
+		}
+	}
+}
+
+func TestGRPCWatchTasks(t *testing.T) {
+	svc, _ := newTestService()
+	client := newGRPCTestClient(t, svc)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchTasks(ctx, &todov1.WatchTasksRequest{})
+	if err != nil {
+		t.Fatalf("WatchTasks failed: %v", err)
+	}
+	// Headers arrive once the server has subscribed.
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Failed to receive headers: %v", err)
+	}
+
+	// Changes made over REST are streamed as well.
+	app := handlers.NewApp(handlers.New(svc), nil, handlers.WithLogger(discardLogger))
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"Test Task","priority":"Low"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err := app.Test(req); err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Failed to create task over REST: %v", err)
+	}
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive event: %v", err)
+	}
+	if event.Type != todov1.TaskEvent_TYPE_CREATED || event.Task.GetTitle() != "Test Task" {
+		t.Errorf("Expected created event for %q, got %v", "Test Task", event)
+	}
+
+	if _, err := client.DeleteTask(ctx, &todov1.DeleteTaskRequest{Id: 1}); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	event, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive event: %v", err)
+	}
+	if event.Type != todov1.TaskEvent_TYPE_DELETED || event.TaskId != 1 {
+		t.Errorf("Expected deleted event for task 1, got %v", event)
+	}
+}
+
+func TestGRPCWatchTasksEndsOnClose(t *testing.T) {
+	svc, _ := newTestService()
+	client := newGRPCTestClient(t, svc)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchTasks(ctx, &todov1.WatchTasksRequest{})
+	if err != nil {
+		t.Fatalf("WatchTasks failed: %v", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Failed to receive headers: %v", err)
+	}
+
+	svc.Close()
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected the stream to end with Unavailable, got %v", err)
+	}
+	// Watches started after Close end right away.
+	stream, err = client.WatchTasks(ctx, &todov1.WatchTasksRequest{})
+	if err != nil {
+		t.Fatalf("WatchTasks failed: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected a late watch to end with Unavailable, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo/internal/config"
+	"todo/internal/database"
+	"todo/internal/handlers"
+	"todo/internal/health"
+	"todo/internal/repository"
+	"todo/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func getHealth(t *testing.T, app *fiber.App, path string) (int, health.Report) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	var report health.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp.StatusCode, report
+}
+
+func TestHealthProbes(t *testing.T) {
+	db, _ := setupMigrateTestDB(t)
+	svc := services.New(repository.NewGormTaskRepository(db), repository.NewGormSubtaskRepository(db), repository.NewGormViewRepository(db))
+	checker := health.New()
+	checker.AddReadiness("database", func(ctx context.Context) error { return database.Ping(ctx, db) })
+	checker.AddReadiness("migrations", func(ctx context.Context) error { return database.CheckMigrations(ctx, db) })
+	app := handlers.NewApp(handlers.New(svc), nil, handlers.WithLogger(discardLogger), handlers.WithHealth(checker))
+
+	// Reachable, but the schema is not migrated yet.
+	code, report := getHealth(t, app, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != health.StatusUnavailable ||
+		report.Checks["database"] != health.StatusOK || report.Checks["migrations"] != "failing" {
+		t.Errorf("Expected /readyz to fail on pending migrations, got %d %+v", code, report)
+	}
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if code, report := getHealth(t, app, "/readyz"); code != http.StatusOK || report.Checks["migrations"] != health.StatusOK {
+		t.Errorf("Expected /readyz to pass once migrated, got %d %+v", code, report)
+	}
+
+	checker.Drain()
+	if code, report := getHealth(t, app, "/readyz"); code != http.StatusServiceUnavailable || report.Status != health.StatusDraining {
+		t.Errorf("Expected /readyz to fail while draining, got %d %+v", code, report)
+	}
+	if code, _ := getHealth(t, app, "/healthz"); code != http.StatusOK {
+		t.Errorf("Expected /healthz to pass while draining, got %d", code)
+	}
+
+	if err := database.Close(db); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	checker = health.New()
+	checker.AddReadiness("database", func(ctx context.Context) error { return database.Ping(ctx, db) })
+	app = handlers.NewApp(handlers.New(svc), nil, handlers.WithLogger(discardLogger), handlers.WithHealth(checker))
+	if code, report := getHealth(t, app, "/readyz"); code != http.StatusServiceUnavailable || report.Checks["database"] != "failing" {
+		t.Errorf("Expected /readyz to fail without a database, got %d %+v", code, report)
+	}
+	// The process itself is fine, so it must not be restarted.
+	if code, _ := getHealth(t, app, "/healthz"); code != http.StatusOK {
+		t.Errorf("Expected /healthz to pass without a database, got %d", code)
+	}
+}
+
+func TestConnectRetries(t *testing.T) {
+	// SQLite cannot create the database file until its directory exists,
+	// which stands in for a database server that is still starting.
+	dir := filepath.Join(t.TempDir(), "later")
+	cfg := config.Database{Driver: "sqlite", DSN: filepath.Join(dir, "todo.db"), ConnectTimeout: 5 * time.Second}
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		os.Mkdir(dir, 0o755)
+	}()
+	db, err := database.Connect(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Expected Connect to wait for the database, got %v", err)
+	}
+	database.Close(db)
+
+	cfg.DSN = filepath.Join(t.TempDir(), "never", "todo.db")
+	cfg.ConnectTimeout = 300 * time.Millisecond
+	if _, err := database.Connect(context.Background(), cfg); err == nil || !strings.Contains(err.Error(), "gave up after") {
+		t.Errorf("Expected Connect to give up, got %v", err)
+	}
+
+	cfg.ConnectTimeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := database.Connect(ctx, cfg); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Connect to stop with its context, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Expected Connect to stop promptly, took %s", time.Since(start))
+	}
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"todo/internal/handlers"
+	"todo/internal/i18n"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\d+\}`)
+
+func placeholders(text string) []string {
+	found := placeholderPattern.FindAllString(text, -1)
+	sort.Strings(found)
+	return found
+}
+
+func TestCataloguesDefineEveryKey(t *testing.T) {
+	keys := i18n.Keys(i18n.Default)
+	if len(keys) == 0 {
+		t.Fatalf("Expected the %s catalogue to have messages", i18n.Default)
+	}
+	for _, locale := range i18n.Locales() {
+		t.Run(locale, func(t *testing.T) {
+			if got := i18n.Keys(locale); !equalStrings(got, keys) {
+				t.Errorf("Expected keys %v, got %v", keys, got)
+			}
+			for _, key := range keys {
+				want, _ := i18n.Message(i18n.Default, key)
+				got, ok := i18n.Message(locale, key)
+				if !ok {
+					continue
+				}
+				if got == "" {
+					t.Errorf("Message %s is empty", key)
+				}
+				if !equalStrings(placeholders(got), placeholders(want)) {
+					t.Errorf("Message %s has placeholders %v, want %v", key, placeholders(got), placeholders(want))
+				}
+			}
+		})
+	}
+}
+
+// TestMessageKeysInUse catches handlers returning a key that no catalogue
+// defines, which would otherwise only show up as the raw key in a response.
+func TestMessageKeysInUse(t *testing.T) {
+	uses := regexp.MustCompile(`(?:(?:problem|internalError)\([^"\n]*|\.T\()"([a-z_.]+)"`)
+	files, err := filepath.Glob("../internal/*/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range uses.FindAllStringSubmatch(string(src), -1) {
+			found++
+			if _, ok := i18n.Message(i18n.Default, m[1]); !ok {
+				t.Errorf("%s uses message key %q missing from the catalogues", file, m[1])
+			}
+		}
+	}
+	if found == 0 {
+		t.Error("Expected to find message keys in use")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "en"},
+		{"de", "de"},
+		{"de-AT", "de"},
+		{"FA-ir", "fa"},
+		{"fr, de;q=0.5", "de"},
+		{"en;q=0.2, fa;q=0.8", "fa"},
+		{"de;q=0, fa", "fa"},
+		{"fr, it", "en"},
+		{"*", "en"},
+	}
+	for _, tt := range tests {
+		if got := i18n.Negotiate(tt.header).Locale(); got != tt.expected {
+			t.Errorf("Negotiate(%q) = %s, want %s", tt.header, got, tt.expected)
+		}
+	}
+}
+
+func TestTranslatorFallsBack(t *testing.T) {
+	if got := i18n.For("ja").T("task_not_found"); got != "Task not found" {
+		t.Errorf("Expected the default message for an unsupported locale, got %q", got)
+	}
+	if got := i18n.For("de").T("no_such_key"); got != "no_such_key" {
+		t.Errorf("Expected an unknown key to be returned as is, got %q", got)
+	}
+}
+
+func TestLocalizedProblems(t *testing.T) {
+	app, _ := setupTestApp()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		acceptLanguage string
+		expectedLang   string
+		expectedDetail string
+		expectedFields []string
+	}{
+		{
+			name:           "German validation",
+			method:         http.MethodPost,
+			path:           "/tasks",
+			body:           `{"title":"Report","priority":"Urgent"}`,
+			acceptLanguage: "de-DE,de;q=0.9,en;q=0.8",
+			expectedLang:   "de",
+			expectedDetail: "priority muss einer der Werte Low, Medium, High sein",
+			expectedFields: []string{"muss einer der Werte Low, Medium, High sein"},
+		},
+		{
+			name:           "Persian validation",
+			method:         http.MethodPost,
+			path:           "/tasks",
+			body:           `{"priority":"High"}`,
+			acceptLanguage: "fa",
+			expectedLang:   "fa",
+			expectedDetail: "title الزامی است",
+			expectedFields: []string{"الزامی است"},
+		},
+		{
+			name:           "Persian not found",
+			method:         http.MethodGet,
+			path:           "/tasks/42",
+			acceptLanguage: "fa-IR",
+			expectedLang:   "fa",
+			expectedDetail: "کار پیدا نشد",
+		},
+		{
+			name:           "German unknown route",
+			method:         http.MethodGet,
+			path:           "/nope",
+			acceptLanguage: "de",
+			expectedLang:   "de",
+			expectedDetail: "GET /nope ist nicht möglich",
+		},
+		{
+			name:           "Unsupported language",
+			method:         http.MethodGet,
+			path:           "/tasks/abc",
+			acceptLanguage: "fr-FR",
+			expectedLang:   "en",
+			expectedDetail: "Invalid task ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to execute request: %v", err)
+			}
+			if got := resp.Header.Get("Content-Language"); got != tt.expectedLang {
+				t.Errorf("Expected Content-Language %s, got %q", tt.expectedLang, got)
+			}
+
+			var problem handlers.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if problem.Detail != tt.expectedDetail {
+				t.Errorf("Expected detail %q, got %q", tt.expectedDetail, problem.Detail)
+			}
+			var messages []string
+			for _, f := range problem.Errors {
+				messages = append(messages, f.Message)
+			}
+			if !equalStrings(messages, tt.expectedFields) {
+				t.Errorf("Expected field messages %v, got %v", tt.expectedFields, messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"todo/internal/database"
+	"todo/internal/handlers"
+	"todo/internal/idempotency"
+	"todo/internal/models"
+	"todo/internal/repository"
+	"todo/internal/services"
+	"todo/pkg/client"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"gorm.io/gorm"
+)
+
+// newIdempotentTestApp returns the HTTP API storing idempotent responses in
+// its database. SQLite uses a file, so that concurrent requests share one
+// database and wait for each other's writes.
+func newIdempotentTestApp(t *testing.T) (*fiber.App, *gorm.DB) {
+	t.Helper()
+	cfg := testDBConfig()
+	if cfg.Driver == "sqlite" {
+		cfg.DSN = filepath.Join(t.TempDir(), "todo.db") + "?_busy_timeout=5000"
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := resetTestDB(db); err != nil {
+		t.Fatalf("Failed to reset test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	svc := services.New(repository.NewGormTaskRepository(db), repository.NewGormSubtaskRepository(db), repository.NewGormViewRepository(db))
+	app := handlers.NewApp(handlers.New(svc), nil, handlers.WithLogger(discardLogger),
+		handlers.WithIdempotency(idempotency.NewGormStore(db), time.Hour))
+	return app, db
+}
+
+func postIdempotent(t *testing.T, app *fiber.App, path, key, body string, headers map[string]string) (*http.Response, string) {
+	t.Helper()
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers[handlers.HeaderIdempotencyKey] = key
+	resp := postJSON(t, app, path, body, headers)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	return resp, string(data)
+}
+
+func countTasks(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&models.Task{}).Count(&n).Error; err != nil {
+		t.Fatalf("Failed to count tasks: %v", err)
+	}
+	return n
+}
+
+func TestIdempotentCreate(t *testing.T) {
+	app, db := newIdempotentTestApp(t)
+	task := `{"title":"Once","priority":"Low"}`
+
+	first, firstBody := postIdempotent(t, app, "/tasks", "key-1", task, nil)
+	if first.StatusCode != http.StatusCreated || first.Header.Get(handlers.HeaderIdempotentReplayed) != "" {
+		t.Fatalf("Expected the task to be created, got %d", first.StatusCode)
+	}
+	retry, retryBody := postIdempotent(t, app, "/tasks", "key-1", task, nil)
+	if retry.StatusCode != http.StatusCreated || retryBody != firstBody || retry.Header.Get(handlers.HeaderIdempotentReplayed) != "true" {
+		t.Errorf("Expected the first response to be replayed, got %d %s", retry.StatusCode, retryBody)
+	}
+	if ct := retry.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Expected the replay to keep its content type, got %q", ct)
+	}
+	if n := countTasks(t, db); n != 1 {
+		t.Errorf("Expected one task, got %d", n)
+	}
+
+	resp, body := postIdempotent(t, app, "/tasks", "key-1", `{"title":"Other","priority":"Low"}`, nil)
+	var p handlers.Problem
+	if err := json.Unmarshal([]byte(body), &p); err != nil || resp.StatusCode != http.StatusUnprocessableEntity || p.Code != handlers.CodeIdempotencyKeyReused {
+		t.Errorf("Expected a reused key to be rejected with 422, got %d %s", resp.StatusCode, body)
+	}
+
+	// Keys belong to their client.
+	if resp, _ := postIdempotent(t, app, "/tasks", "key-1", task, map[string]string{"Authorization": "Bearer other"}); resp.StatusCode != http.StatusCreated ||
+		resp.Header.Get(handlers.HeaderIdempotentReplayed) != "" {
+		t.Errorf("Expected another client's key to be independent, got %d", resp.StatusCode)
+	}
+	if resp, _ := postIdempotent(t, app, "/tasks", "bad key", task, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected an invalid key to be rejected, got %d", resp.StatusCode)
+	}
+
+	// Subtasks, and client errors, are replayed too.
+	if resp, _ := postIdempotent(t, app, "/tasks/1/subtasks", "key-2", `{"title":"Child"}`, nil); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected the subtask to be created, got %d", resp.StatusCode)
+	}
+	if resp, _ := postIdempotent(t, app, "/tasks/1/subtasks", "key-2", `{"title":"Child"}`, nil); resp.Header.Get(handlers.HeaderIdempotentReplayed) != "true" {
+		t.Errorf("Expected the subtask response to be replayed, got %d", resp.StatusCode)
+	}
+	for i := 0; i < 2; i++ {
+		resp, _ := postIdempotent(t, app, "/tasks", "key-3", `{"title":"Bad","priority":"Urgent"}`, nil)
+		if resp.StatusCode != http.StatusBadRequest || (i == 1) != (resp.Header.Get(handlers.HeaderIdempotentReplayed) == "true") {
+			t.Errorf("Expected the validation error to be stored and replayed, got %d on attempt %d", resp.StatusCode, i+1)
+		}
+	}
+}
+
+func TestIdempotentServerErrorsAreNotKept(t *testing.T) {
+	app, db := newIdempotentTestApp(t)
+	if err := db.Migrator().RenameTable("tasks", "tasks_away"); err != nil {
+		t.Fatalf("Failed to rename table: %v", err)
+	}
+	if resp, _ := postIdempotent(t, app, "/tasks", "key-1", `{"title":"Once","priority":"Low"}`, nil); resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", resp.StatusCode)
+	}
+	if err := db.Migrator().RenameTable("tasks_away", "tasks"); err != nil {
+		t.Fatalf("Failed to rename table: %v", err)
+	}
+	resp, _ := postIdempotent(t, app, "/tasks", "key-1", `{"title":"Once","priority":"Low"}`, nil)
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get(handlers.HeaderIdempotentReplayed) != "" {
+		t.Errorf("Expected the retry to run again, got %d", resp.StatusCode)
+	}
+}
+
+func TestIdempotentConcurrentRetries(t *testing.T) {
+	app, db := newIdempotentTestApp(t)
+
+	const n = 8
+	var wg sync.WaitGroup
+	statuses := make([]int, n)
+	for i := range statuses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, _ := postIdempotent(t, app, "/tasks", "key-1", `{"title":"Once","priority":"Low"}`, nil)
+			statuses[i] = resp.StatusCode
+		}()
+	}
+	wg.Wait()
+
+	for _, status := range statuses {
+		if status != http.StatusCreated && status != http.StatusConflict {
+			t.Errorf("Expected 201 or 409, got %v", statuses)
+			break
+		}
+	}
+	if got := countTasks(t, db); got != 1 {
+		t.Errorf("Expected one task from %d concurrent retries, got %d", n, got)
+	}
+}
+
+func TestIdempotencyStoreExpiry(t *testing.T) {
+	db := openTestDB()
+	store := idempotency.NewGormStore(db)
+	ctx := context.Background()
+	start := time.Now()
+	rec := idempotency.Record{ID: idempotency.ID("client", "key"), Fingerprint: "f", ExpiresAt: start.Add(time.Hour)}
+
+	if existing, err := store.Claim(ctx, rec, start); err != nil || existing != nil {
+		t.Fatalf("Expected to claim the key, got %+v, %v", existing, err)
+	}
+	if existing, err := store.Claim(ctx, rec, start.Add(time.Second)); err != nil || existing == nil || !existing.Pending() {
+		t.Fatalf("Expected the key to be held, got %+v, %v", existing, err)
+	}
+	// A request that never finished gives up its key after LockTimeout.
+	later := start.Add(idempotency.LockTimeout + time.Second)
+	if existing, err := store.Claim(ctx, rec, later); err != nil || existing != nil {
+		t.Fatalf("Expected to take over an abandoned key, got %+v, %v", existing, err)
+	}
+
+	rec.Status, rec.Body, rec.ExpiresAt = http.StatusCreated, []byte("{}"), later.Add(time.Hour)
+	if err := store.Complete(ctx, rec); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if existing, err := store.Claim(ctx, rec, later.Add(time.Minute)); err != nil || existing == nil || existing.Status != http.StatusCreated {
+		t.Fatalf("Expected the stored response, got %+v, %v", existing, err)
+	}
+	if existing, err := store.Claim(ctx, rec, later.Add(2*time.Hour)); err != nil || existing != nil {
+		t.Errorf("Expected an expired key to be claimable again, got %+v, %v", existing, err)
+	}
+}
+
+func TestClientRetriesPOSTOnce(t *testing.T) {
+	app, db := newIdempotentTestApp(t)
+	backend := httptest.NewServer(adaptor.FiberApp(app))
+	defer backend.Close()
+
+	// The first attempt reaches the server, but its response is lost.
+	var calls int32
+	var keys []string
+	lossy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(handlers.HeaderIdempotencyKey))
+		req, _ := http.NewRequest(r.Method, backend.URL+r.URL.String(), r.Body)
+		req.Header = r.Header.Clone()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer lossy.Close()
+
+	c := client.New(lossy.URL, client.WithRetries(2, time.Millisecond), client.WithRetryPOST())
+	task, err := c.CreateTask(context.Background(), client.TaskInput{Title: "Once", Priority: client.PriorityLow})
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("Expected both attempts to carry the same Idempotency-Key, got %q", keys)
+	}
+	if n := countTasks(t, db); n != 1 || task.ID != 1 {
+		t.Errorf("Expected the retry to return the first task, got %d tasks and %+v", n, task)
+	}
+}
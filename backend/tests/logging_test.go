@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo/internal/handlers"
+	"todo/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newLoggedTestApp returns the HTTP API logging JSON records into the
+// returned buffer.
+func newLoggedTestApp(sampleRate float64) (*fiber.App, *bytes.Buffer) {
+	svc, db := newTestService()
+	db.Create(&models.Task{Title: "Logged", Priority: "Low"})
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	app := handlers.NewApp(handlers.New(svc), nil, handlers.WithLogger(logger), handlers.WithLogSampleRate(sampleRate))
+	return app, &buf
+}
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Log line %q is not JSON: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAccessLog(t *testing.T) {
+	app, buf := newLoggedTestApp(1)
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+	req.Header.Set("X-Request-ID", "trace-abc-123")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	if got := resp.Header.Get("X-Request-ID"); got != "trace-abc-123" {
+		t.Errorf("Expected the request ID to be propagated, got %q", got)
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 1 {
+		t.Fatalf("Expected one log record, got %v", records)
+	}
+	r := records[0]
+	if r["msg"] != "request" || r["level"] != "INFO" || r["request_id"] != "trace-abc-123" ||
+		r["method"] != "GET" || r["path"] != "/tasks/1" || r["route"] != "/tasks/:id" || r["status"] != float64(200) {
+		t.Errorf("Unexpected log record %v", r)
+	}
+	if _, ok := r["latency"]; !ok {
+		t.Errorf("Expected a latency in %v", r)
+	}
+	if bytes, _ := r["bytes"].(float64); bytes == 0 {
+		t.Errorf("Expected the response size in %v", r)
+	}
+}
+
+func TestAccessLogUser(t *testing.T) {
+	app, buf := newLoggedTestApp(1)
+	for _, token := range []string{"", "alice-token"} {
+		req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if _, err := app.Test(req); err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected two log records, got %v", records)
+	}
+	if _, ok := records[0]["user"]; ok {
+		t.Errorf("Expected no user without a token, got %v", records[0])
+	}
+	// The caller is logged by a hash of their token, never the token.
+	user, _ := records[1]["user"].(string)
+	if !strings.HasPrefix(user, "token:") || len(user) != len("token:")+32 || strings.Contains(buf.String(), "alice-token") {
+		t.Errorf("Expected the hashed token as user, got %v", records[1])
+	}
+}
+
+func TestRequestIDAssigned(t *testing.T) {
+	app, _ := newLoggedTestApp(1)
+
+	for _, incoming := range []string{"", "has spaces", strings.Repeat("x", 200)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if incoming != "" {
+			req.Header.Set("X-Request-ID", incoming)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		if got := resp.Header.Get("X-Request-ID"); got == "" || got == incoming {
+			t.Errorf("Expected a new request ID for %q, got %q", incoming, got)
+		}
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	app, buf := newLoggedTestApp(0)
+
+	for _, path := range []string{"/tasks", "/tasks/42", "/nope"} {
+		if _, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil)); err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected only the failed requests to be logged, got %v", records)
+	}
+	for _, r := range records {
+		if r["level"] != "WARN" || r["status"] != float64(404) {
+			t.Errorf("Unexpected log record %v", r)
+		}
+	}
+	if _, ok := records[1]["route"]; ok {
+		t.Errorf("Expected no route for an unmatched request, got %v", records[1])
+	}
+}
+
+func TestDatabaseErrorsLogged(t *testing.T) {
+	svc, db := newTestService()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	app := handlers.NewApp(handlers.New(svc), nil, handlers.WithLogger(logger))
+	if err := db.Migrator().DropTable(&models.Subtask{}, &models.Task{}); err != nil {
+		t.Fatalf("Failed to drop tables: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set("X-Request-ID", "db-down")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", resp.StatusCode)
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("Expected the cause and the access log, got %v", records)
+	}
+	cause, access := records[0], records[1]
+	if cause["level"] != "ERROR" || cause["request_id"] != "db-down" || cause["reason"] != "list_tasks_failed" ||
+		!strings.Contains(cause["error"].(string), "tasks") {
+		t.Errorf("Unexpected cause record %v", cause)
+	}
+	if access["level"] != "ERROR" || access["status"] != float64(500) {
+		t.Errorf("Unexpected access record %v", access)
+	}
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo/internal/handlers"
+	"todo/internal/metrics"
+	"todo/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"gorm.io/gorm"
+)
+
+// newMetricsTestApp returns the HTTP API with every metric of the server
+// enabled, on a fresh test database.
+func newMetricsTestApp(t *testing.T) (*fiber.App, *gorm.DB) {
+	t.Helper()
+	svc, db := newTestService()
+	m := metrics.New()
+	if err := m.InstrumentDB(db, "test"); err != nil {
+		t.Fatalf("InstrumentDB failed: %v", err)
+	}
+	if err := m.RegisterTaskCounts(svc.OpenTaskCounts); err != nil {
+		t.Fatalf("RegisterTaskCounts failed: %v", err)
+	}
+	return handlers.NewApp(handlers.New(svc), nil, handlers.WithLogger(discardLogger), handlers.WithMetrics(m)), db
+}
+
+func scrape(t *testing.T, app *fiber.App) map[string]*dto.MetricFamily {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if err != nil {
+		t.Fatalf("Failed to scrape: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to parse metrics: %v", err)
+	}
+	return families
+}
+
+// metricValue returns the value of the series of name with exactly labels,
+// and whether it exists. Histograms yield their sample count.
+func metricValue(families map[string]*dto.MetricFamily, name string, labels map[string]string) (float64, bool) {
+	family, ok := families[name]
+	if !ok {
+		return 0, false
+	}
+	for _, m := range family.GetMetric() {
+		if len(m.GetLabel()) != len(labels) {
+			continue
+		}
+		match := true
+		for _, l := range m.GetLabel() {
+			if labels[l.GetName()] != l.GetValue() {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		switch {
+		case m.Counter != nil:
+			return m.GetCounter().GetValue(), true
+		case m.Gauge != nil:
+			return m.GetGauge().GetValue(), true
+		case m.Histogram != nil:
+			return float64(m.GetHistogram().GetSampleCount()), true
+		}
+	}
+	return 0, false
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	app, db := newMetricsTestApp(t)
+	past := time.Now().AddDate(0, 0, -2)
+	future := time.Now().AddDate(0, 0, 2)
+	db.Create(&[]models.Task{
+		{Title: "Overdue", Priority: "High", DueDate: dueAt(past)},
+		{Title: "Upcoming", Priority: "High", DueDate: dueAt(future)},
+		{Title: "Finished", Priority: "Low", DueDate: dueAt(past), Done: true},
+		{Title: "Someday", Priority: "Medium"},
+	})
+
+	for _, path := range []string{"/tasks/1", "/tasks/1", "/tasks/99", "/nope"} {
+		if _, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil)); err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+	}
+	families := scrape(t, app)
+
+	for _, tt := range []struct {
+		name     string
+		labels   map[string]string
+		expected float64
+	}{
+		{"todo_http_requests_total", map[string]string{"method": "GET", "route": "/tasks/:id", "status": "200"}, 2},
+		{"todo_http_requests_total", map[string]string{"method": "GET", "route": "/tasks/:id", "status": "404"}, 1},
+		{"todo_http_requests_total", map[string]string{"method": "GET", "route": metrics.UnmatchedRoute, "status": "404"}, 1},
+		{"todo_http_request_duration_seconds", map[string]string{"method": "GET", "route": "/tasks/:id", "status": "200"}, 2},
+		{"todo_tasks_open", map[string]string{"priority": "High"}, 2},
+		{"todo_tasks_open", map[string]string{"priority": "Medium"}, 1},
+		{"todo_tasks_open", map[string]string{"priority": "Low"}, 0},
+		{"todo_tasks_overdue", map[string]string{"priority": "High"}, 1},
+		{"todo_tasks_overdue", map[string]string{"priority": "Low"}, 0},
+	} {
+		if got, ok := metricValue(families, tt.name, tt.labels); !ok || got != tt.expected {
+			t.Errorf("Expected %s%v = %g, got %g (present: %v)", tt.name, tt.labels, tt.expected, got, ok)
+		}
+	}
+
+	if got, _ := metricValue(families, "todo_db_query_duration_seconds", map[string]string{"operation": "query", "table": "tasks"}); got < 3 {
+		t.Errorf("Expected the task lookups to be timed, got %g queries", got)
+	}
+	if got, ok := metricValue(families, "todo_db_query_errors_total", map[string]string{"operation": "query", "table": "tasks"}); ok && got != 0 {
+		t.Errorf("Expected a missing task not to count as a query error, got %g", got)
+	}
+	if _, ok := metricValue(families, "go_sql_open_connections", map[string]string{"db_name": "test"}); !ok {
+		t.Error("Expected connection pool gauges")
+	}
+}
+
+func TestMetricsCountQueryErrors(t *testing.T) {
+	app, db := newMetricsTestApp(t)
+	if err := db.Migrator().DropTable(&models.Subtask{}, &models.Task{}); err != nil {
+		t.Fatalf("Failed to drop tables: %v", err)
+	}
+
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/tasks", nil)); err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	families := scrape(t, app)
+
+	if got, _ := metricValue(families, "todo_db_query_errors_total", map[string]string{"operation": "query", "table": "tasks"}); got != 1 {
+		t.Errorf("Expected one query error, got %g", got)
+	}
+	if got, _ := metricValue(families, "todo_http_requests_total", map[string]string{"method": "GET", "route": "/tasks", "status": "500"}); got != 1 {
+		t.Errorf("Expected the failed request to be counted, got %g", got)
+	}
+}
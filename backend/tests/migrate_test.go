@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"todo/internal/config"
+	"todo/internal/database"
+	"todo/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// setupMigrateTestDB returns an empty database that is not migrated yet.
+// SQLite uses a file so that every connection sees the same database.
+func setupMigrateTestDB(t *testing.T) (*gorm.DB, config.Database) {
+	cfg := testDBConfig()
+	if cfg.Driver == "sqlite" {
+		cfg.DSN = filepath.Join(t.TempDir(), "todo.db")
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	if err := resetTestDB(db); err != nil {
+		t.Fatalf("failed to reset test database: %v", err)
+	}
+	return db, cfg
+}
+
+func pendingVersions(t *testing.T, db *gorm.DB) []int {
+	t.Helper()
+	pending, err := database.PendingMigrations(db)
+	if err != nil {
+		t.Fatalf("Failed to list pending migrations: %v", err)
+	}
+	var versions []int
+	for _, m := range pending {
+		versions = append(versions, m.Version)
+	}
+	return versions
+}
+
+func TestMigrateUpDownRedo(t *testing.T) {
+	db, _ := setupMigrateTestDB(t)
+	all := len(database.Migrations())
+
+	if got := len(pendingVersions(t, db)); got != all {
+		t.Fatalf("Expected %d pending migrations, got %d", all, got)
+	}
+
+	applied, err := database.MigrateUp(db, 1)
+	if err != nil || len(applied) != 1 || applied[0].Version != 1 {
+		t.Fatalf("Expected to apply migration 1, got %v, %v", applied, err)
+	}
+	if !db.Migrator().HasTable(&models.Task{}) {
+		t.Error("Expected tasks table after migration 1")
+	}
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if got := pendingVersions(t, db); len(got) != 0 {
+		t.Errorf("Expected no pending migrations, got %v", got)
+	}
+	statuses, err := database.MigrationStatuses(db)
+	if err != nil {
+		t.Fatalf("Failed to read statuses: %v", err)
+	}
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			t.Errorf("Expected migration %d to be applied", s.Version)
+		}
+	}
+
+	redone, err := database.MigrateRedo(db)
+	if err != nil || redone.Version != all {
+		t.Fatalf("Expected to redo migration %d, got %v, %v", all, redone.Version, err)
+	}
+	if got := pendingVersions(t, db); len(got) != 0 {
+		t.Errorf("Expected no pending migrations after redo, got %v", got)
+	}
+
+	reverted, err := database.MigrateDown(db, all)
+	if err != nil || len(reverted) != all {
+		t.Fatalf("Expected to revert %d migrations, got %v, %v", all, reverted, err)
+	}
+	if db.Migrator().HasTable(&models.Task{}) {
+		t.Error("Expected tasks table to be dropped")
+	}
+	if _, err := database.MigrateDown(db, 1); !errors.Is(err, database.ErrNoMigrationToRevert) {
+		t.Errorf("Expected ErrNoMigrationToRevert, got %v", err)
+	}
+}
+
+func TestMigrateAdoptsAutoMigratedSchema(t *testing.T) {
+	db, _ := setupMigrateTestDB(t)
+
+	// Databases created before migrations existed were auto-migrated from
+	// the models and already hold data.
+	if err := db.AutoMigrate(&models.Task{}, &models.Subtask{}); err != nil {
+		t.Fatalf("Failed to auto-migrate: %v", err)
+	}
+	task := models.Task{Title: "Existing", Priority: "High", Done: true, Subtasks: []models.Subtask{{Title: "Step"}}}
+	if err := db.Create(&task).Error; err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	var got models.Task
+	if err := db.Preload("Subtasks").First(&got, task.ID).Error; err != nil {
+		t.Fatalf("Failed to load task: %v", err)
+	}
+	if got.Title != "Existing" || len(got.Subtasks) != 1 {
+		t.Errorf("Expected the existing task and subtask to survive, got %+v", got)
+	}
+	var kinds []string
+	if err := db.Model(&models.TaskChange{}).Where("task_id = ?", task.ID).Order("id").Pluck("kind", &kinds).Error; err != nil {
+		t.Fatalf("Failed to load task changes: %v", err)
+	}
+	if strings.Join(kinds, ",") != "created,completed" {
+		t.Errorf("Expected the history of the done task to be backfilled, got %v", kinds)
+	}
+}
+
+func TestInitDBRefusesPendingMigrations(t *testing.T) {
+	_, cfg := setupMigrateTestDB(t)
+
+	_, err := database.InitDB(context.Background(), cfg)
+	if !errors.Is(err, database.ErrPendingMigrations) || !strings.Contains(err.Error(), "migrate up") {
+		t.Fatalf("Expected a pending migration error, got %v", err)
+	}
+
+	cfg.AutoMigrate = true
+	db, err := database.InitDB(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Expected InitDB to migrate, got %v", err)
+	}
+	if got := pendingVersions(t, db); len(got) != 0 {
+		t.Errorf("Expected no pending migrations, got %v", got)
+	}
+
+	cfg.AutoMigrate = false
+	if _, err := database.InitDB(context.Background(), cfg); err != nil {
+		t.Errorf("Expected InitDB to accept a migrated database, got %v", err)
+	}
+}
+
+func TestMigrateConcurrently(t *testing.T) {
+	_, cfg := setupMigrateTestDB(t)
+	if cfg.Driver == "sqlite" {
+		t.Skip("the migration lock is a MySQL/PostgreSQL feature; run with TEST_DB_DRIVER")
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			db, err := database.Open(cfg)
+			if err == nil {
+				err = database.Migrate(db)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Concurrent migration failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo/internal/handlers"
+	"todo/pkg/client"
+)
+
+func TestProblemResponses(t *testing.T) {
+	app, _ := setupTestApp()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedFields []string
+	}{
+		{
+			name:           "Validation",
+			method:         http.MethodPost,
+			path:           "/tasks",
+			body:           `{"priority":"Urgent"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   handlers.CodeValidation,
+			expectedFields: []string{"title:required", "priority:oneof"},
+		},
+		{
+			name:           "Invalid filter",
+			method:         http.MethodGet,
+			path:           "/tasks?sortBy=name",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   handlers.CodeValidation,
+			expectedFields: []string{"sortBy:oneof"},
+		},
+		{
+			name:           "Not found",
+			method:         http.MethodGet,
+			path:           "/tasks/42",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   handlers.CodeTaskNotFound,
+		},
+		{
+			name:           "Unknown route",
+			method:         http.MethodGet,
+			path:           "/nope",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to execute request: %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, handlers.ProblemContentType) {
+				t.Errorf("Expected %s, got %q", handlers.ProblemContentType, ct)
+			}
+
+			var problem handlers.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if problem.Code != tt.expectedCode || problem.Status != tt.expectedStatus || problem.Title != http.StatusText(tt.expectedStatus) {
+				t.Errorf("Unexpected problem %+v", problem)
+			}
+			if problem.RequestID == "" || problem.RequestID != resp.Header.Get("X-Request-ID") {
+				t.Errorf("Expected request ID %q to match the header %q", problem.RequestID, resp.Header.Get("X-Request-ID"))
+			}
+			var fields []string
+			for _, f := range problem.Errors {
+				if f.Message == "" {
+					t.Errorf("Expected a message for field %s", f.Field)
+				}
+				fields = append(fields, f.Field+":"+f.Code)
+			}
+			if !equalStrings(fields, tt.expectedFields) {
+				t.Errorf("Expected field errors %v, got %v", tt.expectedFields, fields)
+			}
+		})
+	}
+}
+
+func TestClientProblemDetails(t *testing.T) {
+	c := client.New(setupClientTestServer(t).URL)
+
+	_, err := c.CreateTask(context.Background(), client.TaskInput{Priority: client.PriorityHigh})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an APIError, got %v", err)
+	}
+	if apiErr.Code != "validation_failed" || apiErr.RequestID == "" || len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "title" {
+		t.Errorf("Unexpected error details %+v", apiErr)
+	}
+}
+
+func TestClientLanguage(t *testing.T) {
+	c := client.New(setupClientTestServer(t).URL, client.WithLanguage("de"))
+
+	_, err := c.GetTask(context.Background(), 42)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected an APIError, got %v", err)
+	}
+	if apiErr.Message != "Aufgabe nicht gefunden" {
+		t.Errorf("Expected a German message, got %q", apiErr.Message)
+	}
+}
@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo/internal/handlers"
+	"todo/internal/health"
+	"todo/internal/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParseLimit(t *testing.T) {
+	for _, tt := range []struct {
+		in       string
+		expected ratelimit.Limit
+		err      bool
+	}{
+		{"60/m", ratelimit.Limit{Requests: 60, Period: time.Minute}, false},
+		{"5/s", ratelimit.Limit{Requests: 5, Period: time.Second}, false},
+		{"1000/h", ratelimit.Limit{Requests: 1000, Period: time.Hour}, false},
+		{"100/10s", ratelimit.Limit{Requests: 100, Period: 10 * time.Second}, false},
+		{"60", ratelimit.Limit{}, true},
+		{"0/m", ratelimit.Limit{}, true},
+		{"10/day", ratelimit.Limit{}, true},
+		{"10/-1s", ratelimit.Limit{}, true},
+	} {
+		got, err := ratelimit.ParseLimit(tt.in)
+		if (err != nil) != tt.err || got != tt.expected {
+			t.Errorf("ParseLimit(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 2, Period: time.Second}
+	start := time.Now()
+	take := func(key string, at time.Duration) ratelimit.Result {
+		res, err := store.Take(context.Background(), key, limit, start.Add(at))
+		if err != nil {
+			t.Fatalf("Take failed: %v", err)
+		}
+		return res
+	}
+
+	if res := take("a", 0); !res.Allowed || res.Remaining != 1 || res.Reset != 500*time.Millisecond {
+		t.Errorf("Expected the first request through with one left, got %+v", res)
+	}
+	if res := take("a", 0); !res.Allowed || res.Remaining != 0 || res.Reset != time.Second {
+		t.Errorf("Expected the burst to be spent, got %+v", res)
+	}
+	if res := take("a", 100*time.Millisecond); res.Allowed || res.RetryAfter != 400*time.Millisecond {
+		t.Errorf("Expected a rejection until the next token, got %+v", res)
+	}
+	if res := take("b", 100*time.Millisecond); !res.Allowed {
+		t.Errorf("Expected another key to have its own bucket, got %+v", res)
+	}
+	// One token refills every 500ms.
+	if res := take("a", 500*time.Millisecond); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("Expected a refilled token, got %+v", res)
+	}
+	// Idle buckets refill to the budget, never beyond.
+	if res := take("a", time.Hour); !res.Allowed || res.Remaining != 1 {
+		t.Errorf("Expected a full bucket, got %+v", res)
+	}
+}
+
+func newRateLimitedTestApp(t *testing.T, opts ...handlers.AppOption) *fiber.App {
+	t.Helper()
+	svc, _ := newTestService()
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(),
+		ratelimit.Limit{Requests: 5, Period: time.Minute},
+		ratelimit.Limit{Requests: 2, Period: time.Minute},
+		ratelimit.Route{Method: http.MethodPost, Path: "/tasks/:id/subtasks", Limit: ratelimit.Limit{Requests: 1, Period: time.Minute}},
+	)
+	opts = append([]handlers.AppOption{handlers.WithLogger(discardLogger), handlers.WithRateLimiter(limiter)}, opts...)
+	return handlers.NewApp(handlers.New(svc), nil, opts...)
+}
+
+func postJSON(t *testing.T, app *fiber.App, path, body string, headers map[string]string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	return resp
+}
+
+func TestRateLimit(t *testing.T) {
+	app := newRateLimitedTestApp(t)
+	task := `{"title":"Spam","priority":"Low"}`
+
+	for i := 0; i < 2; i++ {
+		resp := postJSON(t, app, "/tasks", task, nil)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected request %d within budget, got %d", i+1, resp.StatusCode)
+		}
+		if got := resp.Header.Get("RateLimit-Remaining"); got != []string{"1", "0"}[i] {
+			t.Errorf("Expected RateLimit-Remaining %d, got %q", 1-i, got)
+		}
+	}
+	resp := postJSON(t, app, "/tasks", task, map[string]string{"Accept-Language": "de"})
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Retry-After"); got != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", got)
+	}
+	for header, expected := range map[string]string{"RateLimit-Limit": "2", "RateLimit-Remaining": "0", "RateLimit-Reset": "60", "RateLimit-Policy": "2;w=60"} {
+		if got := resp.Header.Get(header); got != expected {
+			t.Errorf("Expected %s %q, got %q", header, expected, got)
+		}
+	}
+	var p handlers.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if p.Code != handlers.CodeRateLimited || !strings.Contains(p.Detail, "30 Sekunden") {
+		t.Errorf("Expected a localized rate_limited problem, got %+v", p)
+	}
+
+	// Reads have a budget of their own, and so does each token.
+	if resp, _ := app.Test(httptest.NewRequest(http.MethodGet, "/tasks", nil)); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected reads to be unaffected, got %d", resp.StatusCode)
+	}
+	if resp := postJSON(t, app, "/tasks", task, map[string]string{"Authorization": "Bearer other"}); resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected another token to have its own budget, got %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimitCapsTokensPerIP(t *testing.T) {
+	app := newRateLimitedTestApp(t)
+	task := `{"title":"Spam","priority":"Low"}`
+
+	// Made-up tokens from one IP share the budget of ten clients, 2 writes
+	// each.
+	for i := 0; i < 21; i++ {
+		resp := postJSON(t, app, "/tasks", task, map[string]string{"Authorization": fmt.Sprintf("Bearer token-%d", i)})
+		expected := http.StatusCreated
+		if i == 20 {
+			expected = http.StatusTooManyRequests
+		}
+		if resp.StatusCode != expected {
+			t.Fatalf("Expected request %d with a new token to get %d, got %d", i+1, expected, resp.StatusCode)
+		}
+	}
+}
+
+func TestRateLimitRoutes(t *testing.T) {
+	app := newRateLimitedTestApp(t)
+	postJSON(t, app, "/tasks", `{"title":"Parent","priority":"Low"}`, nil)
+
+	if resp := postJSON(t, app, "/tasks/1/subtasks", `{"title":"One"}`, nil); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected the first subtask within budget, got %d", resp.StatusCode)
+	}
+	if resp := postJSON(t, app, "/tasks/1/subtasks", `{"title":"Two"}`, nil); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected the route budget of 1/m to apply, got %d", resp.StatusCode)
+	}
+	// Routing ignores case, and so do route budgets.
+	if resp := postJSON(t, app, "/Tasks/1/SUBTASKS/", `{"title":"Three"}`, nil); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected the route budget to apply to a mixed-case path, got %d", resp.StatusCode)
+	}
+	// The route budget is separate from the write budget.
+	if resp := postJSON(t, app, "/tasks", `{"title":"Second","priority":"Low"}`, nil); resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected the write budget to be untouched, got %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimitExemptsProbes(t *testing.T) {
+	app := newRateLimitedTestApp(t, handlers.WithHealth(health.New()))
+	for i := 0; i < 10; i++ {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if err != nil {
+			t.Fatalf("Failed to execute request: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("RateLimit-Limit") != "" {
+			t.Fatalf("Expected probes not to be rate limited, got %d", resp.StatusCode)
+		}
+	}
+}
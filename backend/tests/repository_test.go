@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+	"todo/internal/models"
+	"todo/internal/repository"
+	"todo/internal/services"
+)
+
+// repositoryBackends builds a Service on each repository implementation, so
+// the behaviour the handlers rely on is checked against all of them.
+var repositoryBackends = map[string]func() *services.Service{
+	"memory": func() *services.Service {
+		store := repository.NewMemoryStore()
+		return services.New(store.Tasks(), store.Subtasks(), store.Views())
+	},
+	"gorm": func() *services.Service {
+		svc, _ := newTestService()
+		return svc
+	},
+}
+
+func TestRepositories(t *testing.T) {
+	for name, newService := range repositoryBackends {
+		t.Run(name, func(t *testing.T) {
+			svc := newService()
+			due := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+
+			for _, task := range []models.Task{
+				{Title: "Low", Description: "Renew the passport", Priority: "Low", Assignee: "Alice", DueDate: dueAt(due),
+					Subtasks: []models.Subtask{{Title: "Photo"}, {Title: "Form"}}},
+				{Title: "High", Priority: "High", Assignee: "Bob", Done: true, DueDate: dueAt(due.AddDate(0, 0, 1))},
+				{Title: "Medium", Priority: "Medium", Assignee: "Alice"},
+			} {
+				if err := svc.CreateTask(&task); err != nil {
+					t.Fatalf("CreateTask failed: %v", err)
+				}
+			}
+
+			for _, tt := range []struct {
+				filter models.TaskFilter
+				titles []string
+			}{
+				{models.TaskFilter{Assignee: "Alice"}, []string{"Low", "Medium"}},
+				{models.TaskFilter{Search: "passport"}, []string{"Low"}},
+				{models.TaskFilter{Status: "completed"}, []string{"High"}},
+				{models.TaskFilter{SortBy: "priority"}, []string{"High", "Medium", "Low"}},
+				{models.TaskFilter{SortBy: "dueDate"}, []string{"Low", "High", "Medium"}},
+			} {
+				tasks, err := svc.ListTasks(tt.filter)
+				if err != nil {
+					t.Fatalf("ListTasks(%+v) failed: %v", tt.filter, err)
+				}
+				if titles := taskTitles(tasks); !equalStrings(titles, tt.titles) {
+					t.Errorf("ListTasks(%+v) = %v, expected %v", tt.filter, titles, tt.titles)
+				}
+			}
+
+			task, err := svc.GetTask(1)
+			if err != nil || len(task.Subtasks) != 2 || task.Subtasks[0].Title != "Photo" {
+				t.Fatalf("Expected task 1 with its subtasks, got %+v, %v", task, err)
+			}
+			summary, err := svc.SummarizeTasks(models.TaskFilter{})
+			if err != nil || summary != (services.TaskSummary{Total: 3, Done: 1, Open: 2}) {
+				t.Errorf("Unexpected summary %+v, %v", summary, err)
+			}
+			counts, err := svc.OpenTaskCounts(due.Add(12 * time.Hour))
+			expectedCounts := []models.PriorityCount{{Priority: "High"}, {Priority: "Medium", Open: 1}, {Priority: "Low", Open: 1, Overdue: 1}}
+			if err != nil || len(counts) != len(expectedCounts) {
+				t.Fatalf("Unexpected open counts %+v, %v", counts, err)
+			}
+			for i := range counts {
+				if counts[i] != expectedCounts[i] {
+					t.Errorf("Expected open counts %+v, got %+v", expectedCounts, counts)
+					break
+				}
+			}
+
+			if err := svc.SetTaskDone(&task, true); err != nil {
+				t.Fatalf("SetTaskDone failed: %v", err)
+			}
+			if got, _ := svc.FindTask(1); !got.Done || len(got.Subtasks) != 0 {
+				t.Errorf("Expected task 1 done and loaded without subtasks, got %+v", got)
+			}
+			subtask := task.Subtasks[1]
+			if err := svc.DeleteSubtask(&subtask); err != nil {
+				t.Fatalf("DeleteSubtask failed: %v", err)
+			}
+			if subtasks, _ := svc.ListSubtasks(1); len(subtasks) != 1 {
+				t.Errorf("Expected one subtask left, got %v", subtasks)
+			}
+			if err := svc.DeleteTask(&task); err != nil {
+				t.Fatalf("DeleteTask failed: %v", err)
+			}
+			if _, err := svc.GetTask(1); !errors.Is(err, services.ErrTaskNotFound) {
+				t.Errorf("Expected ErrTaskNotFound, got %v", err)
+			}
+			if _, err := svc.FindSubtask(99); !errors.Is(err, services.ErrSubtaskNotFound) {
+				t.Errorf("Expected ErrSubtaskNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMemoryServicesAreIndependent(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		t.Run("instance", func(t *testing.T) {
+			t.Parallel()
+			svc := repositoryBackends["memory"]()
+			events, unsubscribe := svc.Subscribe()
+			defer unsubscribe()
+
+			task := models.Task{Title: "Only here", Priority: "Low"}
+			if err := svc.CreateTask(&task); err != nil {
+				t.Fatalf("CreateTask failed: %v", err)
+			}
+			if tasks, _ := svc.ListTasks(models.TaskFilter{}); len(tasks) != 1 || task.ID != 1 {
+				t.Errorf("Expected only this instance's task, got %v", tasks)
+			}
+			if event := <-events; event.Type != services.TaskCreated || len(events) != 0 {
+				t.Errorf("Expected exactly this instance's event, got %v", event)
+			}
+		})
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	svc := repositoryBackends["memory"]()
+	events, unsubscribe := svc.Subscribe()
+	defer unsubscribe()
+
+	// Nobody reads, so the buffer fills up and the subscription ends
+	// rather than silently missing changes.
+	for i := 0; i < 100; i++ {
+		task := models.Task{Title: fmt.Sprintf("Task %d", i), Priority: "Low"}
+		if err := svc.CreateTask(&task); err != nil {
+			t.Fatalf("CreateTask failed: %v", err)
+		}
+	}
+	received := 0
+	for range events {
+		received++
+	}
+	if received == 0 || received >= 100 || svc.Closed() {
+		t.Errorf("Expected the channel to close after a full buffer of events, got %d events, closed %v", received, svc.Closed())
+	}
+
+	// Others still subscribe as usual.
+	events, unsubscribe = svc.Subscribe()
+	defer unsubscribe()
+	task := models.Task{Title: "After", Priority: "Low"}
+	if err := svc.CreateTask(&task); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if event := <-events; event.TaskID != task.ID {
+		t.Errorf("Expected an event for the new task, got %v", event)
+	}
+}
+
+func taskTitles(tasks []models.Task) []string {
+	titles := make([]string, len(tasks))
+	for i, task := range tasks {
+		titles[i] = task.Title
+	}
+	return titles
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"todo/internal/models"
+	"todo/internal/seed"
+
+	"gorm.io/gorm"
+)
+
+func countRows(t *testing.T, db *gorm.DB) (tasks, subtasks int64) {
+	t.Helper()
+	if err := db.Model(&models.Task{}).Count(&tasks).Error; err != nil {
+		t.Fatalf("Failed to count tasks: %v", err)
+	}
+	if err := db.Model(&models.Subtask{}).Count(&subtasks).Error; err != nil {
+		t.Fatalf("Failed to count subtasks: %v", err)
+	}
+	return tasks, subtasks
+}
+
+func TestSeedSamplesIsIdempotent(t *testing.T) {
+	db := openTestDB()
+	now := time.Now()
+
+	first, err := seed.Apply(db, seed.Samples(now))
+	if err != nil {
+		t.Fatalf("Failed to seed: %v", err)
+	}
+	if first.TasksCreated != 10 || first.SubtasksCreated != 30 || first.TasksSkipped != 0 {
+		t.Errorf("Unexpected first result: %+v", first)
+	}
+
+	second, err := seed.Apply(db, seed.Samples(now.Add(time.Hour)))
+	if err != nil {
+		t.Fatalf("Failed to seed again: %v", err)
+	}
+	if second.TasksCreated != 0 || second.SubtasksCreated != 0 || second.TasksSkipped != 10 {
+		t.Errorf("Unexpected second result: %+v", second)
+	}
+	if tasks, subtasks := countRows(t, db); tasks != 10 || subtasks != 30 {
+		t.Errorf("Expected 10 tasks and 30 subtasks, got %d and %d", tasks, subtasks)
+	}
+}
+
+func TestSeedFixtureFiles(t *testing.T) {
+	db := openTestDB()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "tasks.yaml")
+	os.WriteFile(yamlPath, []byte(`
+tasks:
+  - title: Write report
+    priority: High
+    assignee: Alice
+    due_date: 2025-09-30
+    subtasks:
+      - title: Collect data
+        done: true
+  - title: Book venue
+    due_in_days: 3
+`), 0o644)
+	tasks, err := seed.LoadFile(yamlPath, now)
+	if err != nil {
+		t.Fatalf("Failed to load YAML fixtures: %v", err)
+	}
+	if len(tasks) != 2 || tasks[1].Priority != "Medium" || !tasks[1].DueDate.Equal(now.AddDate(0, 0, 3)) {
+		t.Fatalf("Unexpected YAML fixtures: %+v", tasks)
+	}
+	if _, err := seed.Apply(db, tasks); err != nil {
+		t.Fatalf("Failed to seed: %v", err)
+	}
+
+	// The JSON file repeats a task with an extra subtask: only the subtask
+	// is new.
+	jsonPath := filepath.Join(dir, "tasks.json")
+	os.WriteFile(jsonPath, []byte(`{"tasks": [
+		{"title": "Write report", "assignee": "Alice", "priority": "High",
+		 "subtasks": [{"title": "Collect data"}, {"title": "Draft summary"}]}
+	]}`), 0o644)
+	tasks, err = seed.LoadFile(jsonPath, now)
+	if err != nil {
+		t.Fatalf("Failed to load JSON fixtures: %v", err)
+	}
+	result, err := seed.Apply(db, tasks)
+	if err != nil {
+		t.Fatalf("Failed to seed: %v", err)
+	}
+	if result.TasksCreated != 0 || result.TasksSkipped != 1 || result.SubtasksCreated != 1 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if tasks, subtasks := countRows(t, db); tasks != 2 || subtasks != 2 {
+		t.Errorf("Expected 2 tasks and 2 subtasks, got %d and %d", tasks, subtasks)
+	}
+
+	for name, content := range map[string]string{
+		"priority.yaml": "tasks:\n  - title: Bad\n    priority: Urgent\n",
+		"unknown.yaml":  "tasks:\n  - title: Bad\n    owner: Alice\n",
+		"date.json":     `{"tasks": [{"title": "Bad", "due_date": "tomorrow"}]}`,
+		"tasks.txt":     "",
+	} {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0o644)
+		if _, err := seed.LoadFile(path, now); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected an error naming %s, got %v", name, err)
+		}
+	}
+}
+
+func TestSeedGenerate(t *testing.T) {
+	db := openTestDB()
+	now := time.Now()
+
+	tasks := seed.Generate(1200, 42, now)
+	again := seed.Generate(1200, 42, now)
+	for i := range tasks {
+		if tasks[i].Title != again[i].Title || tasks[i].Assignee != again[i].Assignee || len(tasks[i].Subtasks) != len(again[i].Subtasks) {
+			t.Fatalf("Expected the same seed to generate the same tasks, task %d differs", i)
+		}
+	}
+
+	priorities := map[string]int{}
+	withSubtasks := 0
+	for _, task := range tasks {
+		priorities[task.Priority]++
+		if len(task.Subtasks) > 0 {
+			withSubtasks++
+		}
+	}
+	if priorities["Medium"] <= priorities["High"] || priorities["Low"] == 0 {
+		t.Errorf("Unexpected priority distribution: %v", priorities)
+	}
+	if withSubtasks == 0 || withSubtasks == len(tasks) {
+		t.Errorf("Expected some but not all tasks to have subtasks, got %d", withSubtasks)
+	}
+
+	first, err := seed.Apply(db, tasks)
+	if err != nil {
+		t.Fatalf("Failed to seed: %v", err)
+	}
+	if first.TasksCreated != 1200 {
+		t.Errorf("Expected 1200 tasks, got %+v", first)
+	}
+	second, err := seed.Apply(db, again)
+	if err != nil {
+		t.Fatalf("Failed to seed again: %v", err)
+	}
+	if second.TasksCreated != 0 || second.SubtasksCreated != 0 {
+		t.Errorf("Expected reseeding to insert nothing, got %+v", second)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"todo/internal/handlers"
+	"todo/internal/models"
+)
+
+func TestTaskStats(t *testing.T) {
+	for name, newService := range repositoryBackends {
+		t.Run(name, func(t *testing.T) {
+			svc := newService()
+			// A Wednesday: the week ends in five days.
+			now := time.Date(2030, 1, 2, 12, 0, 0, 0, time.Local)
+
+			for _, task := range []models.Task{
+				{Title: "Overdue", Priority: "High", Assignee: "Alice", DueDate: dueAt(now.Add(-time.Hour)),
+					Subtasks: []models.Subtask{{Title: "One", Done: true}, {Title: "Two"}}},
+				{Title: "Friday", Priority: "High", Assignee: "Alice", DueDate: dueAt(now.AddDate(0, 0, 2))},
+				{Title: "Next week", Priority: "Low", Assignee: "Bob", DueDate: dueAt(now.AddDate(0, 0, 6))},
+				{Title: "Done", Priority: "Low", Done: true, DueDate: dueAt(now.Add(-time.Hour)),
+					Subtasks: []models.Subtask{{Title: "Only", Done: true}}},
+				{Title: "Undated", Priority: "Medium"},
+			} {
+				if err := svc.CreateTask(&task); err != nil {
+					t.Fatalf("CreateTask failed: %v", err)
+				}
+			}
+
+			created := time.Now()
+			stats, err := svc.TaskStats(models.TaskFilter{}, now)
+			if err != nil {
+				t.Fatalf("TaskStats failed: %v", err)
+			}
+			if stats.Total != 5 || stats.Done != 1 || stats.Open != 4 || stats.Overdue != 1 || stats.DueThisWeek != 1 {
+				t.Errorf("Unexpected counts %+v", stats)
+			}
+			if age := now.Sub(created).Seconds(); math.Abs(stats.AverageOpenAge-age) > 2 {
+				t.Errorf("Expected an average open age of about %.0fs, got %.0fs", age, stats.AverageOpenAge)
+			}
+
+			expectedPriorities := []models.GroupCount{
+				{Name: "High", Total: 2, Open: 2},
+				{Name: "Medium", Total: 1, Open: 1},
+				{Name: "Low", Total: 2, Done: 1, Open: 1},
+			}
+			if !equalGroups(stats.ByPriority, expectedPriorities) {
+				t.Errorf("Expected priorities %+v, got %+v", expectedPriorities, stats.ByPriority)
+			}
+			expectedAssignees := []models.GroupCount{
+				{Name: "", Total: 2, Done: 1, Open: 1},
+				{Name: "Alice", Total: 2, Open: 2},
+				{Name: "Bob", Total: 1, Open: 1},
+			}
+			if !equalGroups(stats.ByAssignee, expectedAssignees) {
+				t.Errorf("Expected assignees %+v, got %+v", expectedAssignees, stats.ByAssignee)
+			}
+
+			st := stats.Subtasks
+			if st.Total != 3 || st.Done != 2 || st.TasksWithSubtasks != 2 ||
+				math.Abs(st.CompletionRatio-2.0/3) > 1e-9 || math.Abs(st.AverageTaskCompletion-0.75) > 1e-9 {
+				t.Errorf("Unexpected subtask counts %+v", st)
+			}
+
+			stats, err = svc.TaskStats(models.TaskFilter{Assignee: "Alice"}, now)
+			if err != nil || stats.Total != 2 || stats.Subtasks.Total != 2 || len(stats.ByAssignee) != 1 || len(stats.ByPriority) != 3 {
+				t.Errorf("Unexpected stats of Alice's tasks %+v, %v", stats, err)
+			}
+		})
+	}
+}
+
+func equalGroups(a, b []models.GroupCount) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGetStats(t *testing.T) {
+	app, db := setupTestApp()
+	db.Create(&models.Task{Title: "Open", Priority: "High", Assignee: "Alice"})
+	db.Create(&models.Task{Title: "Closed", Priority: "Low", Assignee: "Bob", Done: true})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/stats?status=pending", nil))
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	var stats models.TaskStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || stats.Total != 1 || stats.Open != 1 || len(stats.ByAssignee) != 1 || stats.ByAssignee[0].Name != "Alice" {
+		t.Errorf("Expected the stats of the pending task, got %d %+v", resp.StatusCode, stats)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/stats?status=later", nil))
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	var p handlers.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || resp.StatusCode != http.StatusBadRequest || p.Code != handlers.CodeValidation {
+		t.Errorf("Expected a validation problem, got %d %+v", resp.StatusCode, p)
+	}
+}
@@ -5,44 +5,18 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"todo/internal/database"
-	"todo/internal/handlers"
 	"todo/internal/models"
 
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 	"testing"
 )
 
-func setupSubtaskTestApp() *fiber.App {
-	// Initialize in-memory SQLite database
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		panic("failed to connect to test database")
-	}
-	database.DB = db
-	db.AutoMigrate(&models.Task{}, &models.Subtask{})
-
-	// Initialize Fiber app
-	app := fiber.New()
-
-	// Register subtask routes
-	app.Post("/tasks/:id/subtasks", handlers.CreateSubtask)
-	app.Get("/tasks/:id/subtasks", handlers.GetSubtasks)
-	app.Put("/subtasks/:id", handlers.UpdateSubtask)
-	app.Delete("/subtasks/:id", handlers.DeleteSubtask)
-	app.Patch("/subtasks/:id/done", handlers.UpdateSubtaskDone)
-
-	return app
-}
-
 func TestCreateSubtask(t *testing.T) {
-	app := setupSubtaskTestApp()
+	app, db := setupTestApp()
 
 	// Create a task for testing
 	task := models.Task{Title: "Test Task", Priority: "Medium"}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 
@@ -72,7 +46,7 @@ func TestCreateSubtask(t *testing.T) {
 			taskID:         "1",
 			body:           `{}`,
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Key: 'Subtask.Title' Error:Field validation for 'Title' failed on the 'required' tag",
+			expectedError:  "title is required",
 		},
 		{
 			name:           "Invalid JSON",
@@ -95,12 +69,12 @@ func TestCreateSubtask(t *testing.T) {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
 			if tt.expectedError != "" {
-				var result map[string]string
+				var result map[string]interface{}
 				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 					t.Fatalf("Failed to decode response: %v", err)
 				}
-				if result["error"] != tt.expectedError {
-					t.Errorf("Expected error %q, got %q", tt.expectedError, result["error"])
+				if result["detail"] != tt.expectedError {
+					t.Errorf("Expected error %q, got %q", tt.expectedError, result["detail"])
 				}
 			}
 		})
@@ -108,15 +82,15 @@ func TestCreateSubtask(t *testing.T) {
 }
 
 func TestGetSubtasks(t *testing.T) {
-	app := setupSubtaskTestApp()
+	app, db := setupTestApp()
 
 	// Create a task and subtask for testing
 	task := models.Task{Title: "Test Task", Priority: "Medium"}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 	subtask := models.Subtask{TaskID: task.ID, Title: "Test Subtask"}
-	if err := database.DB.Create(&subtask).Error; err != nil {
+	if err := db.Create(&subtask).Error; err != nil {
 		t.Fatalf("Failed to create test subtask: %v", err)
 	}
 
@@ -142,15 +116,15 @@ func TestGetSubtasks(t *testing.T) {
 }
 
 func TestUpdateSubtask(t *testing.T) {
-	app := setupSubtaskTestApp()
+	app, db := setupTestApp()
 
 	// Create a task and subtask for testing
 	task := models.Task{Title: "Test Task", Priority: "Medium"}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 	subtask := models.Subtask{TaskID: task.ID, Title: "Old Subtask"}
-	if err := database.DB.Create(&subtask).Error; err != nil {
+	if err := db.Create(&subtask).Error; err != nil {
 		t.Fatalf("Failed to create test subtask: %v", err)
 	}
 
@@ -203,12 +177,12 @@ func TestUpdateSubtask(t *testing.T) {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
 			if tt.expectedError != "" {
-				var result map[string]string
+				var result map[string]interface{}
 				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 					t.Fatalf("Failed to decode response: %v", err)
 				}
-				if result["error"] != tt.expectedError {
-					t.Errorf("Expected error %q, got %q", tt.expectedError, result["error"])
+				if result["detail"] != tt.expectedError {
+					t.Errorf("Expected error %q, got %q", tt.expectedError, result["detail"])
 				}
 			}
 		})
@@ -216,15 +190,15 @@ func TestUpdateSubtask(t *testing.T) {
 }
 
 func TestDeleteSubtask(t *testing.T) {
-	app := setupSubtaskTestApp()
+	app, db := setupTestApp()
 
 	// Create a task and subtask for testing
 	task := models.Task{Title: "Test Task", Priority: "Medium"}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 	subtask := models.Subtask{TaskID: task.ID, Title: "Test Subtask"}
-	if err := database.DB.Create(&subtask).Error; err != nil {
+	if err := db.Create(&subtask).Error; err != nil {
 		t.Fatalf("Failed to create test subtask: %v", err)
 	}
 
@@ -265,13 +239,13 @@ func TestDeleteSubtask(t *testing.T) {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
 			if tt.expectedError != "" {
-				var result map[string]string
+				var result map[string]interface{}
 				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 					if resp.StatusCode != fiber.StatusNoContent {
 						t.Fatalf("Failed to decode response: %v", err)
 					}
-				} else if result["error"] != tt.expectedError {
-					t.Errorf("Expected error %q, got %q", tt.expectedError, result["error"])
+				} else if result["detail"] != tt.expectedError {
+					t.Errorf("Expected error %q, got %q", tt.expectedError, result["detail"])
 				}
 			}
 		})
@@ -279,15 +253,15 @@ func TestDeleteSubtask(t *testing.T) {
 }
 
 func TestUpdateSubtaskDone(t *testing.T) {
-	app := setupSubtaskTestApp()
+	app, db := setupTestApp()
 
 	// Create a task and subtask for testing
 	task := models.Task{Title: "Test Task", Priority: "Medium"}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 	subtask := models.Subtask{TaskID: task.ID, Title: "Test Subtask", Done: false}
-	if err := database.DB.Create(&subtask).Error; err != nil {
+	if err := db.Create(&subtask).Error; err != nil {
 		t.Fatalf("Failed to create test subtask: %v", err)
 	}
 
@@ -340,14 +314,14 @@ func TestUpdateSubtaskDone(t *testing.T) {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
 			if tt.expectedError != "" {
-				var result map[string]string
+				var result map[string]interface{}
 				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 					t.Fatalf("Failed to decode response: %v", err)
 				}
-				if result["error"] != tt.expectedError {
-					t.Errorf("Expected error %q, got %q", tt.expectedError, result["error"])
+				if result["detail"] != tt.expectedError {
+					t.Errorf("Expected error %q, got %q", tt.expectedError, result["detail"])
 				}
 			}
 		})
 	}
-}
\ No newline at end of file
+}
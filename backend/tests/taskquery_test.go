@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"todo/internal/handlers"
+	"todo/internal/i18n"
+	"todo/internal/models"
+	"todo/internal/taskquery"
+)
+
+func TestParseTaskQueryErrors(t *testing.T) {
+	for _, tt := range []struct {
+		query  string
+		code   string
+		column int
+	}{
+		{`"landing page`, taskquery.ErrUnterminatedString, 1},
+		{`done OR`, taskquery.ErrUnexpectedEnd, 8},
+		{`(done -overdue`, taskquery.ErrUnclosedParen, 1},
+		{`done)`, taskquery.ErrUnexpectedToken, 5},
+		{`colour:red`, taskquery.ErrUnknownField, 1},
+		{`priority<High`, taskquery.ErrInvalidOperator, 9},
+		{`due:7d`, taskquery.ErrInvalidOperator, 4},
+		{`done priority:Urgent`, taskquery.ErrInvalidValue, 15},
+		{`due<7 days`, taskquery.ErrInvalidValue, 5},
+		{`due<999999999w`, taskquery.ErrInvalidValue, 5},
+		{`created>=-15251w`, taskquery.ErrInvalidValue, 10},
+		{`due<99999999999999999999h`, taskquery.ErrInvalidValue, 5},
+		{`assignee: Alice`, taskquery.ErrMissingValue, 9},
+		{`título:x`, taskquery.ErrUnknownField, 1},
+		{`"ü" colour:red`, taskquery.ErrUnknownField, 5},
+	} {
+		_, err := taskquery.Parse(tt.query)
+		var syntax *taskquery.SyntaxError
+		if !errors.As(err, &syntax) || syntax.Code != tt.code || syntax.Column != tt.column {
+			t.Errorf("%s: expected %s at column %d, got %v", tt.query, tt.code, tt.column, err)
+		}
+		if _, ok := i18n.Message(i18n.Default, "task_query."+tt.code); !ok {
+			t.Errorf("Expected a catalogue message for %s", tt.code)
+		}
+	}
+
+	for _, q := range []string{"", "   ", `priority:high,LOW assignee:"Mary Jane" -done (due<7d OR has:due) NOT overdue`, `created>=-2w updated<=today due:2030-01-31`, `due<15250w`} {
+		if _, err := taskquery.Parse(q); err != nil {
+			t.Errorf("%s: unexpected error %v", q, err)
+		}
+	}
+}
+
+func TestTaskQueryDependsOnClock(t *testing.T) {
+	for q, want := range map[string]bool{
+		"":                                     false,
+		"priority:High -done":                  false,
+		"due:2030-01-31 OR created<2020-01-01": false,
+		"-overdue":                             true,
+		"docs OR (pending due<7d)":             true,
+		"updated>=yesterday":                   true,
+	} {
+		expr, err := taskquery.Parse(q)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", q, err)
+		}
+		if got := taskquery.DependsOnClock(expr); got != want {
+			t.Errorf("%s: expected DependsOnClock %v, got %v", q, want, got)
+		}
+	}
+}
+
+func TestTaskQuery(t *testing.T) {
+	for name, newService := range repositoryBackends {
+		t.Run(name, func(t *testing.T) {
+			svc := newService()
+			now := time.Now()
+			for _, task := range []models.Task{
+				{Title: "Design landing page", Priority: "High", Assignee: "Alice", DueDate: dueAt(now.Add(72 * time.Hour))},
+				{Title: "Ship landing page", Priority: "High", Assignee: "Alice", Done: true, DueDate: dueAt(now.Add(-48 * time.Hour))},
+				{Title: "Fix 100% CPU", Description: "The Landing Page spins", Priority: "Medium", Assignee: "Bob", DueDate: dueAt(now.Add(-time.Hour))},
+				{Title: "Write docs", Priority: "Low", DueDate: dueAt(now.Add(30 * 24 * time.Hour))},
+				{Title: "Plan offsite", Priority: "Low", Assignee: "Mary Jane"},
+			} {
+				if err := svc.CreateTask(&task); err != nil {
+					t.Fatalf("CreateTask failed: %v", err)
+				}
+			}
+
+			for _, tt := range []struct {
+				query  string
+				titles string
+			}{
+				{`priority:High assignee:Alice due<7d -done "landing page"`, "Design landing page"},
+				{`"landing page"`, "Design landing page,Fix 100% CPU,Ship landing page"},
+				{`LANDING`, "Design landing page,Fix 100% CPU,Ship landing page"},
+				{`title:"landing page"`, "Design landing page,Ship landing page"},
+				{`100%`, "Fix 100% CPU"},
+				{`1_0`, ""},
+				{`priority:low,medium`, "Fix 100% CPU,Plan offsite,Write docs"},
+				{`assignee:"Mary Jane"`, "Plan offsite"},
+				{`-has:assignee`, "Write docs"},
+				{`overdue`, "Fix 100% CPU"},
+				{`-overdue pending`, "Design landing page,Plan offsite,Write docs"},
+				{`status:completed`, "Ship landing page"},
+				{`due<7d`, "Design landing page,Fix 100% CPU,Ship landing page"},
+				{`due>=7d`, "Write docs"},
+				{`-due<7d`, "Plan offsite,Write docs"},
+				{`due>=tomorrow -done`, "Design landing page,Write docs"},
+				{`docs OR offsite`, "Plan offsite,Write docs"},
+				{`priority:High OR priority:Low -has:due`, "Design landing page,Plan offsite,Ship landing page"},
+				{`(priority:High OR priority:Low) -has:due`, "Plan offsite"},
+				{`created>=-1h updated<=today`, "Design landing page,Fix 100% CPU,Plan offsite,Ship landing page,Write docs"},
+				{`"'; DROP TABLE tasks; --"`, ""},
+			} {
+				tasks, err := svc.ListTasks(models.TaskFilter{Query: tt.query})
+				if err != nil {
+					t.Errorf("%s: ListTasks failed: %v", tt.query, err)
+					continue
+				}
+				var titles []string
+				for _, task := range tasks {
+					titles = append(titles, task.Title)
+				}
+				sort.Strings(titles)
+				if got := strings.Join(titles, ","); got != tt.titles {
+					t.Errorf("%s: expected %q, got %q", tt.query, tt.titles, got)
+				}
+			}
+
+			// The query narrows the other filters, which still sort.
+			tasks, err := svc.ListTasks(models.TaskFilter{Assignee: "Alice", SortBy: "dueDate", Query: "landing"})
+			if err != nil || len(tasks) != 2 || tasks[0].Title != "Ship landing page" {
+				t.Errorf("Expected Alice's landing page tasks by due date, got %+v, %v", tasks, err)
+			}
+			stats, err := svc.TaskStats(models.TaskFilter{Query: "-done"}, now)
+			if err != nil || stats.Total != 4 || stats.Overdue != 1 {
+				t.Errorf("Expected the query to apply to stats, got %+v, %v", stats, err)
+			}
+
+			_, err = svc.ListTasks(models.TaskFilter{Query: "priority:Urgent"})
+			var syntax *taskquery.SyntaxError
+			if !errors.As(err, &syntax) || syntax.Column != 10 {
+				t.Errorf("Expected a syntax error at column 10, got %v", err)
+			}
+			if err := svc.CreateView(&models.SavedView{Name: "Broken", Definition: models.ViewDefinition{
+				Filter: models.TaskFilter{Query: "(done"}}}, "alice"); !errors.As(err, &syntax) {
+				t.Errorf("Expected a view with a broken query to be rejected, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTaskQueryOverHTTP(t *testing.T) {
+	app, _ := setupTestApp()
+	for _, body := range []string{
+		`{"title":"Launch","priority":"High","assignee":"Alice"}`,
+		`{"title":"Retro","priority":"Low","assignee":"Bob"}`,
+	} {
+		if resp := postJSON(t, app, "/tasks", body, nil); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("Expected 201 creating a task, got %d", resp.StatusCode)
+		}
+	}
+
+	resp := getWithHeaders(t, app, "/tasks?q="+url.QueryEscape("priority:high -done"), nil)
+	var tasks []models.Task
+	if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil || resp.StatusCode != http.StatusOK || len(tasks) != 1 || tasks[0].Title != "Launch" {
+		t.Errorf("Expected only the high priority task, got %d %+v", resp.StatusCode, tasks)
+	}
+
+	resp = getWithHeaders(t, app, "/tasks?q="+url.QueryEscape("done OR colour:red"), map[string]string{"Accept-Language": "de"})
+	var p handlers.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || resp.StatusCode != http.StatusBadRequest ||
+		p.Code != handlers.CodeInvalidTaskQuery || p.Column != 9 || !strings.Contains(p.Detail, "Spalte 9") {
+		t.Errorf("Expected a localized 400 invalid_task_query problem at column 9, got %d %+v", resp.StatusCode, p)
+	}
+
+	resp = getWithHeaders(t, app, "/stats?q="+url.QueryEscape("assignee:Bob"), nil)
+	var stats models.TaskStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil || resp.StatusCode != http.StatusOK || stats.Total != 1 {
+		t.Errorf("Expected stats of Bob's task, got %d %+v", resp.StatusCode, stats)
+	}
+
+	resp = postJSON(t, app, "/views", `{"name":"Broken","visibility":"shared","definition":{"filter":{"q":"due<soon"}}}`, nil)
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || resp.StatusCode != http.StatusBadRequest ||
+		p.Code != handlers.CodeInvalidTaskQuery || p.Column != 5 {
+		t.Errorf("Expected a view with a broken query to be rejected at column 5, got %d %+v", resp.StatusCode, p)
+	}
+}
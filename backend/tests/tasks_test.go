@@ -5,47 +5,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"todo/internal/database"
-	"todo/internal/handlers"
+	"strings"
+	"time"
 	"todo/internal/models"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 	"testing"
 )
 
-func setupTestApp() *fiber.App {
-	// Initialize in-memory SQLite database
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	if err != nil {
-		panic("failed to connect to test database")
-	}
-	database.DB = db
-	db.AutoMigrate(&models.Task{}, &models.Subtask{})
-
-	// Initialize Fiber app
-	app := fiber.New()
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "http://localhost:5173",
-		AllowMethods: "GET,POST,PUT,DELETE,PATCH",
-		AllowHeaders: "Content-Type",
-	}))
-
-	// Register task routes
-	app.Post("/tasks", handlers.CreateTask)
-	app.Get("/tasks", handlers.GetTasks)
-	app.Get("/tasks/:id", handlers.GetTaskByID)
-	app.Put("/tasks/:id", handlers.UpdateTask)
-	app.Delete("/tasks/:id", handlers.DeleteTask)
-	app.Patch("/tasks/:id/done", handlers.UpdateTaskDone)
-
-	return app
-}
-
 func TestCreateTask(t *testing.T) {
-	app := setupTestApp()
+	app, _ := setupTestApp()
 
 	tests := []struct {
 		name           string
@@ -63,13 +32,13 @@ func TestCreateTask(t *testing.T) {
 			name:           "Invalid priority",
 			body:           `{"title":"Test Task","priority":"Invalid"}`,
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Key: 'Task.Priority' Error:Field validation for 'Priority' failed on the 'oneof' tag",
+			expectedError:  "priority must be one of Low, Medium, High",
 		},
 		{
 			name:           "Missing title",
 			body:           `{"priority":"High"}`,
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Key: 'Task.Title' Error:Field validation for 'Title' failed on the 'required' tag",
+			expectedError:  "title is required",
 		},
 		{
 			name:           "Invalid JSON",
@@ -91,12 +60,12 @@ func TestCreateTask(t *testing.T) {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
 			if tt.expectedError != "" {
-				var result map[string]string
+				var result map[string]interface{}
 				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 					t.Fatalf("Failed to decode response: %v", err)
 				}
-				if result["error"] != tt.expectedError {
-					t.Errorf("Expected error %q, got %q", tt.expectedError, result["error"])
+				if result["detail"] != tt.expectedError {
+					t.Errorf("Expected error %q, got %q", tt.expectedError, result["detail"])
 				}
 			}
 		})
@@ -104,11 +73,11 @@ func TestCreateTask(t *testing.T) {
 }
 
 func TestGetTasks(t *testing.T) {
-	app := setupTestApp()
+	app, db := setupTestApp()
 
 	// Create a task for testing
 	task := models.Task{Title: "Test Task", Priority: "Medium"}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 
@@ -134,11 +103,11 @@ func TestGetTasks(t *testing.T) {
 }
 
 func TestGetTaskByID(t *testing.T) {
-	app := setupTestApp()
+	app, db := setupTestApp()
 
 	// Create a task for testing
 	task := models.Task{Title: "Test Task", Priority: "High"}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 
@@ -179,12 +148,12 @@ func TestGetTaskByID(t *testing.T) {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
 			if tt.expectedError != "" {
-				var result map[string]string
+				var result map[string]interface{}
 				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 					t.Fatalf("Failed to decode response: %v", err)
 				}
-				if result["error"] != tt.expectedError {
-					t.Errorf("Expected error %q, got %q", tt.expectedError, result["error"])
+				if result["detail"] != tt.expectedError {
+					t.Errorf("Expected error %q, got %q", tt.expectedError, result["detail"])
 				}
 			}
 		})
@@ -192,11 +161,11 @@ func TestGetTaskByID(t *testing.T) {
 }
 
 func TestUpdateTask(t *testing.T) {
-	app := setupTestApp()
+	app, db := setupTestApp()
 
 	// Create a task for testing
 	task := models.Task{Title: "Old Task", Priority: "Low"}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 
@@ -249,12 +218,12 @@ func TestUpdateTask(t *testing.T) {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
 			if tt.expectedError != "" {
-				var result map[string]string
+				var result map[string]interface{}
 				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 					t.Fatalf("Failed to decode response: %v", err)
 				}
-				if result["error"] != tt.expectedError {
-					t.Errorf("Expected error %q, got %q", tt.expectedError, result["error"])
+				if result["detail"] != tt.expectedError {
+					t.Errorf("Expected error %q, got %q", tt.expectedError, result["detail"])
 				}
 			}
 		})
@@ -262,11 +231,11 @@ func TestUpdateTask(t *testing.T) {
 }
 
 func TestDeleteTask(t *testing.T) {
-	app := setupTestApp()
+	app, db := setupTestApp()
 
 	// Create a task for testing
 	task := models.Task{Title: "Test Task", Priority: "Medium"}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 
@@ -307,13 +276,13 @@ func TestDeleteTask(t *testing.T) {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
 			if tt.expectedError != "" {
-				var result map[string]string
+				var result map[string]interface{}
 				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 					if resp.StatusCode != fiber.StatusNoContent {
 						t.Fatalf("Failed to decode response: %v", err)
 					}
-				} else if result["error"] != tt.expectedError {
-					t.Errorf("Expected error %q, got %q", tt.expectedError, result["error"])
+				} else if result["detail"] != tt.expectedError {
+					t.Errorf("Expected error %q, got %q", tt.expectedError, result["detail"])
 				}
 			}
 		})
@@ -321,11 +290,11 @@ func TestDeleteTask(t *testing.T) {
 }
 
 func TestUpdateTaskDone(t *testing.T) {
-	app := setupTestApp()
+	app, db := setupTestApp()
 
 	// Create a task for testing
 	task := models.Task{Title: "Test Task", Priority: "Medium", Done: false}
-	if err := database.DB.Create(&task).Error; err != nil {
+	if err := db.Create(&task).Error; err != nil {
 		t.Fatalf("Failed to create test task: %v", err)
 	}
 
@@ -378,14 +347,99 @@ func TestUpdateTaskDone(t *testing.T) {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
 			}
 			if tt.expectedError != "" {
-				var result map[string]string
+				var result map[string]interface{}
 				if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 					t.Fatalf("Failed to decode response: %v", err)
 				}
-				if result["error"] != tt.expectedError {
-					t.Errorf("Expected error %q, got %q", tt.expectedError, result["error"])
+				if result["detail"] != tt.expectedError {
+					t.Errorf("Expected error %q, got %q", tt.expectedError, result["detail"])
 				}
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestGetTasksFilters(t *testing.T) {
+	app, db := setupTestApp()
+
+	// Create tasks for testing
+	due := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	for _, task := range []models.Task{
+		{Title: "Low", Description: "Renew the passport", Priority: "Low", Assignee: "Alice", DueDate: dueAt(due)},
+		{Title: "High", Priority: "High", Assignee: "Bob", Done: true, DueDate: dueAt(due.AddDate(0, 0, 1))},
+		{Title: "Medium", Priority: "Medium", Assignee: "Alice"},
+	} {
+		if err := db.Create(&task).Error; err != nil {
+			t.Fatalf("Failed to create test task: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedTitles []string
+	}{
+		{
+			name:           "By assignee",
+			query:          "assignee=Alice",
+			expectedStatus: http.StatusOK,
+			expectedTitles: []string{"Low", "Medium"},
+		},
+		{
+			name:           "Search",
+			query:          "search=passport",
+			expectedStatus: http.StatusOK,
+			expectedTitles: []string{"Low"},
+		},
+		{
+			name:           "Completed",
+			query:          "status=completed",
+			expectedStatus: http.StatusOK,
+			expectedTitles: []string{"High"},
+		},
+		{
+			name:           "Sort by priority",
+			query:          "sortBy=priority",
+			expectedStatus: http.StatusOK,
+			expectedTitles: []string{"High", "Medium", "Low"},
+		},
+		{
+			name:           "Sort by due date",
+			query:          "sortBy=dueDate",
+			expectedStatus: http.StatusOK,
+			expectedTitles: []string{"Low", "High", "Medium"},
+		},
+		{
+			name:           "Invalid status",
+			query:          "status=unknown",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks?"+tt.query, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Failed to execute request: %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedTitles == nil {
+				return
+			}
+			var tasks []models.Task
+			if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			var titles []string
+			for _, task := range tasks {
+				titles = append(titles, task.Title)
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.expectedTitles, ",") {
+				t.Errorf("Expected tasks %v, got %v", tt.expectedTitles, titles)
+			}
+		})
+	}
+}
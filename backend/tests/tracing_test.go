@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo/internal/config"
+	"todo/internal/handlers"
+	"todo/internal/models"
+	"todo/internal/tracing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const incomingTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// newTracedTestApp returns the HTTP API tracing into the returned recorder,
+// with access logs written to the returned buffer.
+func newTracedTestApp(t *testing.T) (*fiber.App, *tracetest.SpanRecorder, *bytes.Buffer) {
+	t.Helper()
+	svc, db := newTestService()
+	db.Create(&models.Task{Title: "Traced", Priority: "High", Subtasks: []models.Subtask{{Title: "Child"}}})
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	if err := tracing.InstrumentDB(db, tp); err != nil {
+		t.Fatalf("InstrumentDB failed: %v", err)
+	}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	app := handlers.NewApp(handlers.New(svc), nil, handlers.WithLogger(logger), handlers.WithTracerProvider(tp))
+	return app, recorder, &logs
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingSpans(t *testing.T) {
+	app, recorder, logs := newTracedTestApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req.Header.Set("traceparent", incomingTraceparent)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	if got := resp.Header.Get("traceparent"); !strings.Contains(got, traceID) {
+		t.Errorf("Expected the trace to be propagated in the response, got %q", got)
+	}
+
+	var server sdktrace.ReadOnlySpan
+	var queries []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "GET /tasks" {
+			server = span
+		} else if strings.HasPrefix(span.Name(), "gorm.") {
+			queries = append(queries, span)
+		}
+	}
+	if server == nil {
+		t.Fatalf("Expected a server span named after the route, got %v", recorder.Ended())
+	}
+	if server.SpanContext().TraceID().String() != traceID || server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the server span to continue the incoming trace, got %v", server.SpanContext())
+	}
+	if got := spanAttribute(server, "http.response.status_code").AsInt64(); got != http.StatusOK {
+		t.Errorf("Expected status attribute 200, got %d", got)
+	}
+
+	// The task list and the preloaded subtasks are separate queries.
+	var names []string
+	for _, q := range queries {
+		names = append(names, q.Name())
+		if q.Parent().SpanID() != server.SpanContext().SpanID() {
+			t.Errorf("Expected %s to be a child of the server span", q.Name())
+		}
+		if spanAttribute(q, "db.query.text").AsString() == "" {
+			t.Errorf("Expected the statement of %s", q.Name())
+		}
+	}
+	if !equalStrings(names, []string{"gorm.query tasks", "gorm.query subtasks"}) {
+		t.Errorf("Expected query spans for tasks and subtasks, got %v", names)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to decode access log: %v", err)
+	}
+	if record["trace_id"] != traceID {
+		t.Errorf("Expected the access log to carry the trace ID, got %v", record)
+	}
+}
+
+func TestTracingErrors(t *testing.T) {
+	app, recorder, _ := newTracedTestApp(t)
+
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/tasks/99", nil)); err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	for _, span := range recorder.Ended() {
+		if span.Status().Code != 0 {
+			t.Errorf("Expected a missing task not to mark %s as failed, got %v", span.Name(), span.Status())
+		}
+	}
+}
+
+func TestTracingExporters(t *testing.T) {
+	var out bytes.Buffer
+	tp, shutdown, err := tracing.New(context.Background(), config.Tracing{Exporter: "stdout", SampleRatio: 1}, &out)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	_, span := tp.Tracer("test").Start(context.Background(), "exported")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"Name":"exported"`) || !strings.Contains(out.String(), tracing.ServiceName) {
+		t.Errorf("Expected the span on stdout, got %s", out.String())
+	}
+
+	tp, _, err = tracing.New(context.Background(), config.Tracing{Exporter: "none"}, &out)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, span := tp.Tracer("test").Start(context.Background(), "dropped"); span.IsRecording() {
+		t.Error("Expected the none exporter not to record spans")
+	}
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo/internal/handlers"
+	"todo/internal/models"
+	"todo/internal/services"
+)
+
+func TestSavedViews(t *testing.T) {
+	for name, newService := range repositoryBackends {
+		t.Run(name, func(t *testing.T) {
+			svc := newService()
+			for _, task := range []models.Task{
+				{Title: "Write report", Priority: "Low", Assignee: "Bob"},
+				{Title: "Fix login", Priority: "High", Assignee: "Alice"},
+				{Title: "Plan sprint", Priority: "Medium", Assignee: "Alice", Done: true},
+				{Title: "Review PR", Priority: "High"},
+			} {
+				if err := svc.CreateTask(&task); err != nil {
+					t.Fatalf("CreateTask failed: %v", err)
+				}
+			}
+
+			private := models.SavedView{Name: "Mine", Definition: models.ViewDefinition{
+				Filter: models.TaskFilter{Status: "pending"}, GroupBy: "priority"}}
+			shared := models.SavedView{Name: "Alice", Visibility: models.VisibilityShared, Definition: models.ViewDefinition{
+				Filter: models.TaskFilter{Assignee: "Alice"}, GroupBy: "status"}}
+			for _, view := range []*models.SavedView{&private, &shared} {
+				if err := svc.CreateView(view, "alice"); err != nil {
+					t.Fatalf("CreateView failed: %v", err)
+				}
+			}
+			if private.Visibility != models.VisibilityPrivate || !private.Owned {
+				t.Errorf("Expected an owned private view, got %+v", private)
+			}
+
+			views, err := svc.ListViews("bob")
+			if err != nil || len(views) != 1 || views[0].ID != shared.ID || views[0].Owned {
+				t.Errorf("Expected bob to see only the shared view, got %+v, %v", views, err)
+			}
+			if views, err := svc.ListViews("alice"); err != nil || len(views) != 2 || views[0].Name != "Alice" || !views[1].Owned {
+				t.Errorf("Expected alice to see both views by name, got %+v, %v", views, err)
+			}
+			if _, err := svc.GetView(private.ID, "bob"); !errors.Is(err, services.ErrViewNotFound) {
+				t.Errorf("Expected the private view to be hidden from bob, got %v", err)
+			}
+			view, err := svc.GetView(shared.ID, "bob")
+			if err != nil {
+				t.Fatalf("GetView failed: %v", err)
+			}
+			if err := svc.UpdateView(&view, models.SavedView{Name: "Taken"}, "bob"); !errors.Is(err, services.ErrNotViewOwner) {
+				t.Errorf("Expected bob not to change alice's view, got %v", err)
+			}
+			if err := svc.DeleteView(&view, "bob"); !errors.Is(err, services.ErrNotViewOwner) {
+				t.Errorf("Expected bob not to delete alice's view, got %v", err)
+			}
+
+			tasks, err := svc.ViewTasks(private)
+			if err != nil {
+				t.Fatalf("ViewTasks failed: %v", err)
+			}
+			if len(tasks.Groups) != 2 || tasks.Groups[0].Name != "High" || len(tasks.Groups[0].Tasks) != 2 || tasks.Groups[1].Name != "Low" {
+				t.Errorf("Expected High then Low pending tasks, got %+v", tasks.Groups)
+			}
+			tasks, err = svc.ViewTasks(shared)
+			if err != nil || len(tasks.Groups) != 2 || tasks.Groups[0].Name != "pending" || tasks.Groups[1].Tasks[0].Title != "Plan sprint" {
+				t.Errorf("Expected alice's pending then completed tasks, got %+v, %v", tasks.Groups, err)
+			}
+
+			private.Definition.GroupBy = ""
+			private.Definition.Filter.SortBy = "priority"
+			if err := svc.UpdateView(&private, private, "alice"); err != nil {
+				t.Fatalf("UpdateView failed: %v", err)
+			}
+			tasks, err = svc.ViewTasks(private)
+			if err != nil || len(tasks.Groups) != 1 || tasks.Groups[0].Name != "" || tasks.Groups[0].Tasks[2].Title != "Write report" {
+				t.Errorf("Expected one group in priority order, got %+v, %v", tasks.Groups, err)
+			}
+
+			if err := svc.DeleteView(&private, "alice"); err != nil {
+				t.Fatalf("DeleteView failed: %v", err)
+			}
+			if _, err := svc.GetView(private.ID, "alice"); !errors.Is(err, services.ErrViewNotFound) {
+				t.Errorf("Expected the deleted view to be gone, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSavedViewValidation(t *testing.T) {
+	svc, _ := newTestService()
+	for _, view := range []models.SavedView{
+		{},
+		{Name: strings.Repeat("x", 101)},
+		{Name: "Bad visibility", Visibility: "public"},
+		{Name: "Bad group", Definition: models.ViewDefinition{GroupBy: "title"}},
+		{Name: "Bad filter", Definition: models.ViewDefinition{Filter: models.TaskFilter{SortBy: "title"}}},
+	} {
+		if err := svc.CreateView(&view, "alice"); !services.IsValidationError(err) {
+			t.Errorf("Expected %+v to be rejected, got %v", view, err)
+		}
+	}
+}
+
+func TestSavedViewsOverHTTP(t *testing.T) {
+	app, _ := setupTestApp()
+	alice := map[string]string{"Authorization": "Bearer alice-token"}
+	bob := map[string]string{"Authorization": "Bearer bob-token"}
+	if resp := postJSON(t, app, "/tasks", `{"title":"Fix login","priority":"High","assignee":"Alice"}`, nil); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a task, got %d", resp.StatusCode)
+	}
+
+	resp := postJSON(t, app, "/views", `{"name":"Alice","definition":{"filter":{"assignee":"Alice"},"group_by":"assignee"}}`, alice)
+	var view models.SavedView
+	if err := json.NewDecoder(resp.Body).Decode(&view); err != nil || resp.StatusCode != http.StatusCreated || !view.Owned {
+		t.Fatalf("Expected 201 with an owned view, got %d %+v", resp.StatusCode, view)
+	}
+	path := fmt.Sprintf("/views/%d", view.ID)
+
+	if resp := getWithHeaders(t, app, path, bob); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for someone else's private view, got %d", resp.StatusCode)
+	}
+	if resp := getWithHeaders(t, app, path+"/tasks", bob); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for the tasks of someone else's private view, got %d", resp.StatusCode)
+	}
+
+	resp = getWithHeaders(t, app, path+"/tasks", alice)
+	var tasks models.ViewTasks
+	if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil || resp.StatusCode != http.StatusOK ||
+		len(tasks.Groups) != 1 || tasks.Groups[0].Name != "Alice" || resp.Header.Get("ETag") == "" {
+		t.Errorf("Expected one cacheable group of Alice's tasks, got %d %+v", resp.StatusCode, tasks)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(`{"name":"Alice","visibility":"shared"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", alice["Authorization"])
+	if resp, err := app.Test(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200 sharing the view, got %v, %v", resp, err)
+	}
+	if resp := getWithHeaders(t, app, path, bob); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected bob to see the shared view, got %d", resp.StatusCode)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, path, nil)
+	req.Header.Set("Authorization", bob["Authorization"])
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to execute request: %v", err)
+	}
+	var p handlers.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || resp.StatusCode != http.StatusForbidden || p.Code != handlers.CodeNotViewOwner {
+		t.Errorf("Expected a 403 not_view_owner problem, got %d %+v", resp.StatusCode, p)
+	}
+
+	resp = postJSON(t, app, "/views", `{"name":"Grouped","definition":{"group_by":"title"}}`, alice)
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || resp.StatusCode != http.StatusBadRequest || p.Code != handlers.CodeValidation {
+		t.Errorf("Expected a 400 validation problem, got %d %+v", resp.StatusCode, p)
+	}
+}
+
+func TestPrivateViewsNeedToken(t *testing.T) {
+	app, _ := setupTestApp()
+
+	// Callers known only by IP address may not own private views.
+	resp := postJSON(t, app, "/views", `{"name":"Mine"}`, nil)
+	var p handlers.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil || resp.StatusCode != http.StatusUnauthorized ||
+		p.Code != handlers.CodeTokenRequired || resp.Header.Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("Expected a 401 token_required problem, got %d %+v", resp.StatusCode, p)
+	}
+
+	resp = postJSON(t, app, "/views", `{"name":"Team","visibility":"shared"}`, nil)
+	var view models.SavedView
+	if err := json.NewDecoder(resp.Body).Decode(&view); err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201 for a shared view, got %d", resp.StatusCode)
+	}
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/views/%d", view.ID), strings.NewReader(`{"name":"Team","visibility":"private"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err := app.Test(req); err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 making the view private without a token, got %v, %v", resp, err)
+	}
+}